@@ -55,12 +55,12 @@ func parserTest() {
 		}
 		fmt.Println("parsing devfile from ./devfile.yaml")
 	}
-	devfile, warning, err := devfilepkg.ParseDevfileAndValidate(args)
+	devfile, _, err := devfilepkg.ParseDevfileAndValidate(args)
 	if err != nil {
 		fmt.Println(err)
 	} else {
-		if len(warning.Commands) > 0 || len(warning.Components) > 0 || len(warning.Projects) > 0 || len(warning.StarterProjects) > 0 {
-			fmt.Printf("top-level variables were not substituted successfully %+v\n", warning)
+		for _, warning := range devfile.Warnings {
+			fmt.Printf("warning %s: %s\n", warning.Code, warning.Message)
 		}
 		devdata := devfile.Data
 		if (reflect.TypeOf(devdata) == reflect.TypeOf(&v2.DevfileV2{})) {
@@ -0,0 +1,294 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	devfileData "github.com/devfile/library/v2/pkg/devfile/parser/data"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/pkg/errors"
+)
+
+// ChangeType is the type of a change between two devfiles
+type ChangeType string
+
+const (
+	// Added is the type of the changes about fields and elements only defined in the new devfile
+	Added ChangeType = "added"
+	// Removed is the type of the changes about fields and elements only defined in the old devfile
+	Removed ChangeType = "removed"
+	// Modified is the type of the changes about fields and elements defined with different values in both devfiles
+	Modified ChangeType = "modified"
+)
+
+// FieldChange is a change of a field between two devfiles
+type FieldChange struct {
+	// Type is the type of the change
+	Type ChangeType `json:"type"`
+	// Path is the path of the field, relative to the element it belongs to for the changes of an element. The fields are
+	// separated by dots, and the items of the lists of named items, e.g. environment variables or endpoints, are identified
+	// by their name in brackets, e.g. container.env[DEBUG].value
+	Path string `json:"path"`
+	// Old is the value of the field in the old devfile, nil for added fields
+	Old interface{} `json:"old,omitempty"`
+	// New is the value of the field in the new devfile, nil for removed fields
+	New interface{} `json:"new,omitempty"`
+}
+
+// MarshalJSON encodes the change with its old value unless it is added and its new value unless it is removed,
+// so that values such as false or 0 are not left out
+func (c FieldChange) MarshalJSON() ([]byte, error) {
+	change := struct {
+		Type ChangeType   `json:"type"`
+		Path string       `json:"path"`
+		Old  *interface{} `json:"old,omitempty"`
+		New  *interface{} `json:"new,omitempty"`
+	}{Type: c.Type, Path: c.Path}
+	if c.Type != Added {
+		change.Old = &c.Old
+	}
+	if c.Type != Removed {
+		change.New = &c.New
+	}
+	return json.Marshal(change)
+}
+
+// ElementChange is a change of a component, command, project or starter project between two devfiles
+type ElementChange struct {
+	// Type is the type of the change
+	Type ChangeType `json:"type"`
+	// Name is the name of the element, or the id of the command
+	Name string `json:"name"`
+	// Changes are the changes of the fields of a modified element
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// DevfileDiff is the semantic difference between two devfiles. The changes of each section are sorted by path or name.
+type DevfileDiff struct {
+	// Metadata are the changes of the metadata fields
+	Metadata []FieldChange `json:"metadata,omitempty"`
+	// Attributes are the changes of the top-level attributes, by key
+	Attributes []FieldChange `json:"attributes,omitempty"`
+	// Variables are the changes of the variables, by name
+	Variables []FieldChange `json:"variables,omitempty"`
+	// Components are the changes of the components, by name
+	Components []ElementChange `json:"components,omitempty"`
+	// Commands are the changes of the commands, by id
+	Commands []ElementChange `json:"commands,omitempty"`
+	// Projects are the changes of the projects, by name
+	Projects []ElementChange `json:"projects,omitempty"`
+	// StarterProjects are the changes of the starter projects, by name
+	StarterProjects []ElementChange `json:"starterProjects,omitempty"`
+	// Events are the changes of the commands bound to each event, e.g. postStart
+	Events []FieldChange `json:"events,omitempty"`
+}
+
+// IsEmpty returns true if the devfiles have no differences
+func (d DevfileDiff) IsEmpty() bool {
+	return len(d.Metadata) == 0 && len(d.Attributes) == 0 && len(d.Variables) == 0 && len(d.Components) == 0 &&
+		len(d.Commands) == 0 && len(d.Projects) == 0 && len(d.StarterProjects) == 0 && len(d.Events) == 0
+}
+
+// Compare returns the semantic difference between the old and the new devfile data
+func Compare(oldData devfileData.DevfileData, newData devfileData.DevfileData) (*DevfileDiff, error) {
+	oldSections, err := devfileSections(oldData)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the old devfile")
+	}
+	newSections, err := devfileSections(newData)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the new devfile")
+	}
+
+	diff := &DevfileDiff{}
+	compareValues("", oldSections["metadata"], newSections["metadata"], &diff.Metadata)
+	compareValues("", oldSections["attributes"], newSections["attributes"], &diff.Attributes)
+	compareValues("", oldSections["variables"], newSections["variables"], &diff.Variables)
+	compareValues("", oldSections["events"], newSections["events"], &diff.Events)
+	for section, changes := range map[string]*[]ElementChange{
+		"components":      &diff.Components,
+		"commands":        &diff.Commands,
+		"projects":        &diff.Projects,
+		"starterProjects": &diff.StarterProjects,
+	} {
+		if *changes, err = compareElements(oldSections[section], newSections[section]); err != nil {
+			return nil, errors.Wrapf(err, "failed to compare the %s", section)
+		}
+	}
+	return diff, nil
+}
+
+// devfileSections returns the JSON values of the sections of the devfile data that are compared, by name
+func devfileSections(data devfileData.DevfileData) (map[string]interface{}, error) {
+	components, err := data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		return nil, err
+	}
+	commands, err := data.GetCommands(common.DevfileOptions{})
+	if err != nil {
+		return nil, err
+	}
+	projects, err := data.GetProjects(common.DevfileOptions{})
+	if err != nil {
+		return nil, err
+	}
+	starterProjects, err := data.GetStarterProjects(common.DevfileOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// top-level attributes and variables are read from the spec, as the getters do not support schema version 2.0.0
+	spec := data.GetDevfileWorkspaceSpec()
+	content, err := json.Marshal(map[string]interface{}{
+		"metadata":        data.GetMetadata(),
+		"attributes":      spec.Attributes,
+		"variables":       spec.Variables,
+		"events":          data.GetEvents(),
+		"components":      components,
+		"commands":        commands,
+		"projects":        projects,
+		"starterProjects": starterProjects,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var sections map[string]interface{}
+	if err = json.Unmarshal(content, &sections); err != nil {
+		return nil, err
+	}
+	// undefined maps are compared as empty ones, so that their keys are reported one by one
+	for _, section := range []string{"attributes", "variables"} {
+		if sections[section] == nil {
+			sections[section] = map[string]interface{}{}
+		}
+	}
+	return sections, nil
+}
+
+// compareElements returns the changes of the elements of two lists, identified by their name or id
+func compareElements(oldValue interface{}, newValue interface{}) ([]ElementChange, error) {
+	oldElements, err := namedItems(oldValue)
+	if err != nil {
+		return nil, err
+	}
+	newElements, err := namedItems(newValue)
+	if err != nil {
+		return nil, err
+	}
+	var changes []ElementChange
+	for _, name := range sortedKeys(oldElements, newElements) {
+		oldElement, inOld := oldElements[name]
+		newElement, inNew := newElements[name]
+		switch {
+		case !inOld:
+			changes = append(changes, ElementChange{Type: Added, Name: name})
+		case !inNew:
+			changes = append(changes, ElementChange{Type: Removed, Name: name})
+		default:
+			var fieldChanges []FieldChange
+			compareValues("", oldElement, newElement, &fieldChanges)
+			if len(fieldChanges) > 0 {
+				changes = append(changes, ElementChange{Type: Modified, Name: name, Changes: fieldChanges})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// compareValues appends the changes between two JSON values at path to changes. Objects are compared field by field,
+// lists of named items item by item, and the other values as a whole.
+func compareValues(path string, oldValue interface{}, newValue interface{}, changes *[]FieldChange) {
+	if reflect.DeepEqual(oldValue, newValue) {
+		return
+	}
+	oldItems, oldErr := namedItems(oldValue)
+	newItems, newErr := namedItems(newValue)
+	if oldErr == nil && newErr == nil && (len(oldItems) > 0 || len(newItems) > 0) {
+		for _, name := range sortedKeys(oldItems, newItems) {
+			compareValues(fmt.Sprintf("%s[%s]", path, name), oldItems[name], newItems[name], changes)
+		}
+		return
+	}
+
+	switch {
+	case oldValue == nil:
+		*changes = append(*changes, FieldChange{Type: Added, Path: path, New: newValue})
+		return
+	case newValue == nil:
+		*changes = append(*changes, FieldChange{Type: Removed, Path: path, Old: oldValue})
+		return
+	}
+
+	oldObject, oldIsObject := oldValue.(map[string]interface{})
+	newObject, newIsObject := newValue.(map[string]interface{})
+	if oldIsObject && newIsObject {
+		for _, key := range sortedKeys(oldObject, newObject) {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			compareValues(fieldPath, oldObject[key], newObject[key], changes)
+		}
+		return
+	}
+	*changes = append(*changes, FieldChange{Type: Modified, Path: path, Old: oldValue, New: newValue})
+}
+
+// namedItems returns the items of a JSON list of objects by their unique name or id, e.g. components or environment
+// variables. It returns an error if the value is not such a list.
+func namedItems(value interface{}) (map[string]interface{}, error) {
+	items := map[string]interface{}{}
+	if value == nil {
+		return items, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list, got %T", value)
+	}
+	for _, item := range list {
+		object, _ := item.(map[string]interface{})
+		name, ok := object["name"].(string)
+		if !ok {
+			name, ok = object["id"].(string)
+		}
+		if !ok {
+			return nil, fmt.Errorf("expected a list of named items")
+		}
+		if _, duplicate := items[name]; duplicate {
+			return nil, fmt.Errorf("duplicate item %s", name)
+		}
+		items[name] = item
+	}
+	return items, nil
+}
+
+// sortedKeys returns the keys of both maps, sorted
+func sortedKeys(a map[string]interface{}, b map[string]interface{}) []string {
+	var keys []string
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
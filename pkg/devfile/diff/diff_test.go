@@ -0,0 +1,199 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const oldDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+  version: 1.0.0
+attributes:
+  team: web
+variables:
+  PORT: "3000"
+components:
+  - name: runtime
+    container:
+      image: node:16
+      mountSources: true
+      env:
+        - name: MODE
+          value: dev
+      endpoints:
+        - name: http
+          targetPort: 3000
+  - name: cache
+    container:
+      image: redis
+commands:
+  - id: run
+    exec:
+      component: runtime
+      commandLine: npm start
+events:
+  postStart: [run]
+projects:
+  - name: app
+    git:
+      remotes:
+        origin: https://github.com/devfile/app
+`
+
+const newDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+  version: 1.1.0
+  displayName: Node.js
+attributes:
+  team: web
+  owner: devtools
+variables:
+  PORT: "8080"
+components:
+  - name: runtime
+    container:
+      image: node:18
+      mountSources: false
+      env:
+        - name: MODE
+          value: dev
+        - name: DEBUG
+          value: "true"
+      endpoints:
+        - name: http
+          targetPort: 8080
+  - name: database
+    container:
+      image: postgres
+commands:
+  - id: run
+    exec:
+      component: runtime
+      commandLine: npm start
+projects:
+  - name: app
+    git:
+      remotes:
+        origin: https://github.com/devfile/app
+`
+
+func TestCompare(t *testing.T) {
+	falseValue := false
+	parse := func(content string) parser.DevfileObj {
+		d, err := parser.ParseDevfile(parser.ParserArgs{Data: []byte(content), SetBooleanDefaults: &falseValue})
+		if err != nil {
+			t.Fatalf("TestCompare() unexpected error parsing the devfile: %v", err)
+		}
+		return d
+	}
+	oldObj := parse(oldDevfile)
+	newObj := parse(newDevfile)
+
+	tests := []struct {
+		name      string
+		oldObj    parser.DevfileObj
+		newObj    parser.DevfileObj
+		want      *DevfileDiff
+		wantEmpty bool
+	}{
+		{
+			name:      "same devfile",
+			oldObj:    oldObj,
+			newObj:    parse(oldDevfile),
+			want:      &DevfileDiff{},
+			wantEmpty: true,
+		},
+		{
+			name:   "changed devfile",
+			oldObj: oldObj,
+			newObj: newObj,
+			want: &DevfileDiff{
+				Metadata: []FieldChange{
+					{Type: Added, Path: "displayName", New: "Node.js"},
+					{Type: Modified, Path: "version", Old: "1.0.0", New: "1.1.0"},
+				},
+				Attributes: []FieldChange{
+					{Type: Added, Path: "owner", New: "devtools"},
+				},
+				Variables: []FieldChange{
+					{Type: Modified, Path: "PORT", Old: "3000", New: "8080"},
+				},
+				Components: []ElementChange{
+					{Type: Removed, Name: "cache"},
+					{Type: Added, Name: "database"},
+					{Type: Modified, Name: "runtime", Changes: []FieldChange{
+						{Type: Modified, Path: "container.endpoints[http].targetPort", Old: float64(3000), New: float64(8080)},
+						{Type: Added, Path: "container.env[DEBUG]", New: map[string]interface{}{"name": "DEBUG", "value": "true"}},
+						{Type: Modified, Path: "container.image", Old: "node:16", New: "node:18"},
+						{Type: Modified, Path: "container.mountSources", Old: true, New: false},
+					}},
+				},
+				Events: []FieldChange{
+					{Type: Removed, Path: "postStart", Old: []interface{}{"run"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compare(tt.oldObj.Data, tt.newObj.Data)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got, "TestCompare(): diff should match")
+			assert.Equal(t, tt.wantEmpty, got.IsEmpty(), "TestCompare(): IsEmpty() should match")
+		})
+	}
+}
+
+func TestFieldChange_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		change FieldChange
+		want   string
+	}{
+		{
+			name:   "added",
+			change: FieldChange{Type: Added, Path: "container.dedicatedPod", New: false},
+			want:   `{"type":"added","path":"container.dedicatedPod","new":false}`,
+		},
+		{
+			name:   "removed",
+			change: FieldChange{Type: Removed, Path: "container.dedicatedPod", Old: true},
+			want:   `{"type":"removed","path":"container.dedicatedPod","old":true}`,
+		},
+		{
+			name:   "modified",
+			change: FieldChange{Type: Modified, Path: "container.endpoints[http].targetPort", Old: float64(0), New: float64(8080)},
+			want:   `{"type":"modified","path":"container.endpoints[http].targetPort","old":0,"new":8080}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.change)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, string(got))
+			}
+		})
+	}
+}
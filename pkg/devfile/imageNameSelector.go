@@ -25,6 +25,7 @@ import (
 	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/devfile/library/v2/pkg/devfile/parser"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
 	"github.com/distribution/reference"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -62,7 +63,9 @@ var k8sSerializer = json.NewSerializerWithOptions(
 // in order to perform any replacements for matching image names.
 // At the moment, this function only supports replacements in Kubernetes native resource types (Pod, CronJob, Job, DaemonSet; Deployment, ReplicaSet, ReplicationController, StatefulSet).
 //
-// Absolute images and non-matching image references are left unchanged.
+// Absolute images and non-matching image references are left unchanged. A warning is added to the devfile for each relative
+// image that matches no component, and for each Kubernetes or OpenShift component that references its resources with a uri,
+// as they cannot be replaced.
 //
 // And the replacement is done by using the following format: "<registry>/<devfileName>-<baseImageName>:<imageTag>",
 // where both <registry>  and <imageTag>  are set by the tool itself (either via auto-detection or via user input).
@@ -77,6 +80,8 @@ func replaceImageNames(d *parser.DevfileObj, registry string, imageTag string) (
 
 	var isAbs bool
 	var imageRef reference.Named
+	var warnings errPkg.Issues
+	replaced := false
 	for _, comp := range imageComponents {
 		imageName := comp.Image.ImageName
 		isAbs, imageRef, err = parseImageReference(imageName)
@@ -101,23 +106,55 @@ func replaceImageNames(d *parser.DevfileObj, registry string, imageTag string) (
 
 		// Replace so that the image can be built and pushed to the registry specified by the tool.
 		comp.Image.ImageName = replacement
+		replaced = true
 
 		// Replace in matching container components
-		err = handleContainerComponents(d, baseImageName, replacement)
+		var containerMatch, k8sMatch bool
+		containerMatch, err = handleContainerComponents(d, baseImageName, replacement)
 		if err != nil {
 			return err
 		}
 
 		// Replace in matching Kubernetes and OpenShift components
-		err = handleKubernetesLikeComponents(d, baseImageName, replacement)
+		k8sMatch, err = handleKubernetesLikeComponents(d, baseImageName, replacement)
 		if err != nil {
 			return err
 		}
+
+		if !containerMatch && !k8sMatch {
+			warnings = append(warnings, componentWarning(d, errPkg.CodeUnmatchedImageSelector, comp.Name,
+				fmt.Sprintf("the image %s of component %s does not match the image of any component", imageName, comp.Name)))
+		}
 	}
 
+	if replaced {
+		warnings = append(warnings, uriComponentWarnings(d)...)
+	}
+	addWarnings(d, warnings)
 	return nil
 }
 
+// uriComponentWarnings returns the warnings about the Kubernetes and OpenShift components of the devfile referencing their
+// resources with a uri, in which image names are not replaced
+func uriComponentWarnings(d *parser.DevfileObj) errPkg.Issues {
+	var warnings errPkg.Issues
+	for _, comp := range d.Data.GetDevfileWorkspaceSpec().Components {
+		var uri string
+		switch {
+		case comp.Kubernetes != nil && comp.Kubernetes.Inlined == "":
+			uri = comp.Kubernetes.Uri
+		case comp.Openshift != nil && comp.Openshift.Inlined == "":
+			uri = comp.Openshift.Uri
+		}
+		if uri == "" {
+			continue
+		}
+		warnings = append(warnings, componentWarning(d, errPkg.CodeKubernetesUriNotPatched, comp.Name,
+			fmt.Sprintf("image names are not replaced in the resources of component %s, referenced by the uri %s", comp.Name, uri)))
+	}
+	return warnings
+}
+
 // parseImageReference uses the Docker reference library to detect if the image name is absolute or not
 // and returns a struct from which we can extract the domain, tag and digest if needed.
 func parseImageReference(imageName string) (isAbsolute bool, imageRef reference.Named, err error) {
@@ -151,37 +188,41 @@ func hasMatch(baseImageName, compImage string) (bool, error) {
 	return getImageSimpleName(imageRef) == baseImageName, nil
 }
 
-func handleContainerComponents(d *parser.DevfileObj, baseImageName, replacement string) (err error) {
+// handleContainerComponents replaces the matching images of container components, and returns whether any matched
+func handleContainerComponents(d *parser.DevfileObj, baseImageName, replacement string) (matched bool, err error) {
 	var containerComponents []v1.Component
 	containerComponents, err = d.Data.GetComponents(common.DevfileOptions{
 		ComponentOptions: common.ComponentOptions{ComponentType: v1.ContainerComponentType},
 	})
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	for _, comp := range containerComponents {
 		var match bool
 		match, err = hasMatch(baseImageName, comp.Container.Image)
 		if err != nil {
-			return err
+			return false, err
 		}
 		if !match {
 			continue
 		}
 		comp.Container.Image = replacement
+		matched = true
 	}
-	return nil
+	return matched, nil
 }
 
-func handleKubernetesLikeComponents(d *parser.DevfileObj, baseImageName, replacement string) error {
+// handleKubernetesLikeComponents replaces the matching images of the inlined resources of Kubernetes and OpenShift
+// components, and returns whether any matched
+func handleKubernetesLikeComponents(d *parser.DevfileObj, baseImageName, replacement string) (matched bool, err error) {
 	var allK8sOcComponents []v1.Component
 
 	k8sComponents, err := d.Data.GetComponents(common.DevfileOptions{
 		ComponentOptions: common.ComponentOptions{ComponentType: v1.KubernetesComponentType},
 	})
 	if err != nil {
-		return err
+		return false, err
 	}
 	allK8sOcComponents = append(allK8sOcComponents, k8sComponents...)
 
@@ -189,7 +230,7 @@ func handleKubernetesLikeComponents(d *parser.DevfileObj, baseImageName, replace
 		ComponentOptions: common.ComponentOptions{ComponentType: v1.OpenshiftComponentType},
 	})
 	if err != nil {
-		return err
+		return false, err
 	}
 	allK8sOcComponents = append(allK8sOcComponents, ocComponents...)
 
@@ -203,6 +244,7 @@ func handleKubernetesLikeComponents(d *parser.DevfileObj, baseImageName, replace
 				return false, nil
 			}
 			c.Image = replacement
+			matched = true
 			return true, nil
 		}
 		for i := range ps.Containers {
@@ -288,17 +330,17 @@ func handleKubernetesLikeComponents(d *parser.DevfileObj, baseImageName, replace
 		if comp.Kubernetes != nil {
 			newContent, err = handleK8sContent(comp.Kubernetes.Inlined)
 			if err != nil {
-				return err
+				return false, err
 			}
 			comp.Kubernetes.Inlined = newContent
 		} else {
 			newContent, err = handleK8sContent(comp.Openshift.Inlined)
 			if err != nil {
-				return err
+				return false, err
 			}
 			comp.Openshift.Inlined = newContent
 		}
 	}
 
-	return nil
+	return matched, nil
 }
@@ -89,6 +89,11 @@ func ParseAndValidate(path string) (d parser.DevfileObj, err error) {
 // ParseDevfileAndValidate func parses the devfile data, validates the devfile integrity with the schema
 // replaces the top-level variable keys if present and validates the devfile data.
 // It returns devfile context and runtime objects, variable substitution warning if any and an error.
+// All the non-fatal issues found while parsing, flattening, substituting variables and replacing image names,
+// including the variable substitution warning, are returned as d.Warnings, with their code and the element they refer to.
+// The data validation issues of warning severity, which still fail the validation, are also returned as d.Warnings.
+// The values of the sensitive variables, see parser.ParserArgs.SensitiveVariables, are redacted from the returned error
+// and warnings.
 func ParseDevfileAndValidate(args parser.ParserArgs) (d parser.DevfileObj, varWarning variables.VariableWarning, err error) {
 	d, err = parser.ParseDevfile(args)
 	if err != nil {
@@ -97,17 +102,22 @@ func ParseDevfileAndValidate(args parser.ParserArgs) (d parser.DevfileObj, varWa
 
 	if d.Data.GetSchemaVersion() != "2.0.0" {
 
-		// add external variables to spec variables
-		if d.Data.GetDevfileWorkspaceSpec().Variables == nil {
-			d.Data.GetDevfileWorkspaceSpec().Variables = map[string]string{}
-		}
-		allVariables := d.Data.GetDevfileWorkspaceSpec().Variables
-		for key, val := range args.ExternalVariables {
-			allVariables[key] = val
+		// add external variables and the variables of the providers to spec variables
+		var restoreVariables func()
+		d.Variables, restoreVariables, err = resolveVariables(d, args)
+		if err != nil {
+			return d, varWarning, err
 		}
+		// the values of the sensitive variables are redacted from the errors and warnings from now on
+		defer func() {
+			d.Warnings = redactIssues(d, d.Warnings)
+			err = redactError(d, err)
+		}()
 
 		// replace the top level variable keys with their values in the devfile
 		varWarning = variables.ValidateAndReplaceGlobalVariable(d.Data.GetDevfileWorkspaceSpec())
+		restoreVariables()
+		addWarnings(&d, variableWarnings(d, varWarning))
 	}
 
 	// Use image names as selectors after variable substitution,
@@ -119,10 +129,23 @@ func ParseDevfileAndValidate(args parser.ParserArgs) (d parser.DevfileObj, varWa
 		}
 	}
 
+	// the substituted variables and replaced image names are not written back to the devfile
+	err = d.SetUnchanged()
+	if err != nil {
+		return d, varWarning, err
+	}
+
 	// generic validation on devfile content
 	err = validate.ValidateDevfileData(d.Data)
 	if err != nil {
-		return d, varWarning, &errPkg.NonCompliantDevfile{Err: err.Error()}
+		issues := d.LocateValidationIssues(err)
+		// the warning severity issues, e.g. a command group without a default command, still fail the validation
+		for _, issue := range issues {
+			if issue.Severity == errPkg.SeverityWarning {
+				d.Warnings = append(d.Warnings, issue)
+			}
+		}
+		return d, varWarning, &errPkg.NonCompliantDevfile{Err: err.Error(), Issues: issues}
 	}
 
 	return d, varWarning, err
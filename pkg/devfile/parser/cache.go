@@ -0,0 +1,396 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	devfilepkg "github.com/devfile/api/v2/pkg/devfile"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/devfile/library/v2/pkg/util"
+	"k8s.io/klog"
+)
+
+const (
+	// DefaultParserCacheMaxEntries is the default maximum number of flattened devfiles, and of parents and plugins,
+	// kept in the cache of a Parser
+	DefaultParserCacheMaxEntries = 256
+	// DefaultParserCacheTTL is the default duration the entries of the cache of a Parser are reused for
+	DefaultParserCacheTTL = 10 * time.Minute
+)
+
+// ParserCacheOptions bounds the cache of a Parser
+type ParserCacheOptions struct {
+	// MaxEntries is the maximum number of flattened devfiles, and of parents and plugins, kept in the cache. The least
+	// recently used entries are evicted first. DefaultParserCacheMaxEntries is used if it is not set.
+	MaxEntries int
+	// TTL is how long an entry is reused after being stored. DefaultParserCacheTTL is used if it is not set.
+	TTL time.Duration
+}
+
+// Parser parses devfiles like ParseDevfile, reusing the parents, plugins and flattened devfiles it parsed before.
+// Flattened devfiles are keyed on the sha256 of the devfile content and on the parser arguments they depend on, while
+// parents and plugins are keyed on their import reference so that they are not fetched again.
+//
+// A Parser is safe for concurrent use, and returns deep copies of the cached devfiles that callers can modify freely.
+// The Kubernetes client, the devfile utils client and the resolvers passed in the arguments are not part of the keys,
+// they are expected to resolve an import reference to the same devfile for the TTL of the cache.
+//
+// Devfiles parsed from an FS or with RecordProvenance are not cached. Parents and plugins are not cached in strict mode,
+// while generating or resolving strictly from a lockfile, nor when they are resolved with a uri from a devfile on disk, as their
+// resources are copied next to it.
+type Parser struct {
+	cache *parserCache
+}
+
+// NewParser returns a Parser with an empty cache bounded by options
+func NewParser(options ParserCacheOptions) *Parser {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = DefaultParserCacheMaxEntries
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultParserCacheTTL
+	}
+	return &Parser{
+		cache: &parserCache{
+			maxEntries: options.MaxEntries,
+			ttl:        options.TTL,
+			now:        time.Now,
+			results:    newCacheEntries(),
+			imports:    newCacheEntries(),
+		},
+	}
+}
+
+// ParseDevfile parses the devfile like ParseDevfile, returning a copy of the cached flattened devfile if the devfile
+// content and the arguments are the same as for a previous call
+func (p *Parser) ParseDevfile(args ParserArgs) (DevfileObj, error) {
+	if args.FS != nil || args.RecordProvenance {
+		return ParseDevfile(args)
+	}
+	var offlineVault *vault
+	if args.OfflineVault != "" {
+		offlineVault = &vault{dir: args.OfflineVault}
+	}
+	return parseDevfileWithCache(args, offlineVault, p.cache)
+}
+
+// Purge removes every entry from the cache
+func (p *Parser) Purge() {
+	p.cache.mu.Lock()
+	defer p.cache.mu.Unlock()
+	p.cache.results.clear()
+	p.cache.imports.clear()
+}
+
+// parserCache stores the flattened devfiles, and the parents and plugins, parsed by a Parser
+type parserCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+	// results are the flattened devfiles returned by the parser
+	results *cacheEntries
+	// imports are the parsed parents and plugins
+	imports *cacheEntries
+}
+
+// cacheEntries are entries kept in least recently used order
+type cacheEntries struct {
+	// order holds the entries, the most recently used one first
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+// cacheEntry is a devfile stored in the cache
+type cacheEntry struct {
+	key      string
+	storedAt time.Time
+	d        DevfileObj
+	// sources are the devfiles recorded while parsing an imported devfile, itself first, to locate errors in them
+	sources []importedDevfile
+}
+
+func newCacheEntries() *cacheEntries {
+	return &cacheEntries{order: list.New(), elements: map[string]*list.Element{}}
+}
+
+// clear removes every entry
+func (e *cacheEntries) clear() {
+	e.order.Init()
+	e.elements = map[string]*list.Element{}
+}
+
+// get returns the entry stored for key in entries, unless it expired
+func (c *parserCache) get(cached *cacheEntries, key string) (cacheEntry, bool) {
+	if c == nil || key == "" {
+		return cacheEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := cached.elements[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	entry := element.Value.(cacheEntry)
+	if c.now().Sub(entry.storedAt) >= c.ttl {
+		cached.order.Remove(element)
+		delete(cached.elements, key)
+		return cacheEntry{}, false
+	}
+	cached.order.MoveToFront(element)
+	return entry, true
+}
+
+// put stores entry in entries, evicting the least recently used entries if there are too many
+func (c *parserCache) put(cached *cacheEntries, entry cacheEntry) {
+	if c == nil || entry.key == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.storedAt = c.now()
+	if element, ok := cached.elements[entry.key]; ok {
+		element.Value = entry
+		cached.order.MoveToFront(element)
+		return
+	}
+	cached.elements[entry.key] = cached.order.PushFront(entry)
+	for cached.order.Len() > c.maxEntries {
+		oldest := cached.order.Back()
+		cached.order.Remove(oldest)
+		delete(cached.elements, oldest.Value.(cacheEntry).key)
+	}
+}
+
+// result returns a copy of the flattened devfile stored for key
+func (c *parserCache) result(key string) (DevfileObj, bool) {
+	if c == nil {
+		return DevfileObj{}, false
+	}
+	entry, ok := c.get(c.results, key)
+	if !ok {
+		return DevfileObj{}, false
+	}
+	klog.V(4).Infof("using the cached flattened devfile %s", key)
+	return copyDevfileObj(entry.d), true
+}
+
+// storeResult stores a copy of the flattened devfile d for key
+func (c *parserCache) storeResult(key string, d DevfileObj) {
+	if c == nil || key == "" {
+		return
+	}
+	c.put(c.results, cacheEntry{key: key, d: copyDevfileObj(d)})
+}
+
+// resultKey returns the key of the flattened devfile d, whose content has been read, parsed with args
+func (c *parserCache) resultKey(d DevfileObj, args ParserArgs) string {
+	if c == nil {
+		return ""
+	}
+	return cacheKey(struct {
+		Content                       [sha256.Size]byte
+		AbsPath                       string
+		URL                           string
+		Token                         string
+		Credentials                   string
+		RegistryURLs                  []string
+		DefaultNamespace              string
+		FlattenedDevfile              *bool
+		SetBooleanDefaults            *bool
+		ConvertKubernetesContentInUri *bool
+		DownloadGitResources          *bool
+		ImageNamesAsSelector          *ImageSelectorArgs
+		GenerateLockfile              bool
+		Lockfile                      *Lockfile
+		OfflineVault                  string
+		Strict                        *StrictArgs
+		HTTPTimeout                   *int
+	}{
+		Content:                       sha256.Sum256(d.Ctx.GetDevfileContent()),
+		AbsPath:                       d.Ctx.GetAbsPath(),
+		URL:                           d.Ctx.GetURL(),
+		Token:                         args.Token,
+		Credentials:                   credentialsKey(args.Credentials),
+		RegistryURLs:                  args.RegistryURLs,
+		DefaultNamespace:              args.DefaultNamespace,
+		FlattenedDevfile:              args.FlattenedDevfile,
+		SetBooleanDefaults:            args.SetBooleanDefaults,
+		ConvertKubernetesContentInUri: args.ConvertKubernetesContentInUri,
+		DownloadGitResources:          args.DownloadGitResources,
+		ImageNamesAsSelector:          args.ImageNamesAsSelector,
+		GenerateLockfile:              args.GenerateLockfile,
+		Lockfile:                      args.Lockfile,
+		OfflineVault:                  args.OfflineVault,
+		Strict:                        args.Strict,
+		HTTPTimeout:                   args.HTTPTimeout,
+	})
+}
+
+// importKey returns the key of the devfile imported with importReference by the devfile with context curDevfileCtx,
+// or an empty key if the devfile cannot be cached
+func (c *parserCache) importKey(resolver Resolver, importReference v1.ImportReference, curDevfileCtx devfileCtx.DevfileCtx, tool resolverTools) string {
+	if c == nil || tool.lock != nil || tool.recordProvenance || tool.strict != nil || (tool.vault != nil && tool.vault.vendor) || curDevfileCtx.GetIOFS() != nil {
+		return ""
+	}
+	absoluteURL := strings.HasPrefix(importReference.Uri, "http://") || strings.HasPrefix(importReference.Uri, "https://")
+	if importReference.Uri != "" && curDevfileCtx.GetAbsPath() != "" {
+		return ""
+	}
+	// relative uris are resolved against the URL of the importing devfile
+	base := ""
+	if importReference.Uri != "" && !absoluteURL {
+		base = curDevfileCtx.GetURL()
+	}
+	return cacheKey(struct {
+		Resolver         string
+		ImportReference  v1.ImportReference
+		Base             string
+		Token            string
+		Credentials      string
+		RegistryURLs     []string
+		DefaultNamespace string
+		Offline          bool
+	}{
+		Resolver:         fmt.Sprintf("%T", resolver),
+		ImportReference:  importReference,
+		Base:             base,
+		Token:            curDevfileCtx.GetToken(),
+		Credentials:      credentialsKey(tool.credentials),
+		RegistryURLs:     tool.registryURLs,
+		DefaultNamespace: tool.defaultNamespace,
+		Offline:          tool.vault != nil,
+	})
+}
+
+// imported returns a copy of the parent or plugin stored for key, recording the devfiles it imports in sources as imported
+// from importedFrom
+func (c *parserCache) imported(key string, importedFrom string, sources *devfileSources) (DevfileObj, bool) {
+	entry, ok := c.get(c.imports, key)
+	if !ok {
+		return DevfileObj{}, false
+	}
+	klog.V(4).Infof("using the cached devfile %s", key)
+	if sources != nil && len(entry.sources) > 0 {
+		imported := append([]importedDevfile(nil), entry.sources...)
+		imported[0].importedFrom = importedFrom
+		sources.imports = append(sources.imports, imported...)
+	}
+	return copyDevfileObj(entry.d), true
+}
+
+// storeImported stores a copy of the parent or plugin d for key, along with the devfiles recorded in sources while parsing it
+func (c *parserCache) storeImported(key string, d DevfileObj, sources *devfileSources) {
+	if key == "" {
+		return
+	}
+	entry := cacheEntry{key: key, d: copyDevfileObj(d)}
+	if sources != nil {
+		entry.sources = append([]importedDevfile(nil), sources.imports...)
+	}
+	c.put(c.imports, entry)
+}
+
+// cacheKey returns the sha256 of the JSON encoding of value, or an empty key if it cannot be encoded
+func cacheKey(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(encoded))
+}
+
+// credentialsKey returns the part of a cache key identifying a credential provider, i.e. its content or its address
+func credentialsKey(credentials util.CredentialProvider) string {
+	if credentials == nil {
+		return ""
+	}
+	return fmt.Sprintf("%#v", credentials)
+}
+
+// copyDevfileObj returns a copy of d whose context, data, lockfile, warnings and variables, and the records of the
+// defaulted fields and unchanged content used to write it, are not shared with d. The context shares the positions of
+// the nodes of the devfile, see DevfileCtx.Copy, and the copy shares the sources of the imported devfiles and the
+// content of the parent, which are only read once the devfile is parsed. The provenance is shared too, it is nil for
+// the devfiles that are cached.
+func copyDevfileObj(d DevfileObj) DevfileObj {
+	copied := d
+	copied.Ctx = d.Ctx.Copy()
+	copied.Data = copyDevfileData(d.Data)
+	if d.Lockfile != nil {
+		lockfile := Lockfile{Entries: make([]LockEntry, len(d.Lockfile.Entries))}
+		for i, entry := range d.Lockfile.Entries {
+			lockfile.Entries[i] = entry
+			entry.ImportReference.DeepCopyInto(&lockfile.Entries[i].ImportReference)
+		}
+		copied.Lockfile = &lockfile
+	}
+	if d.Warnings != nil {
+		copied.Warnings = make(errPkg.Issues, len(d.Warnings))
+		for i, warning := range d.Warnings {
+			issue := *warning
+			issue.Locations = append([]errPkg.Location(nil), warning.Locations...)
+			copied.Warnings[i] = &issue
+		}
+	}
+	if d.Variables != nil {
+		copied.Variables = make(map[string]ResolvedVariable, len(d.Variables))
+		for name, variable := range d.Variables {
+			copied.Variables[name] = variable
+		}
+	}
+	if d.defaulted != nil {
+		copied.defaulted = make(defaultedFields, len(d.defaulted))
+		for field, set := range d.defaulted {
+			copied.defaulted[field] = set
+		}
+	}
+	copied.unchanged = append([]byte(nil), d.unchanged...)
+	return copied
+}
+
+// copyDevfileData returns a deep copy of devfileData
+func copyDevfileData(devfileData data.DevfileData) data.DevfileData {
+	devfileV2, ok := devfileData.(*v2.DevfileV2)
+	if !ok || devfileV2 == nil {
+		return devfileData
+	}
+	copied := &v2.DevfileV2{}
+	copied.DevfileHeader = devfileV2.DevfileHeader
+	if devfileV2.Metadata.Attributes != nil {
+		copied.Metadata.Attributes = attributes.Attributes{}
+		for key, value := range devfileV2.Metadata.Attributes {
+			copied.Metadata.Attributes[key] = *value.DeepCopy()
+		}
+	}
+	copied.Metadata.Tags = append([]string(nil), devfileV2.Metadata.Tags...)
+	copied.Metadata.Architectures = append([]devfilepkg.Architecture(nil), devfileV2.Metadata.Architectures...)
+	devfileV2.DevWorkspaceTemplateSpec.DeepCopyInto(&copied.DevWorkspaceTemplateSpec)
+	return copied
+}
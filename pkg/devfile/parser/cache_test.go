@@ -0,0 +1,208 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const cacheTestParent = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+  - name: runtime
+    container:
+      image: quay.io/parent-image
+`
+
+// newCountingServer returns a server serving content, and the number of requests it served
+func newCountingServer(content string) (*httptest.Server, *int32) {
+	var requests int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(content))
+	})), &requests
+}
+
+// cacheTestArgs returns the args to parse a devfile named name importing the parent at parentURL
+func cacheTestArgs(name string, parentURL string) ParserArgs {
+	return ParserArgs{
+		Data: []byte(fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: %s
+parent:
+  uri: %s
+components:
+  - name: %s-runtime
+    container:
+      image: quay.io/%s-image
+`, name, parentURL, name, name)),
+		DownloadGitResources: &isFalse,
+	}
+}
+
+func TestParser_ParseDevfile(t *testing.T) {
+	testServer, requests := newCountingServer(cacheTestParent)
+	defer testServer.Close()
+
+	p := NewParser(ParserCacheOptions{})
+	want, err := ParseDevfile(cacheTestArgs("main", testServer.URL))
+	if err != nil {
+		t.Fatalf("TestParser_ParseDevfile() unexpected error: %v", err)
+	}
+	atomic.StoreInt32(requests, 0)
+
+	first, err := p.ParseDevfile(cacheTestArgs("main", testServer.URL))
+	if err != nil {
+		t.Fatalf("TestParser_ParseDevfile() unexpected error: %v", err)
+	}
+	assert.Equal(t, want.Data, first.Data, "TestParser_ParseDevfile(): the flattened devfile should match the one of ParseDevfile")
+
+	// callers can modify the returned devfiles without affecting the cache
+	components, _ := first.Data.GetComponents(common.DevfileOptions{})
+	components[0].Container.Image = "quay.io/modified-image"
+	_ = first.Data.UpdateComponent(components[0])
+	first.Ctx.GetDevfileContent()[0] = '#'
+	first.Ctx.GetOriginalContent()[0] = '#'
+
+	second, err := p.ParseDevfile(cacheTestArgs("main", testServer.URL))
+	if err != nil {
+		t.Fatalf("TestParser_ParseDevfile() unexpected error: %v", err)
+	}
+	assert.Equal(t, want.Data, second.Data, "TestParser_ParseDevfile(): the cached devfile should not be modified by callers")
+	assert.Equal(t, want.Ctx.GetDevfileContent(), second.Ctx.GetDevfileContent(), "TestParser_ParseDevfile(): the cached content should not be modified by callers")
+	assert.Equal(t, want.Ctx.GetOriginalContent(), second.Ctx.GetOriginalContent(), "TestParser_ParseDevfile(): the cached original content should not be modified by callers")
+
+	// a devfile with another content is parsed again, reusing the cached parent
+	other, err := p.ParseDevfile(cacheTestArgs("other", testServer.URL))
+	if err != nil {
+		t.Fatalf("TestParser_ParseDevfile() unexpected error: %v", err)
+	}
+	otherComponents, _ := other.Data.GetComponents(common.DevfileOptions{})
+	assert.Len(t, otherComponents, 2)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests), "TestParser_ParseDevfile(): the parent should be fetched once")
+
+	// args the flattened devfile depends on are part of the key
+	args := cacheTestArgs("main", testServer.URL)
+	args.SetBooleanDefaults = &isFalse
+	third, err := p.ParseDevfile(args)
+	if err != nil {
+		t.Fatalf("TestParser_ParseDevfile() unexpected error: %v", err)
+	}
+	thirdComponents, _ := third.Data.GetComponents(common.DevfileOptions{})
+	for _, component := range thirdComponents {
+		assert.Nil(t, component.Container.DedicatedPod, "TestParser_ParseDevfile(): boolean defaults should not be set")
+	}
+
+	p.Purge()
+	_, err = p.ParseDevfile(cacheTestArgs("main", testServer.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests), "TestParser_ParseDevfile(): the parent should be fetched again once purged")
+}
+
+func TestParser_ParseDevfile_TTL(t *testing.T) {
+	testServer, requests := newCountingServer(cacheTestParent)
+	defer testServer.Close()
+
+	p := NewParser(ParserCacheOptions{TTL: time.Minute})
+	now := time.Now()
+	p.cache.now = func() time.Time {
+		return now
+	}
+
+	tests := []struct {
+		name         string
+		elapsed      time.Duration
+		wantRequests int32
+	}{
+		{
+			name:         "first parse fetches the parent",
+			wantRequests: 1,
+		},
+		{
+			name:         "cached devfile is reused within the TTL",
+			elapsed:      30 * time.Second,
+			wantRequests: 1,
+		},
+		{
+			name:         "expired devfile is parsed again",
+			elapsed:      time.Minute,
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.elapsed)
+			_, err := p.ParseDevfile(cacheTestArgs("main", testServer.URL))
+			if err != nil {
+				t.Fatalf("TestParser_ParseDevfile_TTL() unexpected error: %v", err)
+			}
+			assert.Equal(t, tt.wantRequests, atomic.LoadInt32(requests))
+		})
+	}
+}
+
+func TestParserCache_MaxEntries(t *testing.T) {
+	p := NewParser(ParserCacheOptions{MaxEntries: 2})
+	c := p.cache
+	for _, key := range []string{"a", "b"} {
+		c.storeResult(key, DevfileObj{})
+	}
+	// using a makes b the least recently used entry
+	_, ok := c.result("a")
+	assert.True(t, ok)
+	c.storeResult("c", DevfileObj{})
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		_, ok := c.result(key)
+		assert.Equal(t, want, ok, "TestParserCache_MaxEntries(): unexpected cache entry for %s", key)
+	}
+}
+
+func TestParser_ParseDevfile_Concurrent(t *testing.T) {
+	testServer, requests := newCountingServer(cacheTestParent)
+	defer testServer.Close()
+
+	want, err := ParseDevfile(cacheTestArgs("main", testServer.URL))
+	if err != nil {
+		t.Fatalf("TestParser_ParseDevfile_Concurrent() unexpected error: %v", err)
+	}
+
+	p := NewParser(ParserCacheOptions{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d, err := p.ParseDevfile(cacheTestArgs("main", testServer.URL))
+			if assert.NoError(t, err) {
+				assert.Equal(t, want.Data, d.Data)
+				d.Data.SetSchemaVersion("2.1.0")
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, atomic.LoadInt32(requests), int32(9))
+}
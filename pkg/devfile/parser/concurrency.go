@@ -0,0 +1,145 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/devfile/library/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// runInOrder calls fn for each index from 0 to n-1, running at most limit calls at a time, and returns the error of
+// each call. Calls are made one after another, in order, if limit is lower than 2.
+// As callers handle the errors in order and stop at the first one, the calls for the indexes following a failed one
+// are skipped when they have not started yet, and their error is nil.
+func runInOrder(n int, limit int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	if limit < 2 {
+		for i := 0; i < n; i++ {
+			if errs[i] = fn(i); errs[i] != nil {
+				break
+			}
+		}
+		return errs
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	firstFailed := n
+	slots := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		slots <- struct{}{}
+		mu.Lock()
+		skip := i > firstFailed
+		mu.Unlock()
+		if skip {
+			<-slots
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := fn(i); err != nil {
+				mu.Lock()
+				errs[i] = err
+				if i < firstFailed {
+					firstFailed = i
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+// resourceStage stages the resources the resolvers of a plugin copy next to the devfiles, so that plugins resolved
+// concurrently do not copy their resources to the same directories at the same time. The staged resources are copied
+// to their directories with commit, in the order of the plugins, the resources of the first plugin shipping a file
+// being kept as when the plugins are resolved one after another.
+type resourceStage struct {
+	// root is the directory the resources are staged in, it is created when the first resources are staged
+	root string
+	// dirs are the directories the resources are staged in, by directory they are copied to
+	dirs map[string]string
+	// destDirs are the directories the resources are copied to, in the order they were staged
+	destDirs []string
+	// parent is the stage of the plugin importing the devfile, nil if it is not staged
+	parent *resourceStage
+}
+
+// newResourceStage returns a stage for the resources of a plugin, committed to parent if it is not nil
+func newResourceStage(parent *resourceStage) *resourceStage {
+	return &resourceStage{dirs: map[string]string{}, parent: parent}
+}
+
+// dir returns the directory to copy the resources to for them to be copied to destDir on commit, destDir if s is nil
+func (s *resourceStage) dir(destDir string) (string, error) {
+	if s == nil {
+		return destDir, nil
+	}
+	if dir, ok := s.dirs[destDir]; ok {
+		return dir, nil
+	}
+	if s.root == "" {
+		root, err := os.MkdirTemp("", "devfile-resources")
+		if err != nil {
+			return "", errors.Wrap(err, "failed to create a directory to stage the resources in")
+		}
+		s.root = root
+	}
+	dir := filepath.Join(s.root, strconv.Itoa(len(s.destDirs)))
+	if err := os.Mkdir(dir, 0750); err != nil {
+		return "", errors.Wrapf(err, "failed to create a directory to stage the resources of %s in", destDir)
+	}
+	s.dirs[destDir] = dir
+	s.destDirs = append(s.destDirs, destDir)
+	return dir, nil
+}
+
+// commit copies the staged resources to their directories, or to the parent stage, and removes the staged resources
+func (s *resourceStage) commit() error {
+	if s == nil {
+		return nil
+	}
+	defer s.discard()
+	for _, destDir := range s.destDirs {
+		dir, err := s.parent.dir(destDir)
+		if err != nil {
+			return err
+		}
+		if err := util.CopyAllDirFiles(s.dirs[destDir], dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discard removes the staged resources
+func (s *resourceStage) discard() {
+	if s == nil || s.root == "" {
+		return
+	}
+	_ = os.RemoveAll(s.root)
+	s.root, s.dirs, s.destDirs = "", map[string]string{}, nil
+}
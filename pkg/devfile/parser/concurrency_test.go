@@ -0,0 +1,353 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	devfilepkg "github.com/devfile/api/v2/pkg/devfile"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	parserUtil "github.com/devfile/library/v2/pkg/devfile/parser/util"
+	"github.com/devfile/library/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		limit      int
+		failing    map[int]bool
+		wantErrors []int
+	}{
+		{
+			name:  "sequential calls without errors",
+			limit: 0,
+		},
+		{
+			name:  "concurrent calls without errors",
+			limit: 3,
+		},
+		{
+			name:       "sequential calls stop at the first error",
+			limit:      1,
+			failing:    map[int]bool{2: true, 4: true},
+			wantErrors: []int{2},
+		},
+		{
+			name:       "concurrent calls report the first error",
+			limit:      3,
+			failing:    map[int]bool{2: true, 4: true},
+			wantErrors: []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			running, maxRunning := 0, 0
+			called := map[int]bool{}
+			errs := runInOrder(8, tt.limit, func(i int) error {
+				mu.Lock()
+				called[i] = true
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				// later calls end first
+				time.Sleep(time.Duration(8-i) * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				if tt.failing[i] {
+					return fmt.Errorf("call %d failed", i)
+				}
+				return nil
+			})
+
+			limit := tt.limit
+			if limit < 1 {
+				limit = 1
+			}
+			assert.LessOrEqual(t, maxRunning, limit, "TestRunInOrder(): too many concurrent calls")
+			firstError := -1
+			for i, err := range errs {
+				if err != nil {
+					firstError = i
+					break
+				}
+			}
+			if len(tt.wantErrors) == 0 {
+				assert.Equal(t, -1, firstError, "TestRunInOrder(): unexpected error")
+				assert.Len(t, called, 8, "TestRunInOrder(): every call should be made")
+				return
+			}
+			assert.Equal(t, tt.wantErrors[0], firstError, "TestRunInOrder(): first error index should match")
+			assert.EqualError(t, errs[firstError], fmt.Sprintf("call %d failed", firstError))
+			for i := 0; i < firstError; i++ {
+				assert.True(t, called[i], "TestRunInOrder(): call %d before the first error should be made", i)
+			}
+			if tt.limit < 2 {
+				assert.Len(t, called, firstError+1, "TestRunInOrder(): sequential calls should stop at the first error")
+			}
+		})
+	}
+}
+
+// newDelayedServer returns a server serving content for the paths /0, /1... where later paths are served faster,
+// and failing for the paths of missing
+func newDelayedServer(content func(i int) string, missing map[int]bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil || missing[i] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		time.Sleep(time.Duration(10-i) * 5 * time.Millisecond)
+		_, _ = w.Write([]byte(content(i)))
+	}))
+}
+
+func TestParseDevfile_ConcurrentKubernetesUris(t *testing.T) {
+	const count = 6
+	kubeContent := func(i int) string {
+		return fmt.Sprintf("apiVersion: v1\nkind: Service\nmetadata:\n  name: service-%d\n", i)
+	}
+
+	for _, missing := range []map[int]bool{nil, {2: true, 4: true}} {
+		testServer := newDelayedServer(kubeContent, missing)
+
+		var devfile strings.Builder
+		devfile.WriteString("schemaVersion: 2.2.0\nmetadata:\n  name: kube-uris\ncomponents:\n")
+		for i := 0; i < count; i++ {
+			componentType := "kubernetes"
+			if i%2 == 1 {
+				componentType = "openshift"
+			}
+			fmt.Fprintf(&devfile, "  - name: component-%d\n    %s:\n      uri: %s/%d\n", i, componentType, testServer.URL, i)
+		}
+
+		parse := func(concurrency int) (DevfileObj, error) {
+			return ParseDevfile(ParserArgs{
+				Data:                          []byte(devfile.String()),
+				DownloadGitResources:          &isFalse,
+				ConvertKubernetesContentInUri: &isTrue,
+				Concurrency:                   concurrency,
+			})
+		}
+		want, wantErr := parse(0)
+		got, gotErr := parse(4)
+		testServer.Close()
+
+		if missing != nil {
+			if assert.Error(t, wantErr) && assert.Error(t, gotErr) {
+				assert.Equal(t, wantErr.Error(), gotErr.Error(), "TestParseDevfile_ConcurrentKubernetesUris(): errors should match")
+				assert.Contains(t, gotErr.Error(), "component 'component-2'")
+			}
+			continue
+		}
+		if !assert.NoError(t, wantErr) || !assert.NoError(t, gotErr) {
+			continue
+		}
+		wantComponents, _ := want.Data.GetComponents(common.DevfileOptions{})
+		gotComponents, _ := got.Data.GetComponents(common.DevfileOptions{})
+		assert.Equal(t, wantComponents, gotComponents, "TestParseDevfile_ConcurrentKubernetesUris(): components should match")
+		for i, component := range gotComponents {
+			inlined := ""
+			if component.Kubernetes != nil {
+				inlined = component.Kubernetes.Inlined
+			} else if component.Openshift != nil {
+				inlined = component.Openshift.Inlined
+			}
+			assert.Equal(t, kubeContent(i), inlined, "TestParseDevfile_ConcurrentKubernetesUris(): component %s should be inlined", component.Name)
+		}
+	}
+}
+
+func Test_parseParentAndPlugin_ConcurrentPlugins(t *testing.T) {
+	const count = 4
+	pluginContent := func(i int) string {
+		return fmt.Sprintf("schemaVersion: 2.2.0\nmetadata:\n  name: plugin-%d\ncomponents:\n  - name: plugin-runtime-%d\n    container:\n      image: quay.io/plugin-%d\n", i, i, i)
+	}
+
+	for _, missing := range []map[int]bool{nil, {1: true, 3: true}} {
+		testServer := newDelayedServer(pluginContent, missing)
+
+		parse := func(concurrency int) (DevfileObj, *Lockfile, error) {
+			var components []v1.Component
+			for i := 0; i < count; i++ {
+				components = append(components, v1.Component{
+					Name: fmt.Sprintf("plugin-%d", i),
+					ComponentUnion: v1.ComponentUnion{
+						Plugin: &v1.PluginComponent{
+							ImportReference: v1.ImportReference{
+								ImportReferenceUnion: v1.ImportReferenceUnion{
+									Uri: fmt.Sprintf("%s/%d", testServer.URL, i),
+								},
+							},
+						},
+					},
+				})
+			}
+			d := DevfileObj{
+				Ctx: devfileCtx.NewDevfileCtx(OutputDevfileYamlPath),
+				Data: &v2.DevfileV2{
+					Devfile: v1.Devfile{
+						DevfileHeader: devfilepkg.DevfileHeader{
+							SchemaVersion: schemaVersion,
+						},
+						DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+							DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+								Components: components,
+							},
+						},
+					},
+				},
+			}
+			tool := resolverTools{
+				context:            context.Background(),
+				devfileUtilsClient: parserUtil.NewDevfileUtilsClient(),
+				lock:               newLockState(true, nil),
+				sources:            &devfileSources{},
+				concurrency:        concurrency,
+			}
+			err := parseParentAndPlugin(&d, &resolutionContextTree{}, tool)
+			return d, tool.lock.getLockfile(), err
+		}
+		want, wantLockfile, wantErr := parse(0)
+		got, gotLockfile, gotErr := parse(count)
+		testServer.Close()
+
+		if missing != nil {
+			if assert.Error(t, wantErr) && assert.Error(t, gotErr) {
+				assert.Equal(t, wantErr.Error(), gotErr.Error(), "Test_parseParentAndPlugin_ConcurrentPlugins(): errors should match")
+				assert.Contains(t, gotErr.Error(), fmt.Sprintf("%s/1", testServer.URL))
+			}
+			continue
+		}
+		if !assert.NoError(t, wantErr) || !assert.NoError(t, gotErr) {
+			continue
+		}
+		assert.Equal(t, want.Data, got.Data, "Test_parseParentAndPlugin_ConcurrentPlugins(): flattened devfiles should match")
+		assert.Equal(t, wantLockfile, gotLockfile, "Test_parseParentAndPlugin_ConcurrentPlugins(): lockfiles should match")
+		if assert.Len(t, gotLockfile.Entries, count) {
+			for i, entry := range gotLockfile.Entries {
+				assert.Equal(t, fmt.Sprintf("%s/%d", testServer.URL, i), entry.ImportReference.Uri, "Test_parseParentAndPlugin_ConcurrentPlugins(): lockfile entries should be in order")
+			}
+		}
+	}
+}
+
+// delayedResourcesClient downloads the resources of the URLs /0, /1... of a server as a shared.txt file holding the
+// index of the URL, later URLs being downloaded faster
+type delayedResourcesClient struct{}
+
+func (c delayedResourcesClient) DownloadInMemory(params util.HTTPRequestParams) ([]byte, error) {
+	return parserUtil.NewDevfileUtilsClient().DownloadInMemory(params)
+}
+
+func (c delayedResourcesClient) DownloadGitRepoResources(url string, destDir string, token string) error {
+	i, err := strconv.Atoi(url[strings.LastIndex(url, "/")+1:])
+	if err != nil {
+		return err
+	}
+	time.Sleep(time.Duration(10-i) * 5 * time.Millisecond)
+	dir, err := os.MkdirTemp("", "resources")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "shared.txt"), []byte(strconv.Itoa(i)), 0600); err != nil {
+		return err
+	}
+	return util.CopyAllDirFiles(dir, destDir)
+}
+
+func Test_parseParentAndPlugin_ConcurrentPluginResources(t *testing.T) {
+	const count = 3
+	pluginContent := func(i int) string {
+		return fmt.Sprintf("schemaVersion: 2.2.0\nmetadata:\n  name: plugin-%d\ncomponents:\n  - name: plugin-runtime-%d\n    container:\n      image: quay.io/plugin-%d\n", i, i, i)
+	}
+	testServer := newDelayedServer(pluginContent, nil)
+	defer testServer.Close()
+
+	for _, concurrency := range []int{0, count} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			var components []v1.Component
+			for i := 0; i < count; i++ {
+				components = append(components, v1.Component{
+					Name: fmt.Sprintf("plugin-%d", i),
+					ComponentUnion: v1.ComponentUnion{
+						Plugin: &v1.PluginComponent{
+							ImportReference: v1.ImportReference{
+								ImportReferenceUnion: v1.ImportReferenceUnion{
+									Uri: fmt.Sprintf("%s/%d", testServer.URL, i),
+								},
+							},
+						},
+					},
+				})
+			}
+			dir := t.TempDir()
+			d := DevfileObj{
+				Ctx: devfileCtx.NewDevfileCtx(filepath.Join(dir, "devfile.yaml")),
+				Data: &v2.DevfileV2{
+					Devfile: v1.Devfile{
+						DevfileHeader: devfilepkg.DevfileHeader{
+							SchemaVersion: schemaVersion,
+						},
+						DevWorkspaceTemplateSpec: v1.DevWorkspaceTemplateSpec{
+							DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+								Components: components,
+							},
+						},
+					},
+				},
+			}
+			if err := d.Ctx.SetAbsPath(); err != nil {
+				t.Fatal(err)
+			}
+			tool := resolverTools{
+				context:              context.Background(),
+				devfileUtilsClient:   delayedResourcesClient{},
+				downloadGitResources: true,
+				lock:                 newLockState(true, nil),
+				sources:              &devfileSources{},
+				concurrency:          concurrency,
+			}
+			err := parseParentAndPlugin(&d, &resolutionContextTree{}, tool)
+			if !assert.NoError(t, err) {
+				return
+			}
+			shared, err := os.ReadFile(filepath.Join(dir, "shared.txt"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, "0", string(shared), "Test_parseParentAndPlugin_ConcurrentPluginResources(): the file of the first plugin should be kept")
+			}
+		})
+	}
+}
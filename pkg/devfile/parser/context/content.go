@@ -99,6 +99,9 @@ func (d *DevfileCtx) SetDevfileContentFromBytes(data []byte) error {
 	if err != nil {
 		return err
 	}
+	d.originalContent = data
+	// keep the position of the nodes, lost in the conversion, to locate errors
+	d.positions = newNodePositions(data)
 
 	// Successful
 	return nil
@@ -108,3 +111,8 @@ func (d *DevfileCtx) SetDevfileContentFromBytes(data []byte) error {
 func (d *DevfileCtx) GetDevfileContent() []byte {
 	return d.rawContent
 }
+
+// GetOriginalContent returns the devfile content as it was read, in YAML or JSON
+func (d *DevfileCtx) GetOriginalContent() []byte {
+	return d.originalContent
+}
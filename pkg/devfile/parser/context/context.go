@@ -16,7 +16,10 @@
 package parser
 
 import (
+	"io/fs"
 	"net/url"
+	"path"
+	"path/filepath"
 
 	parserUtil "github.com/devfile/library/v2/pkg/devfile/parser/util"
 	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
@@ -42,6 +45,12 @@ type DevfileCtx struct {
 	// raw content of the devfile
 	rawContent []byte
 
+	// content of the devfile as it was read, before its conversion to JSON
+	originalContent []byte
+
+	// positions of the nodes of the devfile in its original YAML or JSON content
+	positions *nodePositions
+
 	// devfile json schema
 	jsonSchema string
 
@@ -66,6 +75,15 @@ func NewDevfileCtx(path string) DevfileCtx {
 	}
 }
 
+// NewFSDevfileCtx returns a new DevfileCtx type object for a devfile read from fsys. The path is a slash-separated path
+// relative to the root of fsys, to the devfile or to a folder containing it, as for NewDevfileCtx.
+func NewFSDevfileCtx(fsys fs.FS, path string) DevfileCtx {
+	return DevfileCtx{
+		relPath: path,
+		fs:      filesystem.NewIOFs(fsys),
+	}
+}
+
 // NewURLDevfileCtx returns a new DevfileCtx type object
 func NewURLDevfileCtx(url string) DevfileCtx {
 	return DevfileCtx{
@@ -156,8 +174,21 @@ func (d *DevfileCtx) SetToken(token string) {
 	d.token = token
 }
 
+// GetIOFS returns the file system the devfile is read from if it was created with NewFSDevfileCtx, nil otherwise
+func (d *DevfileCtx) GetIOFS() fs.FS {
+	if ioFs, ok := d.fs.(filesystem.IOFs); ok {
+		return ioFs.FS
+	}
+	return nil
+}
+
 // SetAbsPath sets absolute file path for devfile
 func (d *DevfileCtx) SetAbsPath() (err error) {
+	// the path of a devfile read from an fs.FS is its path in the FS
+	if d.GetIOFS() != nil {
+		d.absPath = path.Clean(filepath.ToSlash(d.relPath))
+		return nil
+	}
 	// Set devfile absolute path
 	if d.absPath, err = util.GetAbsPath(d.relPath); err != nil {
 		return err
@@ -168,6 +199,15 @@ func (d *DevfileCtx) SetAbsPath() (err error) {
 
 }
 
+// Copy returns a copy of the context that does not share the content of the devfile with it. The positions of the
+// nodes of the devfile, which are not modified once computed, and the filesystem are shared.
+func (d *DevfileCtx) Copy() DevfileCtx {
+	copied := *d
+	copied.rawContent = append([]byte(nil), d.rawContent...)
+	copied.originalContent = append([]byte(nil), d.originalContent...)
+	return copied
+}
+
 // GetConvertUriToInlined func returns if the devfile kubernetes comp has been converted from uri to inlined
 func (d *DevfileCtx) GetConvertUriToInlined() bool {
 	return d.convertUriToInlined
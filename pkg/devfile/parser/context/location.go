@@ -34,6 +34,11 @@ var possibleDevfileNames = []string{
 	".devfile.yml",
 }
 
+// PossibleDevfileNames returns the possible filenames for a devfile, in the priority order they are looked up in a directory
+func PossibleDevfileNames() []string {
+	return append([]string(nil), possibleDevfileNames...)
+}
+
 // lookupDevfileFromPath returns the file path to use as devfile filename, by looking at the relative path specified in relPath.
 // If relPath is not a directory, it is returned as is.
 // For backward compatibility, if relPath is a directory, it will try to detect the first existing devfile filename under relPath,
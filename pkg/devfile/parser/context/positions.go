@@ -0,0 +1,187 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// position is the 1-based line and column of a node in the devfile content
+type position struct {
+	line   int
+	column int
+}
+
+// elementKey identifies an element of a list of the devfile by the JSON pointer of the list and the element name or id
+type elementKey struct {
+	list string
+	name string
+}
+
+// nodePositions records the position of the nodes of a devfile by JSON pointer, and the JSON pointer of the elements of
+// its lists by name, so that errors can be located in the original YAML content once it has been converted to JSON
+type nodePositions struct {
+	nodes    map[string]position
+	elements map[elementKey]string
+}
+
+// newNodePositions returns the positions of the nodes of the YAML or JSON content, or nil if it cannot be decoded
+func newNodePositions(data []byte) *nodePositions {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil
+	}
+	positions := &nodePositions{nodes: map[string]position{}, elements: map[elementKey]string{}}
+	positions.walk(&root, "")
+	return positions
+}
+
+// walk records the position of node, at the JSON pointer, and of its children
+func (p *nodePositions) walk(node *yaml.Node, pointer string) {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) > 0 {
+			p.walk(node.Content[0], pointer)
+		}
+		return
+	}
+	// the position of a map value is the one of its key, which is recorded before walking the value
+	if _, ok := p.nodes[pointer]; !ok {
+		p.nodes[pointer] = position{line: node.Line, column: node.Column}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPointer := pointer + "/" + escapeJSONPointerToken(key.Value)
+			p.nodes[childPointer] = position{line: key.Line, column: key.Column}
+			p.walk(value, childPointer)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			childPointer := fmt.Sprintf("%s/%d", pointer, i)
+			if name := elementName(item); name != "" {
+				p.elements[elementKey{list: pointer, name: name}] = childPointer
+			}
+			p.walk(item, childPointer)
+		}
+	}
+}
+
+// elementName returns the name, or the id for commands, of a list element, or an empty string if it has none
+func elementName(node *yaml.Node) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if (key.Value == "name" || key.Value == "id") && value.Kind == yaml.ScalarNode {
+			return value.Value
+		}
+	}
+	return ""
+}
+
+// elementAt returns the name of the list element holding the node at the JSON pointer, or an empty string if the node
+// is not part of a named list element
+func (p *nodePositions) elementAt(jsonPointer string) string {
+	name := ""
+	longest := 0
+	for key, pointer := range p.elements {
+		if (jsonPointer == pointer || strings.HasPrefix(jsonPointer, pointer+"/")) && len(pointer) > longest {
+			name, longest = key.name, len(pointer)
+		}
+	}
+	return name
+}
+
+// ElementAt returns the name, or id for commands, of the innermost list element containing the node at the JSON pointer
+// in the devfile content, or an empty string if the node is not part of a named element
+func (d *DevfileCtx) ElementAt(jsonPointer string) string {
+	if d.positions == nil {
+		return ""
+	}
+	return d.positions.elementAt(jsonPointer)
+}
+
+// EscapeJSONPointerToken escapes a map key to be used as a JSON pointer token, as defined by RFC 6901
+func EscapeJSONPointerToken(token string) string {
+	return escapeJSONPointerToken(token)
+}
+
+// escapeJSONPointerToken escapes a map key to be used as a JSON pointer token, as defined by RFC 6901
+func escapeJSONPointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// Locate returns the location of the node at the JSON pointer in the devfile content. If the node is not part of the content,
+// e.g. because it was added while flattening the devfile, the line and column of its closest ancestor are returned.
+func (d *DevfileCtx) Locate(jsonPointer string) errPkg.Location {
+	location := errPkg.Location{File: d.absPath, JSONPointer: jsonPointer}
+	if location.File == "" {
+		location.File = d.url
+	}
+	if d.positions == nil {
+		return location
+	}
+	for pointer := jsonPointer; ; pointer = pointer[:strings.LastIndex(pointer, "/")] {
+		if position, ok := d.positions.nodes[pointer]; ok {
+			location.Line, location.Column = position.line, position.column
+			return location
+		}
+		if !strings.Contains(pointer, "/") {
+			return location
+		}
+	}
+}
+
+// LocateElement returns the location of the element with the given name, or id for commands, in the list of the devfile
+// at the JSON pointer list, e.g. /components or /parent/commands. The list pointer may contain * wildcards, as in
+// /components/*/plugin/components. False is returned if the devfile has no such element.
+func (d *DevfileCtx) LocateElement(list string, name string) (errPkg.Location, bool) {
+	if d.positions == nil {
+		return errPkg.Location{}, false
+	}
+	if pointer, ok := d.positions.elements[elementKey{list: list, name: name}]; ok {
+		return d.Locate(pointer), true
+	}
+	if !strings.Contains(list, "*") {
+		return errPkg.Location{}, false
+	}
+	var pointers []string
+	for key, pointer := range d.positions.elements {
+		if matched, _ := path.Match(list, key.list); matched && key.name == name {
+			pointers = append(pointers, pointer)
+		}
+	}
+	if len(pointers) == 0 {
+		return errPkg.Location{}, false
+	}
+	// several lists can match, e.g. the components of different plugins, keep the first one in the devfile
+	first := d.Locate(pointers[0])
+	for _, pointer := range pointers[1:] {
+		location := d.Locate(pointer)
+		if location.Line < first.Line || (location.Line == first.Line && location.Column < first.Column) {
+			first = location
+		}
+	}
+	return first, true
+}
@@ -0,0 +1,155 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const locatedDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+attributes:
+  example.com/source: registry
+components:
+  - name: runtime
+    container:
+      image: quay.io/nodejs
+  - name: theia
+    plugin:
+      uri: https://example.com/theia.yaml
+      components:
+        - name: theia-ide
+          container:
+            image: quay.io/theia
+commands:
+  - id: build
+    exec:
+      component: runtime
+      commandLine: npm install
+`
+
+func TestDevfileCtx_Locate(t *testing.T) {
+	d := NewURLDevfileCtx("https://example.com/devfile.yaml")
+	if err := d.SetDevfileContentFromBytes([]byte(locatedDevfile)); err != nil {
+		t.Fatalf("TestDevfileCtx_Locate() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		jsonPointer  string
+		wantLine     int
+		wantColumn   int
+		wantNotFound bool
+	}{
+		{
+			name:        "root",
+			jsonPointer: "",
+			wantLine:    1,
+			wantColumn:  1,
+		},
+		{
+			name:        "map value is located at its key",
+			jsonPointer: "/components/0/container/image",
+			wantLine:    9,
+			wantColumn:  7,
+		},
+		{
+			name:        "list element",
+			jsonPointer: "/commands/0",
+			wantLine:    18,
+			wantColumn:  5,
+		},
+		{
+			name:        "escaped map key",
+			jsonPointer: "/attributes/example.com~1source",
+			wantLine:    5,
+			wantColumn:  3,
+		},
+		{
+			name:        "missing node is located at its closest ancestor",
+			jsonPointer: "/components/0/container/env/0",
+			wantLine:    8,
+			wantColumn:  5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := errPkg.Location{File: "https://example.com/devfile.yaml", Line: tt.wantLine, Column: tt.wantColumn, JSONPointer: tt.jsonPointer}
+			assert.Equal(t, want, d.Locate(tt.jsonPointer), "TestDevfileCtx_Locate(): location should match")
+		})
+	}
+}
+
+func TestDevfileCtx_LocateElement(t *testing.T) {
+	d := NewDevfileCtx("devfile.yaml")
+	d.absPath = "/projects/devfile.yaml"
+	if err := d.SetDevfileContentFromBytes([]byte(locatedDevfile)); err != nil {
+		t.Fatalf("TestDevfileCtx_LocateElement() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		list        string
+		element     string
+		wantPointer string
+		wantLine    int
+	}{
+		{
+			name:        "component by name",
+			list:        "/components",
+			element:     "theia",
+			wantPointer: "/components/1",
+			wantLine:    10,
+		},
+		{
+			name:        "command by id",
+			list:        "/commands",
+			element:     "build",
+			wantPointer: "/commands/0",
+			wantLine:    18,
+		},
+		{
+			name:        "plugin override with a wildcard",
+			list:        "/components/*/plugin/components",
+			element:     "theia-ide",
+			wantPointer: "/components/1/plugin/components/0",
+			wantLine:    14,
+		},
+		{
+			name:    "missing element",
+			list:    "/components",
+			element: "theia-ide",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, ok := d.LocateElement(tt.list, tt.element)
+			if tt.wantPointer == "" {
+				assert.False(t, ok, "TestDevfileCtx_LocateElement(): element should not be found")
+				return
+			}
+			if assert.True(t, ok, "TestDevfileCtx_LocateElement(): element should be found") {
+				assert.Equal(t, "/projects/devfile.yaml", location.File)
+				assert.Equal(t, tt.wantPointer, location.JSONPointer)
+				assert.Equal(t, tt.wantLine, location.Line)
+			}
+		})
+	}
+}
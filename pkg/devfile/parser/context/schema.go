@@ -17,9 +17,11 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
 	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/devfile/library/v2/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/xeipuuv/gojsonschema"
 	"k8s.io/klog"
@@ -37,25 +39,88 @@ func (d *DevfileCtx) SetDevfileJSONSchema() error {
 	return nil
 }
 
+// compiledJSONSchema returns the compiled JSON schema of the devfile, the one shared for its apiVersion if it is supported
+func (d *DevfileCtx) compiledJSONSchema() (*gojsonschema.Schema, error) {
+	if data.IsApiVersionSupported(d.apiVersion) {
+		return data.GetCompiledDevfileJSONSchema(d.apiVersion)
+	}
+	return gojsonschema.NewSchema(gojsonschema.NewStringLoader(d.jsonSchema))
+}
+
+// schemaContextSeparator separates the tokens of a schema error context, it cannot be part of a key as YAML content
+// cannot contain a NUL character
+const schemaContextSeparator = "\x00"
+
+// SchemaErrorTokens returns the unescaped JSON pointer tokens of the node of a schema error context, e.g. (root)/components/0
+func SchemaErrorTokens(context *gojsonschema.JsonContext) []string {
+	tokens := strings.Split(context.String(schemaContextSeparator), schemaContextSeparator)
+	if tokens[0] == gojsonschema.STRING_CONTEXT_ROOT {
+		tokens = tokens[1:]
+	}
+	return tokens
+}
+
+// schemaErrorPointer returns the RFC 6901 JSON pointer of the node of a schema error context
+func schemaErrorPointer(context *gojsonschema.JsonContext) string {
+	var pointer string
+	for _, token := range SchemaErrorTokens(context) {
+		pointer += "/" + escapeJSONPointerToken(token)
+	}
+	return pointer
+}
+
+// isParentVersionConstraint returns true if the schema error is the version of the parent not matching the pattern of
+// exact versions because it is a semver constraint, e.g. ~2.1, resolved against the versions of the registry stack
+func isParentVersionConstraint(desc gojsonschema.ResultError) bool {
+	if desc.Type() != "pattern" || desc.Context().String("/") != gojsonschema.STRING_CONTEXT_ROOT+"/parent/version" {
+		return false
+	}
+	version, ok := desc.Value().(string)
+	return ok && util.IsVersionConstraint(version)
+}
+
 // ValidateDevfileSchema validate JSON schema of the provided devfile
 func (d *DevfileCtx) ValidateDevfileSchema() error {
-	var (
-		schemaLoader   = gojsonschema.NewStringLoader(d.jsonSchema)
-		documentLoader = gojsonschema.NewStringLoader(string(d.rawContent))
-	)
+	schema, err := d.compiledJSONSchema()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate devfile schema")
+	}
 
 	// Validate devfile with JSON schema
-	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(d.rawContent))
 	if err != nil {
 		return errors.Wrapf(err, "failed to validate devfile schema")
 	}
 
 	if !result.Valid() {
 		errMsg := "invalid devfile schema. errors :\n"
+		var issues errPkg.Issues
 		for _, desc := range result.Errors() {
+			if isParentVersionConstraint(desc) {
+				continue
+			}
 			errMsg = errMsg + fmt.Sprintf("- %s\n", desc)
+			// the context of an error is the path of the invalid node, e.g. (root)/components/0
+			pointer := schemaErrorPointer(desc.Context())
+			issue := &errPkg.Issue{
+				Code:      errPkg.CodeSchemaViolation,
+				Severity:  errPkg.SeverityError,
+				Message:   desc.String(),
+				Field:     pointer,
+				Locations: []errPkg.Location{d.Locate(pointer)},
+			}
+			// the offending field of a missing property error is the property itself
+			if property, ok := desc.Details()["property"].(string); ok && desc.Type() == "required" {
+				issue.Field = pointer + "/" + escapeJSONPointerToken(property)
+			}
+			if d.positions != nil {
+				issue.Element = d.positions.elementAt(pointer)
+			}
+			issues = append(issues, issue)
+		}
+		if len(issues) > 0 {
+			return &errPkg.NonCompliantDevfile{Err: errMsg, Issues: issues}
 		}
-		return &errPkg.NonCompliantDevfile{Err: errMsg}
 	}
 
 	// Sucessful
@@ -16,11 +16,18 @@
 package parser
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/xeipuuv/gojsonschema"
 
 	v200 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2/2.0.0"
+	v220 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2/2.2.0"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
 )
 
 const (
@@ -168,8 +175,159 @@ func TestValidateDevfileSchema(t *testing.T) {
 			assert.Regexp(t, expectedErr, err.Error(), "TestValidateDevfileSchema(): Error message should match")
 		}
 	})
+
+	t.Run("invalid 2.2.0 yaml schema errors are located", func(t *testing.T) {
+		d := DevfileCtx{jsonSchema: v220.JsonSchema220, absPath: "/projects/devfile.yaml"}
+		err := d.SetDevfileContentFromBytes([]byte(`schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+components:
+  - name: runtime
+    container:
+      mountSources: true
+`))
+		if err != nil {
+			t.Fatalf("TestValidateDevfileSchema() unexpected error: '%v'", err)
+		}
+
+		err = d.ValidateDevfileSchema()
+		var nonCompliantErr *errPkg.NonCompliantDevfile
+		if assert.True(t, errors.As(err, &nonCompliantErr), "TestValidateDevfileSchema(): expected a NonCompliantDevfile error, got: %v", err) &&
+			assert.Len(t, nonCompliantErr.Issues, 1) {
+			issue := nonCompliantErr.Issues[0]
+			assert.Regexp(t, "image is required", issue.Error())
+			assert.Equal(t, errPkg.CodeSchemaViolation, issue.Code)
+			assert.Equal(t, errPkg.SeverityError, issue.Severity)
+			assert.Equal(t, "/components/0/container/image", issue.Field)
+			assert.Equal(t, "runtime", issue.Element)
+			assert.Equal(t, []errPkg.Location{{File: "/projects/devfile.yaml", Line: 6, Column: 5, JSONPointer: "/components/0/container"}},
+				issue.Locations, "TestValidateDevfileSchema(): error location should match")
+		}
+	})
+
+	t.Run("invalid 2.2.0 yaml schema errors under keys with a slash or a tilde are located", func(t *testing.T) {
+		d := DevfileCtx{jsonSchema: v220.JsonSchema220, absPath: "/projects/devfile.yaml"}
+		err := d.SetDevfileContentFromBytes([]byte(`schemaVersion: 2.2.0
+metadata:
+  name: nodejs
+components:
+  - name: runtime
+    container:
+      image: quay.io/runtime
+      annotation:
+        deployment:
+          app.kubernetes.io/name: [nodejs]
+          example.com/~version: 1
+`))
+		if err != nil {
+			t.Fatalf("TestValidateDevfileSchema() unexpected error: '%v'", err)
+		}
+
+		err = d.ValidateDevfileSchema()
+		var nonCompliantErr *errPkg.NonCompliantDevfile
+		if assert.True(t, errors.As(err, &nonCompliantErr), "TestValidateDevfileSchema(): expected a NonCompliantDevfile error, got: %v", err) &&
+			assert.Len(t, nonCompliantErr.Issues, 2) {
+			var locations []errPkg.Location
+			for _, issue := range nonCompliantErr.Issues {
+				assert.Equal(t, issue.Field, issue.Locations[0].JSONPointer, "TestValidateDevfileSchema(): the field should be the located node")
+				locations = append(locations, issue.Locations...)
+			}
+			assert.ElementsMatch(t, []errPkg.Location{
+				{File: "/projects/devfile.yaml", Line: 10, Column: 11, JSONPointer: "/components/0/container/annotation/deployment/app.kubernetes.io~1name"},
+				{File: "/projects/devfile.yaml", Line: 11, Column: 11, JSONPointer: "/components/0/container/annotation/deployment/example.com~1~0version"},
+			}, locations, "TestValidateDevfileSchema(): error locations should match")
+		}
+	})
+
+	for version, wantErr := range map[string]string{"~2.1": "", ">=1.2 <2": "", "bad": "parent.version: Does not match pattern"} {
+		t.Run("2.2.0 parent version "+version, func(t *testing.T) {
+			d := DevfileCtx{jsonSchema: v220.JsonSchema220}
+			err := d.SetDevfileContentFromBytes([]byte("schemaVersion: 2.2.0\nparent:\n  id: nodejs\n  version: '" + version + "'\n"))
+			if err != nil {
+				t.Fatalf("TestValidateDevfileSchema() unexpected error: '%v'", err)
+			}
+
+			err = d.ValidateDevfileSchema()
+			if wantErr == "" {
+				assert.NoError(t, err, "TestValidateDevfileSchema(): version constraints of the parent should be valid")
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), wantErr)
+			}
+		})
+	}
 }
 
 func validJsonRawContent200() []byte {
 	return []byte(validJson200)
 }
+
+func TestValidateDevfileSchema_Concurrent(t *testing.T) {
+	d, err := NewByteContentDevfileCtx([]byte(validJson200))
+	if err != nil {
+		t.Fatalf("TestValidateDevfileSchema_Concurrent() unexpected error: '%v'", err)
+	}
+	if err = d.populateDevfile(); err != nil {
+		t.Fatalf("TestValidateDevfileSchema_Concurrent() unexpected error: '%v'", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, d.ValidateDevfileSchema(), "TestValidateDevfileSchema_Concurrent(): devfile should be valid")
+		}()
+	}
+	wg.Wait()
+}
+
+// sampleDevfileCtxs returns the contexts of the sample devfiles under tests/v2/devfiles/samples
+func sampleDevfileCtxs(b *testing.B) []DevfileCtx {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "..", "..", "tests", "v2", "devfiles", "samples", "*.yaml"))
+	if err != nil || len(paths) == 0 {
+		b.Fatalf("failed to find the sample devfiles: %v", err)
+	}
+	var ctxs []DevfileCtx
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatalf("failed to read %s: %v", path, err)
+		}
+		d, err := NewByteContentDevfileCtx(content)
+		if err != nil {
+			b.Fatalf("failed to read %s: %v", path, err)
+		}
+		if err = d.populateDevfile(); err != nil {
+			b.Fatalf("failed to read %s: %v", path, err)
+		}
+		ctxs = append(ctxs, d)
+	}
+	return ctxs
+}
+
+// BenchmarkValidateDevfileSchema compares the validation of the sample devfiles with the shared compiled schemas to the
+// validation compiling the schema every time
+func BenchmarkValidateDevfileSchema(b *testing.B) {
+	ctxs := sampleDevfileCtxs(b)
+
+	b.Run("compiled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, d := range ctxs {
+				if err := d.ValidateDevfileSchema(); err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		}
+	})
+
+	b.Run("uncompiled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, d := range ctxs {
+				result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(d.jsonSchema), gojsonschema.NewBytesLoader(d.rawContent))
+				if err != nil || !result.Valid() {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		}
+	})
+}
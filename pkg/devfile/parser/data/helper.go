@@ -21,6 +21,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/xeipuuv/gojsonschema"
 	"k8s.io/klog"
 )
 
@@ -60,6 +61,20 @@ func GetDevfileJSONSchema(version string) (string, error) {
 	return schema, nil
 }
 
+// GetCompiledDevfileJSONSchema returns the compiled devfile JSON schema of the supported apiVersion. The schema is
+// compiled the first time it is requested, then the same schema is returned to every caller, it is safe for concurrent use.
+func GetCompiledDevfileJSONSchema(version string) (*gojsonschema.Schema, error) {
+	compiled, ok := devfileApiVersionToCompiledJSONSchema[supportedApiVersion(version)]
+	if !ok {
+		_, err := GetDevfileJSONSchema(version)
+		return nil, err
+	}
+	compiled.once.Do(func() {
+		compiled.schema, compiled.err = gojsonschema.NewSchema(gojsonschema.NewStringLoader(devfileApiVersionToJSONSchema[supportedApiVersion(version)]))
+	})
+	return compiled.schema, compiled.err
+}
+
 // IsApiVersionSupported returns true if the API version is supported
 func IsApiVersionSupported(version string) bool {
 	return apiVersionToDevfileStruct[supportedApiVersion(version)] != nil
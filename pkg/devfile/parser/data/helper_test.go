@@ -92,6 +92,50 @@ func TestGetDevfileJSONSchema(t *testing.T) {
 	})
 }
 
+func TestGetCompiledDevfileJSONSchema(t *testing.T) {
+
+	t.Run("valid devfile apiVersion", func(t *testing.T) {
+
+		var (
+			version  = APISchemaVersion220
+			got, err = GetCompiledDevfileJSONSchema(string(version))
+		)
+
+		if err != nil {
+			t.Errorf("did not expect an error '%v'", err)
+		}
+
+		again, _ := GetCompiledDevfileJSONSchema(string(version))
+		if got == nil || got != again {
+			t.Errorf("the compiled json schema should be shared")
+		}
+	})
+
+	t.Run("apiVersions with the same json schema", func(t *testing.T) {
+
+		var (
+			got, _  = GetCompiledDevfileJSONSchema(string(APIVersionAlpha2))
+			want, _ = GetCompiledDevfileJSONSchema(string(APISchemaVersion230))
+		)
+
+		if got != want {
+			t.Errorf("the compiled json schema should be shared by apiVersions with the same json schema")
+		}
+	})
+
+	t.Run("invalid devfile apiVersion", func(t *testing.T) {
+
+		var (
+			version = "invalidVersion"
+			_, err  = GetCompiledDevfileJSONSchema(string(version))
+		)
+
+		if err == nil {
+			t.Errorf("expected an error, didn't get one")
+		}
+	})
+}
+
 func TestIsApiVersionSupported(t *testing.T) {
 
 	t.Run("valid devfile apiVersion", func(t *testing.T) {
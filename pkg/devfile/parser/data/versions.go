@@ -17,6 +17,7 @@ package data
 
 import (
 	"reflect"
+	"sync"
 
 	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
 	v200 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2/2.0.0"
@@ -25,6 +26,7 @@ import (
 	v221 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2/2.2.1"
 	v222 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2/2.2.2"
 	v230 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2/2.3.0"
+	"github.com/xeipuuv/gojsonschema"
 )
 
 // SupportedApiVersions stores the supported devfile API versions
@@ -73,3 +75,26 @@ func init() {
 	// should use hightest v2 schema version since it is expected to be backward compatible with the same api version
 	devfileApiVersionToJSONSchema[APIVersionAlpha2] = v230.JsonSchema230
 }
+
+// compiledJSONSchema is a devfile JSON schema compiled on first use, then shared by all validations
+type compiledJSONSchema struct {
+	once   sync.Once
+	schema *gojsonschema.Schema
+	err    error
+}
+
+// Map to store mappings between supported devfile API versions and respective compiled devfile JSON schemas
+var devfileApiVersionToCompiledJSONSchema map[supportedApiVersion]*compiledJSONSchema
+
+// init initializes a map of supported devfile apiVersions with it's respective compiled devfile JSON schema,
+// apiVersions sharing the same JSON schema share the same compiled schema
+func init() {
+	devfileApiVersionToCompiledJSONSchema = make(map[supportedApiVersion]*compiledJSONSchema)
+	compiledSchemas := make(map[string]*compiledJSONSchema)
+	for version, schema := range devfileApiVersionToJSONSchema {
+		if _, ok := compiledSchemas[schema]; !ok {
+			compiledSchemas[schema] = &compiledJSONSchema{}
+		}
+		devfileApiVersionToCompiledJSONSchema[version] = compiledSchemas[schema]
+	}
+}
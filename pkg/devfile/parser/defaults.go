@@ -0,0 +1,137 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+)
+
+// booleanField is a boolean field of a component or a command that is set to its default value when nil
+type booleanField struct {
+	// path is the path of the field in the element, e.g. container/mountSources
+	path string
+	// field is the field
+	field **bool
+	// value is the default value of the field
+	value bool
+	// valueOf computes the default value of the field from the other fields of the element, if it depends on them.
+	// The fields it depends on come first in the fields of the element, so they are defaulted when it is called
+	valueOf func() bool
+}
+
+// defaultValue returns the default value of the field
+func (field booleanField) defaultValue() bool {
+	if field.valueOf != nil {
+		return field.valueOf()
+	}
+	return field.value
+}
+
+// defaultedFields are the boolean fields the parser set to their default values, by element and path,
+// e.g. components/runtime/container/mountSources
+type defaultedFields map[string]bool
+
+// setDefault sets the field of the element to its default value if it is nil, and records it
+func (f defaultedFields) setDefault(element string, field booleanField) {
+	if *field.field != nil {
+		return
+	}
+	value := field.defaultValue()
+	*field.field = &value
+	if f != nil {
+		f[element+"/"+field.path] = true
+	}
+}
+
+// unsetDefault unsets the field of the element if the parser set it and it is still set to its default value
+func (f defaultedFields) unsetDefault(element string, field booleanField) {
+	if f[element+"/"+field.path] && *field.field != nil && **field.field == field.defaultValue() {
+		*field.field = nil
+	}
+}
+
+// commandBooleans returns the boolean fields of the command that are set to their default values when nil
+func commandBooleans(command *v1.Command) []booleanField {
+	var fields []booleanField
+	var cmdGroup *v1.CommandGroup
+	var groupPath string
+	if command.Exec != nil {
+		fields = append(fields, booleanField{path: "exec/hotReloadCapable", field: &command.Exec.HotReloadCapable})
+		cmdGroup, groupPath = command.Exec.Group, "exec/group"
+	} else if command.Composite != nil {
+		fields = append(fields, booleanField{path: "composite/parallel", field: &command.Composite.Parallel})
+		cmdGroup, groupPath = command.Composite.Group, "composite/group"
+	} else if command.Apply != nil {
+		cmdGroup, groupPath = command.Apply.Group, "apply/group"
+	}
+	if cmdGroup != nil {
+		fields = append(fields, booleanField{path: groupPath + "/isDefault", field: &cmdGroup.IsDefault})
+	}
+	return fields
+}
+
+// componentBooleans returns the boolean fields of the component that are set to their default values when nil for the
+// devfile schema version, all of them if the version is empty
+func componentBooleans(component *v1.Component, devfileVersion string) []booleanField {
+	var fields []booleanField
+	var endpoints []v1.Endpoint
+	var endpointsPath string
+	if component.Container != nil {
+		container := component.Container
+		fields = append(fields,
+			booleanField{path: "container/dedicatedPod", field: &container.DedicatedPod},
+			booleanField{path: "container/mountSources", field: &container.MountSources, valueOf: func() bool {
+				// sources are not mounted by default in a dedicated pod
+				return (&v1.Container{DedicatedPod: container.DedicatedPod}).GetMountSources()
+			}})
+		endpoints, endpointsPath = container.Endpoints, "container/endpoints"
+	} else if component.Kubernetes != nil {
+		endpoints, endpointsPath = component.Kubernetes.Endpoints, "kubernetes/endpoints"
+		if devfileVersion != string(data.APISchemaVersion200) && devfileVersion != string(data.APISchemaVersion210) {
+			fields = append(fields, booleanField{path: "kubernetes/deployByDefault", field: &component.Kubernetes.DeployByDefault})
+		}
+	} else if component.Openshift != nil {
+		endpoints, endpointsPath = component.Openshift.Endpoints, "openshift/endpoints"
+		if devfileVersion != string(data.APISchemaVersion200) && devfileVersion != string(data.APISchemaVersion210) {
+			fields = append(fields, booleanField{path: "openshift/deployByDefault", field: &component.Openshift.DeployByDefault})
+		}
+	} else if component.Volume != nil && devfileVersion != string(data.APISchemaVersion200) {
+		fields = append(fields, booleanField{path: "volume/ephemeral", field: &component.Volume.Ephemeral})
+	} else if component.Image != nil {
+		//we don't need to do a schema version check since Image in v2.2.0.  If used in older specs, a parser error would occur
+		if component.Image.Dockerfile != nil {
+			fields = append(fields, booleanField{path: "image/dockerfile/rootRequired", field: &component.Image.Dockerfile.RootRequired})
+		}
+		fields = append(fields, booleanField{path: "image/autoBuild", field: &component.Image.AutoBuild})
+	}
+	for i := range endpoints {
+		fields = append(fields, booleanField{path: fmt.Sprintf("%s/%s/secure", endpointsPath, endpoints[i].Name), field: &endpoints[i].Secure})
+	}
+	return fields
+}
+
+// commandElement returns the element of a command in the defaulted fields
+func commandElement(command v1.Command) string {
+	return "commands/" + command.Id
+}
+
+// componentElement returns the element of a component in the defaulted fields
+func componentElement(component v1.Component) string {
+	return "components/" + component.Name
+}
@@ -16,8 +16,12 @@
 package parser
 
 import (
+	"encoding/json"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
 )
 
 // Default filenames for create devfile
@@ -34,4 +38,52 @@ type DevfileObj struct {
 
 	// Data has the devfile data
 	Data data.DevfileData
+
+	// Lockfile records the resolution of the parent and plugins, it is only set if ParserArgs.GenerateLockfile is true
+	Lockfile *Lockfile
+
+	// Warnings are the non-fatal issues found while parsing, e.g. invalid HTTP timeouts, HTTP responses that could not be
+	// cached or the deprecated constructs reported in strict mode. ParseDevfileAndValidate adds the warnings of variable
+	// substitution and image names replacement.
+	Warnings errPkg.Issues
+
+	// Variables are the values the variable references were replaced with, along with their source, by variable name.
+	// They are only set by ParseDevfileAndValidate.
+	Variables map[string]ResolvedVariable
+
+	// Provenance reports where the content of the devfile came from, it is only set if ParserArgs.RecordProvenance is true
+	Provenance *Provenance
+
+	// sources are the devfiles imported while flattening the devfile, used to locate errors
+	sources *devfileSources
+
+	// resolvedReference is the import reference the devfile was resolved from, with the version its semver constraint
+	// was resolved to for registry imports
+	resolvedReference v1.ImportReference
+
+	// defaulted are the boolean fields the parser set to their default values
+	defaulted defaultedFields
+
+	// flattened is true if the data is the flattened content of the devfile, see ParserArgs.FlattenedDevfile
+	flattened bool
+
+	// parentContent is the content of the resolved parent of the devfile, before the parent overrides are applied. It is
+	// only set if the devfile was flattened and has a parent.
+	parentContent *v1.DevWorkspaceTemplateSpecContent
+
+	// unchanged is the JSON content of the data when it was parsed or last set as unchanged, the changes made to the
+	// data since then are the ones written by WriteYamlDevfile
+	unchanged []byte
+}
+
+// SetUnchanged records the current data as unchanged: WriteYamlDevfile only writes the changes made to the data from
+// now on, and leaves the rest of the original content of the devfile as it is. Parsing a devfile sets its data as
+// unchanged, as ParseDevfileAndValidate does once the variables and image names are replaced.
+func (d *DevfileObj) SetUnchanged() error {
+	content, err := json.Marshal(d.Data)
+	if err != nil {
+		return err
+	}
+	d.unchanged = content
+	return nil
 }
@@ -0,0 +1,123 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/devfile/api/v2/pkg/attributes"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// internalAttributes are the attributes the parser adds to the elements of the devfile
+var internalAttributes = []string{K8sLikeComponentOriginalURIKey, importSourceAttribute, parentOverrideAttribute, pluginOverrideAttribute}
+
+// EncodeOptions are the options of the encoding of a devfile
+type EncodeOptions struct {
+	// Minimal encodes the devfile as it was authored: the boolean fields the parser set to their default values, see
+	// ParserArgs.SetBooleanDefaults, the attributes added by the parser, e.g. the import source of the elements of a
+	// parent, and the content inlined from the uri of the Kubernetes and OpenShift components are left out
+	Minimal bool
+}
+
+// EncodeYaml writes the devfile data to w as YAML. The values of the sensitive variables are written as placeholders
+// to the variables, e.g. {{name}}. Unlike WriteYamlDevfile, the data is left as it is, and the devfile can have been
+// parsed from any source.
+func (d DevfileObj) EncodeYaml(w io.Writer, options EncodeOptions) error {
+	data, err := d.encodedData(options)
+	if err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal devfile object into yaml")
+	}
+	if _, err = w.Write(content); err != nil {
+		return errors.Wrapf(err, "failed to write the devfile")
+	}
+	return nil
+}
+
+// EncodeJson writes the devfile data to w as indented JSON. The values of the sensitive variables are written as
+// placeholders to the variables, e.g. {{name}}. The data is left as it is.
+func (d DevfileObj) EncodeJson(w io.Writer, options EncodeOptions) error {
+	data, err := d.encodedData(options)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(data); err != nil {
+		return errors.Wrapf(err, "failed to write the devfile as json")
+	}
+	return nil
+}
+
+// encodedData returns the devfile data to encode, with the values of the sensitive variables replaced with placeholders
+func (d DevfileObj) encodedData(options EncodeOptions) (interface{}, error) {
+	if options.Minimal {
+		d.Data = copyDevfileData(d.Data)
+		if err := d.minimize(); err != nil {
+			return nil, errors.Wrapf(err, "failed to strip the values set by the parser")
+		}
+	}
+	data, err := d.redactedData()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to redact sensitive variables")
+	}
+	return data, nil
+}
+
+// minimize strips the boolean fields set to their default values, the internal attributes and the inlined content of
+// the Kubernetes and OpenShift components from the devfile data
+func (d *DevfileObj) minimize() error {
+	if err := restoreK8sCompURI(d); err != nil {
+		return err
+	}
+	content := d.Data.GetDevfileWorkspaceSpecContent()
+	for i := range content.Components {
+		for _, field := range componentBooleans(&content.Components[i], "") {
+			d.defaulted.unsetDefault(componentElement(content.Components[i]), field)
+		}
+		content.Components[i].Attributes = withoutInternalAttributes(content.Components[i].Attributes)
+	}
+	for i := range content.Commands {
+		for _, field := range commandBooleans(&content.Commands[i]) {
+			d.defaulted.unsetDefault(commandElement(content.Commands[i]), field)
+		}
+		content.Commands[i].Attributes = withoutInternalAttributes(content.Commands[i].Attributes)
+	}
+	for i := range content.Projects {
+		content.Projects[i].Attributes = withoutInternalAttributes(content.Projects[i].Attributes)
+	}
+	for i := range content.StarterProjects {
+		content.StarterProjects[i].Attributes = withoutInternalAttributes(content.StarterProjects[i].Attributes)
+	}
+	return nil
+}
+
+// withoutInternalAttributes removes the internal attributes from the attributes, returning nil if no attribute is left
+func withoutInternalAttributes(elementAttributes attributes.Attributes) attributes.Attributes {
+	for _, key := range internalAttributes {
+		delete(elementAttributes, key)
+	}
+	if len(elementAttributes) == 0 {
+		return nil
+	}
+	return elementAttributes
+}
@@ -0,0 +1,156 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevfileObj_Encode(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/devfile.yaml": &fstest.MapFile{Data: []byte(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: ../stacks/parent.yaml
+components:
+- name: runtime
+  attributes:
+    team: devtools
+  container:
+    image: quay.io/runtime
+    dedicatedPod: false
+    endpoints:
+    - name: http
+      targetPort: 8080
+- name: deploy
+  kubernetes:
+    uri: manifests/deploy.yaml
+commands:
+- id: run
+  exec:
+    component: runtime
+    commandLine: run
+    group:
+      kind: run
+`)},
+		"project/manifests/deploy.yaml": &fstest.MapFile{Data: []byte("kind: Deployment\n")},
+		"stacks/parent.yaml": &fstest.MapFile{Data: []byte(`schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: tools
+  container:
+    image: quay.io/tools
+`)},
+	}
+
+	tests := []struct {
+		name         string
+		json         bool
+		minimal      bool
+		wantContent  string
+		wantContains []string
+	}{
+		{
+			name:    "minimal yaml",
+			minimal: true,
+			wantContent: `commands:
+- exec:
+    commandLine: run
+    component: runtime
+    group:
+      kind: run
+  id: run
+components:
+- container:
+    image: quay.io/tools
+  name: tools
+- attributes:
+    team: devtools
+  container:
+    dedicatedPod: false
+    endpoints:
+    - name: http
+      targetPort: 8080
+    image: quay.io/runtime
+  name: runtime
+- kubernetes:
+    uri: manifests/deploy.yaml
+  name: deploy
+metadata:
+  name: main
+schemaVersion: 2.2.0
+`,
+		},
+		{
+			name:    "minimal json",
+			json:    true,
+			minimal: true,
+			wantContains: []string{
+				`"uri": "manifests/deploy.yaml"`,
+				`"dedicatedPod": false`,
+			},
+		},
+		{
+			name: "yaml",
+			wantContains: []string{
+				"mountSources: true",
+				"secure: false",
+				"isDefault: false",
+				`api.devfile.io/imported-from: 'uri: ../stacks/parent.yaml'`,
+				"inlined: |\n      kind: Deployment",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDevfile(ParserArgs{FS: fsys, Path: "project/devfile.yaml"})
+			if err != nil {
+				t.Fatalf("TestDevfileObj_Encode() unexpected error parsing the devfile: %v", err)
+			}
+			var buffer bytes.Buffer
+			encode := d.EncodeYaml
+			if tt.json {
+				encode = d.EncodeJson
+			}
+			if err = encode(&buffer, EncodeOptions{Minimal: tt.minimal}); err != nil {
+				t.Fatalf("TestDevfileObj_Encode() unexpected error: %v", err)
+			}
+			if tt.wantContent != "" {
+				assert.Equal(t, tt.wantContent, buffer.String(), "TestDevfileObj_Encode(): content should match")
+			}
+			for _, want := range tt.wantContains {
+				assert.Contains(t, buffer.String(), want, "TestDevfileObj_Encode(): content should contain the field")
+			}
+			if tt.minimal {
+				assert.NotContains(t, buffer.String(), "mountSources", "TestDevfileObj_Encode(): the default values should be left out")
+				assert.NotContains(t, buffer.String(), "api.devfile.io", "TestDevfileObj_Encode(): the internal attributes should be left out")
+			}
+
+			components, err := d.Data.GetComponents(common.DevfileOptions{FilterByName: "runtime"})
+			if err != nil {
+				t.Fatalf("TestDevfileObj_Encode() unexpected error getting the components: %v", err)
+			}
+			assert.NotNil(t, components[0].Container.MountSources, "TestDevfileObj_Encode(): the data should be left as it is")
+		})
+	}
+}
@@ -15,11 +15,18 @@
 
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // NonCompliantDevfile returns an error if devfile parsing failed due to Non-Compliant Devfile
 type NonCompliantDevfile struct {
 	Err string
+	// Issues are the individual issues making the devfile non-compliant, along with the location of the devfile nodes
+	// causing them when known. They are only listed for schema, data validation, override and merge errors.
+	// Use GetIssues to get the issues of any error.
+	Issues Issues
 }
 
 func (e *NonCompliantDevfile) Error() string {
@@ -29,3 +36,103 @@ func (e *NonCompliantDevfile) Error() string {
 	}
 	return errMsg
 }
+
+// Location locates a node of a devfile
+type Location struct {
+	// File is the absolute path or the URL of the devfile, it is empty for devfiles that were not read from a file or a URL
+	File string
+	// Line is the 1-based line of the node, it is 0 if the position of the node is unknown
+	Line int
+	// Column is the 1-based column of the node, it is 0 if the position of the node is unknown
+	Column int
+	// JSONPointer is the RFC 6901 JSON pointer of the node, e.g. /components/0/container/image
+	JSONPointer string
+}
+
+func (l Location) String() string {
+	location := l.File
+	if l.Line > 0 {
+		location = strings.TrimPrefix(fmt.Sprintf("%s:%d:%d", location, l.Line, l.Column), ":")
+	}
+	if l.JSONPointer != "" {
+		location = fmt.Sprintf("%s#%s", location, l.JSONPointer)
+	}
+	return location
+}
+
+// ParseCancelled returns an error if devfile parsing was interrupted because the context passed in
+// was cancelled or its deadline was exceeded
+type ParseCancelled struct {
+	// Reference is the import reference being resolved when parsing was interrupted
+	Reference string
+	// Err is the context error, either context.Canceled or context.DeadlineExceeded
+	Err error
+}
+
+func (e *ParseCancelled) Error() string {
+	errMsg := "devfile parsing was cancelled"
+	if e.Reference != "" {
+		errMsg = fmt.Sprintf("%s while resolving %s", errMsg, e.Reference)
+	}
+	if e.Err != nil {
+		errMsg = fmt.Sprintf("%s: %v", errMsg, e.Err)
+	}
+	return errMsg
+}
+
+// Unwrap returns the context error so that callers can use errors.Is(err, context.Canceled)
+func (e *ParseCancelled) Unwrap() error {
+	return e.Err
+}
+
+// LockfileMismatch returns an error if, when resolving strictly from a lockfile, an import reference
+// is not recorded in the lockfile or the content fetched for it does not match the recorded digest
+type LockfileMismatch struct {
+	// Reference is the import reference being resolved
+	Reference string
+	// ImportedFrom is the devfile containing the import reference
+	ImportedFrom string
+	// Expected is the digest recorded in the lockfile, it is empty if the import reference is not recorded
+	Expected string
+	// Actual is the digest of the fetched content
+	Actual string
+}
+
+func (e *LockfileMismatch) Error() string {
+	if e.Expected == "" {
+		return fmt.Sprintf("%s imported from %s is not recorded in the lockfile", e.Reference, e.ImportedFrom)
+	}
+	return fmt.Sprintf("digest mismatch for %s imported from %s: the lockfile records %s, fetched content has %s", e.Reference, e.ImportedFrom, e.Expected, e.Actual)
+}
+
+// OfflineCacheMiss returns an error if, when parsing offline, a remote reference was not vendored in the offline vault
+type OfflineCacheMiss struct {
+	// Reference is the remote reference that is missing, e.g. a devfile URL, a registry id or a git repository
+	Reference string
+	// Vault is the path of the offline vault
+	Vault string
+}
+
+func (e *OfflineCacheMiss) Error() string {
+	return fmt.Sprintf("%s is not available in the offline vault %s, vendor the devfile while online to add it", e.Reference, e.Vault)
+}
+
+// NoMatchingVersion returns an error if no version of a registry stack matches the version constraint of an import
+// reference, or if the stack is not listed in the index of the registry
+type NoMatchingVersion struct {
+	// Id is the id of the stack
+	Id string
+	// RegistryURL is the URL of the registry
+	RegistryURL string
+	// Constraint is the version constraint, it is empty if the stack is not listed in the index of the registry
+	Constraint string
+	// Versions are the versions of the stack available in the registry
+	Versions []string
+}
+
+func (e *NoMatchingVersion) Error() string {
+	if e.Constraint == "" {
+		return fmt.Sprintf("stack %s is not found in the index of registry %s", e.Id, e.RegistryURL)
+	}
+	return fmt.Sprintf("no version of stack %s in registry %s matches %s, available versions: %s", e.Id, e.RegistryURL, e.Constraint, strings.Join(e.Versions, ", "))
+}
@@ -0,0 +1,186 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// IssueCode identifies the kind of an issue, codes are stable and can be relied upon instead of messages
+type IssueCode string
+
+const (
+	// CodeNonCompliantDevfile is the code of the issues that have no more specific code
+	CodeNonCompliantDevfile IssueCode = "NonCompliantDevfile"
+	// CodeSchemaViolation is the code of the issues found while validating a devfile against its JSON schema
+	CodeSchemaViolation IssueCode = "SchemaViolation"
+	// CodeOverrideConflict is the code of the issues about parent or plugin overrides of elements that do not exist
+	CodeOverrideConflict IssueCode = "OverrideConflict"
+	// CodeMergeConflict is the code of the issues about elements defined in both a devfile and its parent or plugins
+	CodeMergeConflict IssueCode = "MergeConflict"
+	// CodeInvalidComponent is the code of the issues about invalid components
+	CodeInvalidComponent IssueCode = "InvalidComponent"
+	// CodeInvalidVolume is the code of the issues about invalid volume components
+	CodeInvalidVolume IssueCode = "InvalidVolume"
+	// CodeMissingVolumeMount is the code of the issues about container volume mounts without a matching volume component
+	CodeMissingVolumeMount IssueCode = "MissingVolumeMount"
+	// CodeReservedEnv is the code of the issues about reserved environment variables set in a container component
+	CodeReservedEnv IssueCode = "ReservedEnv"
+	// CodeInvalidResourceRequirement is the code of the issues about invalid memory or cpu requests and limits of a component
+	CodeInvalidResourceRequirement IssueCode = "InvalidResourceRequirement"
+	// CodeDuplicateEndpoint is the code of the issues about endpoints sharing a name or a target port
+	CodeDuplicateEndpoint IssueCode = "DuplicateEndpoint"
+	// CodeAnnotationConflict is the code of the issues about deployment or service annotations declared with different values
+	CodeAnnotationConflict IssueCode = "AnnotationConflict"
+	// CodeDuplicateKey is the code of the issues about elements of a list sharing a name or an id
+	CodeDuplicateKey IssueCode = "DuplicateKey"
+	// CodeInvalidCommand is the code of the issues about invalid commands
+	CodeInvalidCommand IssueCode = "InvalidCommand"
+	// CodeMultipleDefaultCommands is the code of the issues about command groups with several default commands
+	CodeMultipleDefaultCommands IssueCode = "MultipleDefaultCommands"
+	// CodeMissingDefaultCommand is the code of the issues about command groups without a default command
+	CodeMissingDefaultCommand IssueCode = "MissingDefaultCommand"
+	// CodeInvalidEvent is the code of the issues about events referencing invalid commands
+	CodeInvalidEvent IssueCode = "InvalidEvent"
+	// CodeInvalidProject is the code of the issues about invalid projects
+	CodeInvalidProject IssueCode = "InvalidProject"
+	// CodeInvalidStarterProject is the code of the issues about invalid starter projects
+	CodeInvalidStarterProject IssueCode = "InvalidStarterProject"
+	// CodeUnknownField is the code of the issues about fields unknown to the devfile API, reported in strict mode
+	CodeUnknownField IssueCode = "UnknownField"
+	// CodeDeprecated is the code of the issues about deprecated constructs, reported in strict mode
+	CodeDeprecated IssueCode = "Deprecated"
+	// CodeInvalidHTTPTimeout is the code of the warnings about HTTP timeouts that are ignored in favor of the default one
+	CodeInvalidHTTPTimeout IssueCode = "InvalidHTTPTimeout"
+	// CodeHTTPCacheUnavailable is the code of the warnings about HTTP responses that could not be cached
+	CodeHTTPCacheUnavailable IssueCode = "HTTPCacheUnavailable"
+	// CodeUndefinedVariable is the code of the warnings about elements referencing variables that are not defined
+	CodeUndefinedVariable IssueCode = "UndefinedVariable"
+	// CodeUnmatchedImageSelector is the code of the warnings about relative image names, used as selectors, that do not
+	// match the image of any component
+	CodeUnmatchedImageSelector IssueCode = "UnmatchedImageSelector"
+	// CodeKubernetesUriNotPatched is the code of the warnings about Kubernetes and OpenShift components referencing their
+	// resources with a uri, in which images names cannot be replaced
+	CodeKubernetesUriNotPatched IssueCode = "KubernetesUriNotPatched"
+	// CodeFieldDropped is the code of the changes about fields and elements dropped while converting a devfile to another
+	// schema version, as the target version does not support them
+	CodeFieldDropped IssueCode = "FieldDropped"
+	// CodeFieldTransformed is the code of the changes about fields transformed while converting a devfile to another schema
+	// version, to be expressed in the target version
+	CodeFieldTransformed IssueCode = "FieldTransformed"
+)
+
+// Severity is the severity of an issue
+type Severity string
+
+const (
+	// SeverityError is the severity of issues that make the devfile invalid
+	SeverityError Severity = "error"
+	// SeverityWarning is the severity of issues that do not prevent the devfile from being used
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is an individual issue found in a devfile
+type Issue struct {
+	// Code identifies the kind of issue
+	Code IssueCode
+	// Severity is the severity of the issue
+	Severity Severity
+	// Message describes the issue, it is the message of the error the issue was found from
+	Message string
+	// Field is the JSON pointer of the offending field in the devfile it was found in, i.e. the flattened devfile for
+	// data validation issues, e.g. /components/2, and the devfile content for schema, override and merge issues
+	Field string
+	// Element is the name of the offending component, project or starter project, or the id of the offending command
+	Element string
+	// ImportReference is the import reference of the parent or plugin devfile the offending element is defined in,
+	// as written in the devfile importing it. It is empty for the main devfile.
+	ImportReference string
+	// Locations are the locations of the offending devfile nodes, when known
+	Locations []Location
+	// Err is the error the issue was found from, e.g. a validation error of devfile/api, if any
+	Err error
+}
+
+func (i *Issue) Error() string {
+	return i.Message
+}
+
+// Unwrap returns the error the issue was found from, so that it can be found with errors.As
+func (i *Issue) Unwrap() error {
+	return i.Err
+}
+
+// Issues is a list of issues reported as a single error, e.g. the issues about each of the elements listed in a conflict
+type Issues []*Issue
+
+// Error returns the distinct messages of the issues, one per line
+func (issues Issues) Error() string {
+	var messages []string
+	seen := map[string]bool{}
+	for _, issue := range issues {
+		if !seen[issue.Message] {
+			seen[issue.Message] = true
+			messages = append(messages, issue.Message)
+		}
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the issues, so that they can be found with errors.As
+func (issues Issues) Unwrap() []error {
+	errs := make([]error, 0, len(issues))
+	for _, issue := range issues {
+		errs = append(errs, issue)
+	}
+	return errs
+}
+
+// GetIssues returns the individual issues of a parsing or validation error. Errors aggregated in multierrors are
+// inspected individually, and a non-compliant devfile error without listed issues is reported as a single issue with
+// the CodeNonCompliantDevfile code. Nil is returned for errors that are not about the content of a devfile, e.g. network errors.
+func GetIssues(err error) Issues {
+	if err == nil {
+		return nil
+	}
+	var merr *multierror.Error
+	if errors.As(err, &merr) {
+		var issues Issues
+		for _, err := range merr.Errors {
+			issues = append(issues, GetIssues(err)...)
+		}
+		return issues
+	}
+	var nonCompliantErr *NonCompliantDevfile
+	if errors.As(err, &nonCompliantErr) {
+		if len(nonCompliantErr.Issues) > 0 {
+			return nonCompliantErr.Issues
+		}
+		return Issues{{Code: CodeNonCompliantDevfile, Severity: SeverityError, Message: nonCompliantErr.Err}}
+	}
+	var issues Issues
+	if errors.As(err, &issues) {
+		return issues
+	}
+	var issue *Issue
+	if errors.As(err, &issue) {
+		return Issues{issue}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDevfile_FS(t *testing.T) {
+	const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deploy
+`
+	fsys := fstest.MapFS{
+		"project/devfile.yaml": &fstest.MapFile{Data: []byte(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: ../stacks/parent.yaml
+components:
+- name: deploy
+  kubernetes:
+    uri: manifests/deploy.yaml
+`)},
+		"project/manifests/deploy.yaml": &fstest.MapFile{Data: []byte(deployment)},
+		"stacks/parent.yaml": &fstest.MapFile{Data: []byte(`schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: parent-runtime
+  container:
+    image: quay.io/parent-image
+`)},
+		"broken/devfile.yaml": &fstest.MapFile{Data: []byte(`schemaVersion: 2.2.0
+metadata:
+  name: broken
+parent:
+  uri: missing.yaml
+`)},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		wantComponents []string
+		wantErr        string
+	}{
+		{
+			name:           "should parse a devfile with a relative parent and Kubernetes uri from the FS",
+			path:           "project",
+			wantComponents: []string{"parent-runtime", "deploy"},
+		},
+		{
+			name:           "should look up the devfile file name in the FS",
+			path:           "project/devfile.yaml",
+			wantComponents: []string{"parent-runtime", "deploy"},
+		},
+		{
+			name:    "should fail for a parent missing from the FS",
+			path:    "broken",
+			wantErr: "the provided path is not a valid filepath broken/missing.yaml",
+		},
+		{
+			name:    "should fail for a devfile missing from the FS",
+			path:    "missing",
+			wantErr: "missing: file does not exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDevfile(ParserArgs{FS: fsys, Path: tt.path})
+			if tt.wantErr != "" {
+				if assert.Error(t, err, "TestParseDevfile_FS(): expected an error") {
+					assert.Contains(t, err.Error(), tt.wantErr, "TestParseDevfile_FS(): Error message should match")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestParseDevfile_FS() unexpected error: %v", err)
+			}
+
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("TestParseDevfile_FS() unexpected error getting components: %v", err)
+			}
+			var names []string
+			for _, component := range components {
+				names = append(names, component.Name)
+				if component.Kubernetes != nil {
+					assert.Equal(t, deployment, component.Kubernetes.Inlined, "TestParseDevfile_FS(): Kubernetes uri should be read from the FS")
+				}
+			}
+			assert.ElementsMatch(t, tt.wantComponents, names, "TestParseDevfile_FS(): flattened components should match")
+		})
+	}
+}
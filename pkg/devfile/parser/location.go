@@ -0,0 +1,272 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/hashicorp/go-multierror"
+)
+
+// conflictErrorPattern matches the errors of devfile/api about elements conflicting while overriding or merging a parent or
+// plugin, capturing the type of the elements and their names
+var conflictErrorPattern = regexp.MustCompile(`^Some (\w+) (?:are already defined in (?:parent|plugin '[^']*')|do not override any existing element): (.+?)\. `)
+
+// devfileSources records the context of the devfiles imported while flattening a devfile, to locate the elements
+// imported from them
+type devfileSources struct {
+	imports []importedDevfile
+}
+
+// importedDevfile is a devfile imported as a parent or a plugin
+type importedDevfile struct {
+	// importReference is the import reference as written in the importing devfile, as recorded in the source attributes
+	importReference string
+	// resolvedReference is the resolved import reference
+	resolvedReference string
+	// importedFrom is the resolved import reference of the importing devfile
+	importedFrom string
+	ctx          devfileCtx.DevfileCtx
+}
+
+// add records a devfile imported with the source reference importReference, resolved as resolvedReference, from the
+// devfile importedFrom
+func (s *devfileSources) add(importReference string, resolvedReference v1.ImportReference, importedFrom string, ctx devfileCtx.DevfileCtx) {
+	if s == nil {
+		return
+	}
+	s.imports = append(s.imports, importedDevfile{
+		importReference:   importReference,
+		resolvedReference: resolveImportReference(resolvedReference),
+		importedFrom:      importedFrom,
+		ctx:               ctx,
+	})
+}
+
+// fork returns the sources recording the devfiles imported in a branch of the resolution tree, so that branches can be
+// resolved concurrently. The devfiles recorded by the fork are added back with join.
+func (s *devfileSources) fork() *devfileSources {
+	if s == nil {
+		return nil
+	}
+	return &devfileSources{}
+}
+
+// join adds the devfiles recorded by forks, in order
+func (s *devfileSources) join(forks ...*devfileSources) {
+	if s == nil {
+		return
+	}
+	for _, fork := range forks {
+		s.imports = append(s.imports, fork.imports...)
+	}
+}
+
+// importedDevfiles returns the devfile imported with importReference from the main devfile, followed by the devfiles it
+// imports, recursively
+func (s *devfileSources) importedDevfiles(importReference string) []importedDevfile {
+	if s == nil {
+		return nil
+	}
+	var devfiles []importedDevfile
+	for _, imported := range s.imports {
+		if imported.importedFrom == resolveImportReference(v1.ImportReference{}) && imported.importReference == importReference {
+			devfiles = append(devfiles, imported)
+		}
+	}
+	for i := 0; i < len(devfiles); i++ {
+		for _, imported := range s.imports {
+			if imported.importedFrom == devfiles[i].resolvedReference {
+				devfiles = append(devfiles, imported)
+			}
+		}
+	}
+	return devfiles
+}
+
+// resolvedDevfiles returns the devfiles with the resolved import reference
+func (s *devfileSources) resolvedDevfiles(resolvedReference string) []importedDevfile {
+	if s == nil {
+		return nil
+	}
+	var devfiles []importedDevfile
+	for _, imported := range s.imports {
+		if imported.resolvedReference == resolvedReference {
+			devfiles = append(devfiles, imported)
+		}
+	}
+	return devfiles
+}
+
+// LocateValidationIssues returns the individual issues of err, as returned by validate.ValidateDevfileData for the devfile,
+// along with the location of the elements they refer to. Elements imported from a parent or a plugin are located in the
+// devfile they are defined in, or in the overrides of the importing devfile for overridden elements, and the import
+// reference of their issues is the one of the devfile defining them.
+// Issues that do not refer to a named element, e.g. endpoint conflicts, are returned without location.
+func (d DevfileObj) LocateValidationIssues(err error) errPkg.Issues {
+	var issues errPkg.Issues
+	for _, issue := range errPkg.GetIssues(err) {
+		located := *issue
+		located.Locations = append([]errPkg.Location(nil), issue.Locations...)
+		list := strings.SplitN(strings.TrimPrefix(issue.Field, "/"), "/", 2)[0]
+		switch {
+		case list == "events":
+			located.Locations = append(located.Locations, d.Ctx.Locate(issue.Field))
+		case issue.Element != "":
+			if location, importReference, ok := d.locateElement(list, issue.Element); ok {
+				located.Locations = append(located.Locations, location)
+				if importReference != nil {
+					located.ImportReference = *importReference
+				}
+			}
+		}
+		issues = append(issues, &located)
+	}
+	return issues
+}
+
+// locateElement returns the location of the element of the flattened devfile with the given name in list, looking it up in the
+// devfile it was imported from according to its source attributes. For elements that are not overridden, the import
+// reference of the devfile defining the element is also returned, an empty one for the main devfile.
+func (d DevfileObj) locateElement(list string, name string) (errPkg.Location, *string, bool) {
+	var devfiles []importedDevfile
+	listPointer := "/" + list
+	elementAttributes := d.elementAttributes(list, name)
+	mainDevfile := resolveImportReference(v1.ImportReference{})
+	overridden := true
+	switch {
+	case elementAttributes.Exists(parentOverrideAttribute):
+		overriddenFrom := elementAttributes.GetString(parentOverrideAttribute, nil)
+		if overriddenFrom == mainDevfile {
+			devfiles = []importedDevfile{{ctx: d.Ctx}}
+		} else {
+			devfiles = d.sources.resolvedDevfiles(overriddenFrom)
+		}
+		listPointer = "/parent/" + list
+	case elementAttributes.Exists(pluginOverrideAttribute):
+		overriddenFrom := elementAttributes.GetString(pluginOverrideAttribute, nil)
+		if overriddenFrom == mainDevfile {
+			devfiles = []importedDevfile{{ctx: d.Ctx}}
+		} else {
+			devfiles = d.sources.resolvedDevfiles(overriddenFrom)
+		}
+		listPointer = "/components/*/plugin/" + list
+	case elementAttributes.Exists(importSourceAttribute):
+		devfiles = d.sources.importedDevfiles(elementAttributes.GetString(importSourceAttribute, nil))
+		overridden = false
+	default:
+		devfiles = []importedDevfile{{ctx: d.Ctx}}
+		overridden = false
+	}
+
+	for _, devfile := range devfiles {
+		if location, ok := devfile.ctx.LocateElement(listPointer, name); ok {
+			if overridden {
+				return location, nil, true
+			}
+			importReference := devfile.importReference
+			return location, &importReference, true
+		}
+	}
+	return errPkg.Location{}, nil, false
+}
+
+// elementAttributes returns the attributes of the element of the flattened devfile with the given name in list
+func (d DevfileObj) elementAttributes(list string, name string) attributes.Attributes {
+	if d.Data == nil {
+		return nil
+	}
+	switch list {
+	case "components":
+		components, _ := d.Data.GetComponents(common.DevfileOptions{FilterByName: name})
+		if len(components) > 0 {
+			return components[0].Attributes
+		}
+	case "commands":
+		commands, _ := d.Data.GetCommands(common.DevfileOptions{FilterByName: name})
+		if len(commands) > 0 {
+			return commands[0].Attributes
+		}
+	case "projects":
+		projects, _ := d.Data.GetProjects(common.DevfileOptions{FilterByName: name})
+		if len(projects) > 0 {
+			return projects[0].Attributes
+		}
+	case "starterProjects":
+		starterProjects, _ := d.Data.GetStarterProjects(common.DevfileOptions{FilterByName: name})
+		if len(starterProjects) > 0 {
+			return starterProjects[0].Attributes
+		}
+	}
+	return nil
+}
+
+// locateConflicts replaces the errors returned while overriding or merging a parent or plugin into the devfile with
+// context ctx by the issues about each of the conflicting elements, located in the devfile. The elements are looked up
+// in the lists at the JSON pointer prefix for overrides, e.g. /parent, and in the top-level lists for merge conflicts.
+// The returned error has the same message as err.
+func locateConflicts(err error, ctx devfileCtx.DevfileCtx, overridePrefix string) error {
+	var merr *multierror.Error
+	if !errors.As(err, &merr) {
+		return locateConflict(err, ctx, overridePrefix)
+	}
+	located := &multierror.Error{ErrorFormat: merr.ErrorFormat}
+	for _, err := range merr.Errors {
+		located = multierror.Append(located, locateConflict(err, ctx, overridePrefix))
+	}
+	return located
+}
+
+// locateConflict returns the issues about the conflicting elements of a single override or merge error
+func locateConflict(err error, ctx devfileCtx.DevfileCtx, overridePrefix string) error {
+	matches := conflictErrorPattern.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return err
+	}
+	list := "/" + strings.ToLower(matches[1][:1]) + matches[1][1:]
+	code := errPkg.CodeMergeConflict
+	if strings.Contains(matches[0], "do not override") {
+		list = overridePrefix + list
+		code = errPkg.CodeOverrideConflict
+	}
+	var issues errPkg.Issues
+	for _, name := range strings.Split(matches[2], ", ") {
+		issue := &errPkg.Issue{Code: code, Severity: errPkg.SeverityError, Message: err.Error(), Element: name, Err: err}
+		if location, ok := ctx.LocateElement(list, name); ok {
+			issue.Field = location.JSONPointer
+			issue.Locations = []errPkg.Location{location}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// setIssuesImportReference attributes the issues of an error returned while parsing the devfile imported with
+// importReference to that devfile, unless they were already attributed to a devfile it imports
+func setIssuesImportReference(err error, importReference v1.ImportReference) {
+	for _, issue := range errPkg.GetIssues(err) {
+		if issue.ImportReference == "" {
+			issue.ImportReference = resolveImportReference(importReference)
+		}
+	}
+}
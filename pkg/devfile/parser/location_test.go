@@ -0,0 +1,281 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/devfile/library/v2/pkg/devfile/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDevfile_ErrorLocations(t *testing.T) {
+	const grandparentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: grandparent
+components:
+  - name: runtime
+    container:
+      image: quay.io/grandparent-image
+`
+
+	tests := []struct {
+		name                string
+		devfile             string
+		parent              string
+		grandparent         string
+		validate            bool
+		wantErr             string
+		wantCode            errPkg.IssueCode
+		wantElement         string
+		wantImportReference string
+		wantFile            string
+		wantLine            int
+		wantColumn          int
+		wantPointer         string
+	}{
+		{
+			name: "parent override of an unknown element is located in the overrides",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+  components:
+    - name: unknown
+      container:
+        image: quay.io/override
+`,
+			parent: `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+  - name: parent-runtime
+    container:
+      image: quay.io/parent-image
+`,
+			wantErr:     "Some Components do not override any existing element: unknown",
+			wantCode:    errPkg.CodeOverrideConflict,
+			wantElement: "unknown",
+			wantFile:    "devfile.yaml",
+			wantLine:    7,
+			wantColumn:  7,
+			wantPointer: "/parent/components/0",
+		},
+		{
+			name: "merge conflict while flattening a parent is located in the parent file",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+`,
+			parent: `schemaVersion: 2.2.0
+metadata:
+  name: parent
+parent:
+  uri: grandparent.yaml
+components:
+  - name: runtime
+    container:
+      image: quay.io/parent-image
+`,
+			wantErr:             "Some Components are already defined in parent: runtime",
+			wantCode:            errPkg.CodeMergeConflict,
+			wantElement:         "runtime",
+			wantImportReference: "uri: parent.yaml",
+			wantFile:            "parent.yaml",
+			wantLine:            7,
+			wantColumn:          5,
+			wantPointer:         "/components/0",
+		},
+		{
+			name: "schema error of a parent is located in the parent file",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+`,
+			parent: `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+  - name: parent-runtime
+    container:
+      mountSources: true
+`,
+			wantErr:             "image is required",
+			wantCode:            errPkg.CodeSchemaViolation,
+			wantElement:         "parent-runtime",
+			wantImportReference: "uri: parent.yaml",
+			wantFile:            "parent.yaml",
+			wantLine:            6,
+			wantColumn:          5,
+			wantPointer:         "/components/0/container",
+		},
+		{
+			name: "validation error of an element imported from a parent is located in the parent file",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+`,
+			parent: `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+  - name: parent-runtime
+    container:
+      image: quay.io/parent-image
+commands:
+  - id: build
+    exec:
+      component: missing
+      commandLine: make
+`,
+			validate:            true,
+			wantErr:             `the command "build" is invalid`,
+			wantCode:            errPkg.CodeInvalidCommand,
+			wantElement:         "build",
+			wantImportReference: "uri: parent.yaml",
+			wantFile:            "parent.yaml",
+			wantLine:            9,
+			wantColumn:          5,
+			wantPointer:         "/commands/0",
+		},
+		{
+			name: "validation error of an element overriding a parent element is located in the overrides",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+  commands:
+    - id: build
+      exec:
+        component: missing
+`,
+			parent: `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+  - name: parent-runtime
+    container:
+      image: quay.io/parent-image
+commands:
+  - id: build
+    exec:
+      component: parent-runtime
+      commandLine: make
+`,
+			validate:            true,
+			wantErr:             `the command "build" is invalid`,
+			wantCode:            errPkg.CodeInvalidCommand,
+			wantElement:         "build",
+			wantImportReference: "uri: parent.yaml",
+			wantFile:            "devfile.yaml",
+			wantLine:            7,
+			wantColumn:          7,
+			wantPointer:         "/parent/commands/0",
+		},
+		{
+			name: "validation error of an element imported from a grandparent is attributed to the grandparent",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+`,
+			parent: `schemaVersion: 2.2.0
+metadata:
+  name: parent
+parent:
+  uri: grandparent.yaml
+`,
+			grandparent: `schemaVersion: 2.2.0
+metadata:
+  name: grandparent
+components:
+  - name: runtime
+    container:
+      image: quay.io/grandparent-image
+      env:
+        - name: PROJECTS_ROOT
+          value: /projects
+`,
+			validate:            true,
+			wantErr:             "env variable PROJECTS_ROOT is reserved and cannot be customized in component runtime",
+			wantCode:            errPkg.CodeReservedEnv,
+			wantElement:         "runtime",
+			wantImportReference: "uri: grandparent.yaml",
+			wantFile:            "grandparent.yaml",
+			wantLine:            5,
+			wantColumn:          5,
+			wantPointer:         "/components/0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			grandparent := tt.grandparent
+			if grandparent == "" {
+				grandparent = grandparentDevfile
+			}
+			files := map[string]string{"devfile.yaml": tt.devfile, "parent.yaml": tt.parent, "grandparent.yaml": grandparent}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+					t.Fatalf("TestParseDevfile_ErrorLocations() failed to write %s: %v", name, err)
+				}
+			}
+
+			d, err := ParseDevfile(ParserArgs{Path: filepath.Join(dir, "devfile.yaml"), DownloadGitResources: &isFalse})
+			var issues errPkg.Issues
+			if tt.validate {
+				if err != nil {
+					t.Fatalf("TestParseDevfile_ErrorLocations() unexpected error: %v", err)
+				}
+				err = validate.ValidateDevfileData(d.Data)
+				issues = d.LocateValidationIssues(err)
+			} else {
+				issues = errPkg.GetIssues(err)
+				var nonCompliantErr *errPkg.NonCompliantDevfile
+				var conflictIssues errPkg.Issues
+				if !errors.As(err, &nonCompliantErr) {
+					assert.True(t, errors.As(err, &conflictIssues), "TestParseDevfile_ErrorLocations(): conflict should be made of issues")
+				}
+			}
+			if err == nil {
+				t.Fatalf("TestParseDevfile_ErrorLocations() expected an error matching %q", tt.wantErr)
+			}
+			assert.Regexp(t, tt.wantErr, err.Error(), "TestParseDevfile_ErrorLocations(): Error message should match")
+
+			wantLocation := errPkg.Location{File: filepath.Join(dir, tt.wantFile), Line: tt.wantLine, Column: tt.wantColumn, JSONPointer: tt.wantPointer}
+			if assert.Len(t, issues, 1, "TestParseDevfile_ErrorLocations(): expected a single issue") {
+				assert.Regexp(t, tt.wantErr, issues[0].Error())
+				assert.Equal(t, tt.wantCode, issues[0].Code, "TestParseDevfile_ErrorLocations(): issue code should match")
+				assert.Equal(t, tt.wantElement, issues[0].Element, "TestParseDevfile_ErrorLocations(): issue element should match")
+				assert.Equal(t, tt.wantImportReference, issues[0].ImportReference, "TestParseDevfile_ErrorLocations(): issue import reference should match")
+				assert.Equal(t, []errPkg.Location{wantLocation}, issues[0].Locations, "TestParseDevfile_ErrorLocations(): error location should match")
+			}
+		})
+	}
+}
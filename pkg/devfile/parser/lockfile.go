@@ -0,0 +1,296 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/devfile/library/v2/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// LockfileName is the conventional name of a lockfile, stored next to the devfile it was generated for
+const LockfileName = "devfile.lock"
+
+// Lockfile records how every parent and plugin in the resolution tree of a devfile was resolved,
+// so that the same content can be resolved again later on
+type Lockfile struct {
+	// Entries are the resolved import references, in resolution order
+	Entries []LockEntry `json:"entries"`
+}
+
+// LockEntry records the resolution of a single import reference
+type LockEntry struct {
+	// ImportReference is the import reference as defined in the importing devfile
+	ImportReference v1.ImportReference `json:"importReference"`
+	// ImportedFrom is the resolved import reference of the importing devfile, or "main devfile" for the devfile being parsed
+	ImportedFrom string `json:"importedFrom"`
+	// ResolvedURL is the absolute URL or path for uri import references, and the registry URL used for id import references
+	ResolvedURL string `json:"resolvedURL,omitempty"`
+	// RegistryVersion is the version of the devfile pulled from a registry for id import references
+	RegistryVersion string `json:"registryVersion,omitempty"`
+	// GitCommit is the commit the revision of a git provider uri pointed to
+	GitCommit string `json:"gitCommit,omitempty"`
+	// Digest is the sha256 digest of the fetched devfile content converted to JSON, in the sha256:<hex> format.
+	// The conversion makes it independent of the YAML formatting of the devfile.
+	Digest string `json:"digest"`
+}
+
+// ReadLockfile reads the lockfile at path
+func ReadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read lockfile %s", path)
+	}
+	var lockfile Lockfile
+	err = yaml.Unmarshal(data, &lockfile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode lockfile %s", path)
+	}
+	return &lockfile, nil
+}
+
+// WriteToFile writes the lockfile to path in YAML format
+func (l *Lockfile) WriteToFile(path string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal lockfile into yaml")
+	}
+	err = os.WriteFile(filepath.Clean(path), data, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write lockfile %s", path)
+	}
+	return nil
+}
+
+// lookup returns the entry recorded for importReference in the devfile resolved as importedFrom, or nil if there is none
+func (l *Lockfile) lookup(importReference v1.ImportReference, importedFrom string) *LockEntry {
+	for i := range l.Entries {
+		entry := &l.Entries[i]
+		if entry.ImportedFrom == importedFrom && reflect.DeepEqual(entry.ImportReference, importReference) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// lockState records the resolution of import references while parsing, and resolves them strictly from a lockfile if one is set
+type lockState struct {
+	// locked is the lockfile import references are strictly resolved from, nil if not resolving strictly
+	locked *Lockfile
+	// lockfile is the lockfile being generated
+	lockfile Lockfile
+}
+
+// newLockState returns the state to use for generating a lockfile or resolving strictly from locked, nil if doing neither
+func newLockState(generate bool, locked *Lockfile) *lockState {
+	if !generate && locked == nil {
+		return nil
+	}
+	return &lockState{locked: locked}
+}
+
+// fork returns a state recording the resolutions made in a branch of the resolution tree, so that branches can be
+// resolved concurrently. The entries of the fork are added back with join.
+func (l *lockState) fork() *lockState {
+	if l == nil {
+		return nil
+	}
+	return &lockState{locked: l.locked}
+}
+
+// join adds the entries recorded by forks, in order
+func (l *lockState) join(forks ...*lockState) {
+	if l == nil {
+		return
+	}
+	for _, fork := range forks {
+		l.lockfile.Entries = append(l.lockfile.Entries, fork.lockfile.Entries...)
+	}
+}
+
+// getLockfile returns the lockfile generated while parsing
+func (l *lockState) getLockfile() *Lockfile {
+	if l == nil {
+		return nil
+	}
+	lockfile := l.lockfile
+	return &lockfile
+}
+
+// pin returns the import reference to resolve instead of importReference, and the entry recorded for it when resolving strictly.
+// Uri import references are pinned to their resolved URL and git commit, and id import references to the registry URL and
+// version that were used.
+func (l *lockState) pin(importReference v1.ImportReference, importedFrom string) (v1.ImportReference, *LockEntry, error) {
+	if l == nil || l.locked == nil {
+		return importReference, nil, nil
+	}
+	entry := l.locked.lookup(importReference, importedFrom)
+	if entry == nil {
+		return importReference, nil, &errPkg.LockfileMismatch{Reference: resolveImportReference(importReference), ImportedFrom: importedFrom}
+	}
+
+	pinned := importReference
+	switch {
+	case importReference.Uri != "":
+		// only URLs are pinned, relative paths on disk are resolved against the importing devfile again
+		if strings.HasPrefix(entry.ResolvedURL, "http://") || strings.HasPrefix(entry.ResolvedURL, "https://") {
+			pinned.Uri = entry.ResolvedURL
+			if entry.GitCommit != "" {
+				uri, err := pinGitRevision(entry.ResolvedURL, entry.GitCommit)
+				if err != nil {
+					return importReference, nil, err
+				}
+				pinned.Uri = uri
+			}
+		}
+	case importReference.Id != "":
+		if entry.ResolvedURL != "" {
+			pinned.RegistryUrl = entry.ResolvedURL
+		}
+		if entry.RegistryVersion != "" {
+			pinned.Version = entry.RegistryVersion
+		}
+	}
+	return pinned, entry, nil
+}
+
+// unpin reverts the changes made by pin to a resolved import reference, so that the resolution tree, and thus the
+// importedFrom of the entries, is the same as when the lockfile was generated
+func (l *lockState) unpin(resolved v1.ImportReference, importReference v1.ImportReference, entry *LockEntry) v1.ImportReference {
+	if entry == nil {
+		return resolved
+	}
+	if importReference.Uri != "" && entry.ResolvedURL != "" {
+		resolved.Uri = entry.ResolvedURL
+	}
+	// the version a semver constraint was resolved to is kept, as it is when resolving without a lockfile
+	if !isVersionConstraint(importReference.Version) {
+		resolved.Version = importReference.Version
+	}
+	return resolved
+}
+
+// record adds the resolution of importReference to the lockfile being generated. The digest is computed from the fetched
+// content of d, or from its data for devfiles that were not fetched as content, e.g. DevWorkspaceTemplates.
+// When resolving strictly, the digest is checked against the one of entry.
+func (l *lockState) record(tool resolverTools, importReference v1.ImportReference, importedFrom string, resolved v1.ImportReference, d DevfileObj, entry *LockEntry, token string) error {
+	if l == nil {
+		// the commits of git provider uris are vendored, so that a lockfile can be generated offline
+		if tool.vault.vendoring() && importReference.Uri != "" && util.IsGitProviderRepo(resolved.Uri) {
+			_, err := resolveGitCommit(tool, resolved.Uri, token)
+			return err
+		}
+		return nil
+	}
+	content := d.Ctx.GetDevfileContent()
+	if content == nil && d.Data != nil {
+		var err error
+		content, err = json.Marshal(d.Data)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal devfile data")
+		}
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+	if entry != nil {
+		if entry.Digest != digest {
+			return &errPkg.LockfileMismatch{Reference: resolveImportReference(importReference), ImportedFrom: importedFrom, Expected: entry.Digest, Actual: digest}
+		}
+		l.lockfile.Entries = append(l.lockfile.Entries, *entry)
+		return nil
+	}
+
+	newEntry := LockEntry{
+		ImportReference: importReference,
+		ImportedFrom:    importedFrom,
+		Digest:          digest,
+	}
+	switch {
+	case importReference.Uri != "":
+		newEntry.ResolvedURL = resolved.Uri
+		if util.IsGitProviderRepo(resolved.Uri) {
+			var err error
+			newEntry.GitCommit, err = resolveGitCommit(tool, resolved.Uri, token)
+			if err != nil {
+				return err
+			}
+		}
+	case importReference.Id != "":
+		newEntry.ResolvedURL = resolved.RegistryUrl
+		newEntry.RegistryVersion = getDevfileMetadataVersion(content)
+		if isVersionConstraint(importReference.Version) {
+			newEntry.RegistryVersion = resolved.Version
+		}
+	}
+	l.lockfile.Entries = append(l.lockfile.Entries, newEntry)
+	return nil
+}
+
+// resolveGitCommit returns the commit the revision of the git provider uri resolves to. When parsing offline, the commit
+// is served from the vault, and is stored in it when vendoring.
+func resolveGitCommit(tool resolverTools, uri string, token string) (string, error) {
+	commit, err := tool.vault.content("commit "+uri, "commit of "+uri, func() ([]byte, error) {
+		credentials, err := util.CredentialsForURL(tool.credentials, uri)
+		if err != nil {
+			return nil, err
+		}
+		if credentials != nil {
+			token = credentials.GitToken()
+		}
+		gitUrl, err := util.NewGitURL(uri, token)
+		if err != nil {
+			return nil, err
+		}
+		commit, err := gitUrl.ResolveCommitWithContext(tool.getContext())
+		return []byte(commit), err
+	})
+	return string(commit), err
+}
+
+// getDevfileMetadataVersion returns the metadata version of the devfile content, or an empty string if it cannot be read
+func getDevfileMetadataVersion(content []byte) string {
+	var devfile struct {
+		Metadata struct {
+			Version string `json:"version"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal(content, &devfile); err != nil {
+		return ""
+	}
+	return devfile.Metadata.Version
+}
+
+// pinGitRevision replaces the revision of a git provider uri by commit
+func pinGitRevision(uri string, commit string) (string, error) {
+	gitUrl, err := util.NewGitURL(uri, "")
+	if err != nil {
+		return "", err
+	}
+	suffix := fmt.Sprintf("/%s/%s", gitUrl.Revision, gitUrl.Path)
+	if gitUrl.Revision == "" || !strings.HasSuffix(uri, suffix) {
+		return "", fmt.Errorf("failed to pin %s to commit %s, the url does not end with its revision and path", uri, commit)
+	}
+	return strings.TrimSuffix(uri, suffix) + fmt.Sprintf("/%s/%s", commit, gitUrl.Path), nil
+}
@@ -0,0 +1,372 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/devfile/library/v2/pkg/testingutil"
+	"github.com/devfile/library/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	kubev1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// devfileDigest returns the lockfile digest of a YAML devfile
+func devfileDigest(t *testing.T, devfile string) string {
+	content, err := devfileCtx.YAMLToJSON([]byte(devfile))
+	if err != nil {
+		t.Fatalf("failed to convert devfile to JSON: %v", err)
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+}
+
+func TestParseDevfile_Lockfile(t *testing.T) {
+	parentDevfile := `schemaVersion: 2.2.0
+metadata:
+  name: parent
+parent:
+  uri: grandparent.yaml
+`
+	const grandparentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: grandparent
+components:
+- name: runtime
+  container:
+    image: quay.io/grandparent-image
+`
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data string
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/grandparent.yaml"):
+			data = grandparentDevfile
+		case r.URL.Path == "/parent.yaml":
+			data = parentDevfile
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(data))
+		if err != nil {
+			t.Errorf("TestParseDevfile_Lockfile() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	mainDevfile := []byte(fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: %s/parent.yaml
+`, testServer.URL))
+
+	d, err := ParseDevfile(ParserArgs{Data: mainDevfile, GenerateLockfile: true, DownloadGitResources: &isFalse})
+	if err != nil {
+		t.Fatalf("TestParseDevfile_Lockfile() unexpected error generating the lockfile: %v", err)
+	}
+	wantLockfile := &Lockfile{
+		Entries: []LockEntry{
+			{
+				ImportReference: v1.ImportReference{ImportReferenceUnion: v1.ImportReferenceUnion{Uri: testServer.URL + "/parent.yaml"}},
+				ImportedFrom:    "main devfile",
+				ResolvedURL:     testServer.URL + "/parent.yaml",
+				Digest:          devfileDigest(t, parentDevfile),
+			},
+			{
+				ImportReference: v1.ImportReference{ImportReferenceUnion: v1.ImportReferenceUnion{Uri: "grandparent.yaml"}},
+				ImportedFrom:    "uri: " + testServer.URL + "/parent.yaml",
+				ResolvedURL:     testServer.URL + "/parent.yaml/grandparent.yaml",
+				Digest:          devfileDigest(t, grandparentDevfile),
+			},
+		},
+	}
+	assert.Equal(t, wantLockfile, d.Lockfile, "TestParseDevfile_Lockfile(): generated lockfile should match")
+
+	lockfilePath := filepath.Join(t.TempDir(), LockfileName)
+	if err = d.Lockfile.WriteToFile(lockfilePath); err != nil {
+		t.Fatalf("TestParseDevfile_Lockfile() unexpected error writing the lockfile: %v", err)
+	}
+	lockfile, err := ReadLockfile(lockfilePath)
+	if err != nil {
+		t.Fatalf("TestParseDevfile_Lockfile() unexpected error reading the lockfile: %v", err)
+	}
+	assert.Equal(t, wantLockfile, lockfile, "TestParseDevfile_Lockfile(): lockfile read back should match")
+
+	_, err = ParseDevfile(ParserArgs{Data: mainDevfile, Lockfile: lockfile, DownloadGitResources: &isFalse})
+	assert.NoError(t, err, "TestParseDevfile_Lockfile(): strict mode should succeed with unchanged content")
+
+	otherDevfile := []byte(fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: %s/other.yaml
+`, testServer.URL))
+	_, err = ParseDevfile(ParserArgs{Data: otherDevfile, Lockfile: lockfile, DownloadGitResources: &isFalse})
+	var mismatchErr *errPkg.LockfileMismatch
+	if assert.True(t, errors.As(err, &mismatchErr), "TestParseDevfile_Lockfile(): expected a LockfileMismatch error, got: %v", err) {
+		assert.Equal(t, "", mismatchErr.Expected)
+		assert.Regexp(t, "uri: .*/other.yaml imported from main devfile is not recorded in the lockfile", err.Error())
+	}
+
+	parentDevfile = strings.Replace(parentDevfile, "name: parent", "name: updated-parent", 1)
+	_, err = ParseDevfile(ParserArgs{Data: mainDevfile, Lockfile: lockfile, DownloadGitResources: &isFalse})
+	if assert.True(t, errors.As(err, &mismatchErr), "TestParseDevfile_Lockfile(): expected a LockfileMismatch error, got: %v", err) {
+		assert.Equal(t, wantLockfile.Entries[0].Digest, mismatchErr.Expected)
+		assert.Equal(t, devfileDigest(t, parentDevfile), mismatchErr.Actual)
+	}
+}
+
+func TestLockState_pin(t *testing.T) {
+	registryEntry := LockEntry{
+		ImportReference: v1.ImportReference{ImportReferenceUnion: v1.ImportReferenceUnion{Id: "nodejs"}},
+		ImportedFrom:    "main devfile",
+		ResolvedURL:     "https://registry.devfile.io",
+		RegistryVersion: "2.1.1",
+	}
+	gitEntry := LockEntry{
+		ImportReference: v1.ImportReference{ImportReferenceUnion: v1.ImportReferenceUnion{Uri: "https://github.com/devfile/registry/blob/main/stacks/nodejs/devfile.yaml"}},
+		ImportedFrom:    "main devfile",
+		ResolvedURL:     "https://github.com/devfile/registry/blob/main/stacks/nodejs/devfile.yaml",
+		GitCommit:       "0123456789abcdef0123456789abcdef01234567",
+	}
+	localEntry := LockEntry{
+		ImportReference: v1.ImportReference{ImportReferenceUnion: v1.ImportReferenceUnion{Uri: "../parent.yaml"}},
+		ImportedFrom:    "main devfile",
+		ResolvedURL:     "/projects/parent.yaml",
+	}
+	lock := newLockState(false, &Lockfile{Entries: []LockEntry{registryEntry, gitEntry, localEntry}})
+
+	tests := []struct {
+		name            string
+		importReference v1.ImportReference
+		importedFrom    string
+		wantReference   v1.ImportReference
+		wantUnpinned    v1.ImportReference
+		wantErr         string
+	}{
+		{
+			name:            "should pin a registry id to the registry URL and version",
+			importReference: registryEntry.ImportReference,
+			importedFrom:    "main devfile",
+			wantReference: v1.ImportReference{
+				ImportReferenceUnion: v1.ImportReferenceUnion{Id: "nodejs"},
+				RegistryUrl:          "https://registry.devfile.io",
+				Version:              "2.1.1",
+			},
+			wantUnpinned: v1.ImportReference{
+				ImportReferenceUnion: v1.ImportReferenceUnion{Id: "nodejs"},
+				RegistryUrl:          "https://registry.devfile.io",
+			},
+		},
+		{
+			name:            "should pin a git provider uri to the commit",
+			importReference: gitEntry.ImportReference,
+			importedFrom:    "main devfile",
+			wantReference: v1.ImportReference{ImportReferenceUnion: v1.ImportReferenceUnion{
+				Uri: "https://github.com/devfile/registry/blob/0123456789abcdef0123456789abcdef01234567/stacks/nodejs/devfile.yaml",
+			}},
+			wantUnpinned: gitEntry.ImportReference,
+		},
+		{
+			name:            "should not pin a relative path on disk",
+			importReference: localEntry.ImportReference,
+			importedFrom:    "main devfile",
+			wantReference:   localEntry.ImportReference,
+			wantUnpinned:    v1.ImportReference{ImportReferenceUnion: v1.ImportReferenceUnion{Uri: "/projects/parent.yaml"}},
+		},
+		{
+			name:            "should fail if the import reference is recorded for another devfile",
+			importReference: registryEntry.ImportReference,
+			importedFrom:    "uri: https://example.com/devfile.yaml",
+			wantErr:         "id: nodejs, registryURL:  imported from uri: https://example.com/devfile.yaml is not recorded in the lockfile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinned, entry, err := lock.pin(tt.importReference, tt.importedFrom)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Equal(t, tt.wantErr, err.Error(), "TestLockState_pin(): Error message should match")
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantReference, pinned)
+			assert.Equal(t, tt.wantUnpinned, lock.unpin(pinned, tt.importReference, entry), "TestLockState_pin(): unpinned import reference should match")
+		})
+	}
+}
+
+func TestPinGitRevision(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		name    string
+		uri     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "GitHub url",
+			uri:  "https://github.com/devfile/library/blob/main/devfile.yaml",
+			want: "https://github.com/devfile/library/blob/" + commit + "/devfile.yaml",
+		},
+		{
+			name: "raw GitHub url",
+			uri:  "https://raw.githubusercontent.com/devfile/library/main/stacks/devfile.yaml",
+			want: "https://raw.githubusercontent.com/devfile/library/" + commit + "/stacks/devfile.yaml",
+		},
+		{
+			name: "GitLab url",
+			uri:  "https://gitlab.com/devfile/library/-/blob/v1.0.0/devfile.yaml",
+			want: "https://gitlab.com/devfile/library/-/blob/" + commit + "/devfile.yaml",
+		},
+		{
+			name: "Bitbucket url",
+			uri:  "https://bitbucket.org/devfile/library/src/main/devfile.yaml",
+			want: "https://bitbucket.org/devfile/library/src/" + commit + "/devfile.yaml",
+		},
+		{
+			name:    "url without revision",
+			uri:     "https://github.com/devfile/library",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pinGitRevision(tt.uri, commit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TestPinGitRevision() unexpected error: %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseDevfile_LockfileKubernetesImport(t *testing.T) {
+	mainDevfile := []byte(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  kubernetes:
+    name: parent
+`)
+	// the parser modifies the template it gets, so each parse gets its own client
+	newK8sClient := func() *testingutil.FakeK8sClient {
+		return &testingutil.FakeK8sClient{
+			DevWorkspaceResources: map[string]v1.DevWorkspaceTemplate{
+				"parent": {
+					TypeMeta: kubev1.TypeMeta{Kind: "DevWorkspaceTemplate", APIVersion: "workspace.devfile.io/v1alpha2"},
+					Spec: v1.DevWorkspaceTemplateSpec{
+						DevWorkspaceTemplateSpecContent: v1.DevWorkspaceTemplateSpecContent{
+							Components: []v1.Component{{
+								Name: "runtime",
+								ComponentUnion: v1.ComponentUnion{
+									Container: &v1.ContainerComponent{Container: v1.Container{Image: "quay.io/parent-image"}},
+								},
+							}},
+						},
+					},
+				},
+			},
+			ExpectedNamespace: "default",
+		}
+	}
+	args := ParserArgs{Data: mainDevfile, Context: context.Background(), K8sClient: newK8sClient(), DefaultNamespace: "default", GenerateLockfile: true}
+	d, err := ParseDevfile(args)
+	if err != nil {
+		t.Fatalf("TestParseDevfile_LockfileKubernetesImport() unexpected error generating the lockfile: %v", err)
+	}
+	if assert.Len(t, d.Lockfile.Entries, 1) {
+		assert.Equal(t, v1.ImportReference{ImportReferenceUnion: v1.ImportReferenceUnion{Kubernetes: &v1.KubernetesCustomResourceImportReference{Name: "parent"}}},
+			d.Lockfile.Entries[0].ImportReference, "TestParseDevfile_LockfileKubernetesImport(): the reference should be recorded as authored")
+	}
+
+	args.GenerateLockfile = false
+	args.Lockfile = d.Lockfile
+	args.K8sClient = newK8sClient()
+	_, err = ParseDevfile(args)
+	assert.NoError(t, err, "TestParseDevfile_LockfileKubernetesImport(): strict mode should match the reference without a namespace")
+}
+
+// inMemoryDevfileUtils serves the content of the URLs it knows and downloads no git resources
+type inMemoryDevfileUtils map[string]string
+
+func (c inMemoryDevfileUtils) DownloadInMemory(params util.HTTPRequestParams) ([]byte, error) {
+	content, ok := c[params.URL]
+	if !ok {
+		return nil, fmt.Errorf("failed to download %s", params.URL)
+	}
+	return []byte(content), nil
+}
+
+func (c inMemoryDevfileUtils) DownloadGitRepoResources(url string, destDir string, token string) error {
+	return nil
+}
+
+func TestParseDevfile_LockfileOffline(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: runtime
+  container:
+    image: quay.io/parent-image
+`
+	parentURL := "https://raw.githubusercontent.com/devfile/registry/" + commit + "/parent.yaml"
+	client := inMemoryDevfileUtils{parentURL: parentDevfile}
+	mainDevfile := []byte(fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: %s
+`, parentURL))
+
+	vaultDir := filepath.Join(t.TempDir(), "vault")
+	_, err := VendorDevfile(ParserArgs{Data: mainDevfile, DevfileUtilsClient: client, DownloadGitResources: &isFalse}, vaultDir)
+	if err != nil {
+		t.Fatalf("TestParseDevfile_LockfileOffline() unexpected error vendoring the devfile: %v", err)
+	}
+
+	offlineArgs := ParserArgs{Data: mainDevfile, DevfileUtilsClient: inMemoryDevfileUtils{}, GenerateLockfile: true, OfflineVault: vaultDir, DownloadGitResources: &isFalse}
+	d, err := ParseDevfile(offlineArgs)
+	if assert.NoError(t, err, "TestParseDevfile_LockfileOffline(): the lockfile should be generated offline") &&
+		assert.Len(t, d.Lockfile.Entries, 1) {
+		assert.Equal(t, commit, d.Lockfile.Entries[0].GitCommit, "TestParseDevfile_LockfileOffline(): the commit should be served from the vault")
+	}
+
+	v := &vault{dir: vaultDir}
+	if err = os.RemoveAll(v.entryDir("commit " + parentURL)); err != nil {
+		t.Fatalf("TestParseDevfile_LockfileOffline() unexpected error removing the vendored commit: %v", err)
+	}
+	_, err = ParseDevfile(offlineArgs)
+	var cacheMissErr *errPkg.OfflineCacheMiss
+	if assert.True(t, errors.As(err, &cacheMissErr), "TestParseDevfile_LockfileOffline(): expected an OfflineCacheMiss error, got: %v", err) {
+		assert.Equal(t, "commit of "+parentURL, cacheMissErr.Reference)
+	}
+}
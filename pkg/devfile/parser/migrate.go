@@ -0,0 +1,515 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devfile/api/v2/pkg/validation/variables"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// MigrationReport reports the changes made to the data of a devfile converted to another schema version
+type MigrationReport struct {
+	// From is the schema version of the devfile before the conversion
+	From string
+	// To is the schema version the devfile was converted to
+	To string
+	// Changes are the fields and elements dropped, with the CodeFieldDropped code, and the fields transformed, with the
+	// CodeFieldTransformed code, to convert the devfile. Their fields are JSON pointers in the data before the conversion.
+	Changes errPkg.Issues
+}
+
+var (
+	// schemaVersion210 is the first schema version supporting variables and top-level attributes
+	schemaVersion210 = version.Must(version.NewVersion(string(data.APISchemaVersion210)))
+	// schemaVersion222 is the first schema version supporting dependent projects
+	schemaVersion222 = version.Must(version.NewVersion(string(data.APISchemaVersion222)))
+)
+
+// migrationLists maps the top-level lists of named elements to the kind of their elements
+var migrationLists = map[string]string{
+	"components":        "component",
+	"commands":          "command",
+	"projects":          "project",
+	"starterProjects":   "starter project",
+	"dependentProjects": "dependent project",
+}
+
+// Upgrade converts the data of the devfile to the given schema version, which must not be older than the schema version
+// of the devfile, and validates it against the JSON schema of that version. The fields the target version does not
+// support are dropped or transformed, and reported in the returned MigrationReport. The data is left as it is on error.
+func (d *DevfileObj) Upgrade(schemaVersion string) (*MigrationReport, error) {
+	return d.migrate(schemaVersion, true)
+}
+
+// Downgrade converts the data of the devfile to the given schema version, which must not be newer than the schema version
+// of the devfile, and validates it against the JSON schema of that version. The fields the target version does not
+// support are dropped or transformed, e.g. the variables are replaced with their values for 2.0.0, and reported in the
+// returned MigrationReport. The data is left as it is on error.
+func (d *DevfileObj) Downgrade(schemaVersion string) (*MigrationReport, error) {
+	return d.migrate(schemaVersion, false)
+}
+
+// migrate converts the data of the devfile to the target schema version, an upgrade or a downgrade
+func (d *DevfileObj) migrate(target string, upgrade bool) (*MigrationReport, error) {
+	source := d.Data.GetSchemaVersion()
+	sourceVersion, err := parseSchemaVersion(source)
+	if err != nil {
+		return nil, err
+	}
+	targetVersion, err := parseSchemaVersion(target)
+	if err != nil {
+		return nil, err
+	}
+	if upgrade && targetVersion.LessThan(sourceVersion) {
+		return nil, fmt.Errorf("cannot upgrade the devfile from schema version %s to the older version %s", source, target)
+	}
+	if !upgrade && targetVersion.GreaterThan(sourceVersion) {
+		return nil, fmt.Errorf("cannot downgrade the devfile from schema version %s to the newer version %s", source, target)
+	}
+	schema, err := data.GetCompiledDevfileJSONSchema(target)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &migration{target: target, sources: map[string][]string{}}
+	if err = m.load(d.Data, targetVersion); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert the devfile to schema version %s", target)
+	}
+	m.transform(targetVersion)
+	if err = m.prune(schema); err != nil {
+		return nil, err
+	}
+	m.dropDanglingCommands()
+
+	migrated, err := data.NewDevfileData(target)
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(m.content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert the devfile to schema version %s", target)
+	}
+	if err = json.Unmarshal(content, migrated); err != nil {
+		return nil, errors.Wrapf(err, "failed to convert the devfile to schema version %s", target)
+	}
+
+	report := &MigrationReport{From: source, To: target}
+	if len(m.changes) > 0 {
+		report.Changes = d.LocateValidationIssues(m.changes)
+	}
+	d.Data = migrated
+	return report, nil
+}
+
+// parseSchemaVersion returns the version of a supported devfile schema version
+func parseSchemaVersion(schemaVersion string) (*version.Version, error) {
+	if schemaVersion == string(data.APIVersionAlpha2) || !data.IsApiVersionSupported(schemaVersion) {
+		return nil, fmt.Errorf("schema version %q is not supported", schemaVersion)
+	}
+	return version.NewVersion(schemaVersion)
+}
+
+// migration is the content of a devfile being converted to another schema version
+type migration struct {
+	// target is the schema version the devfile is converted to
+	target string
+	// content is the JSON content of the devfile data
+	content map[string]interface{}
+	// sources are the JSON pointers of the elements of the top-level lists in the data before the conversion, by list
+	sources map[string][]string
+	// changes are the changes made to the content
+	changes errPkg.Issues
+}
+
+// load sets the content to the data of the devfile, with its variables replaced with their values if the target version
+// does not support variables
+func (m *migration) load(devfileData data.DevfileData, target *version.Version) error {
+	content, err := json.Marshal(devfileData)
+	if err != nil {
+		return err
+	}
+	if target.LessThan(schemaVersion210) && len(devfileData.GetDevfileWorkspaceSpec().Variables) > 0 {
+		// the variables are replaced on a copy of the data, as the devfile API does
+		replaced, err := data.NewDevfileData(devfileData.GetSchemaVersion())
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(content, replaced); err != nil {
+			return err
+		}
+		spec := replaced.GetDevfileWorkspaceSpec()
+		variables.ValidateAndReplaceGlobalVariable(spec)
+		spec.Variables = nil
+		if content, err = json.Marshal(replaced); err != nil {
+			return err
+		}
+		m.change(errPkg.CodeFieldTransformed, []string{"variables"},
+			fmt.Sprintf("the variables are replaced with their values, as schema version %s does not support variables", m.target))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err = decoder.Decode(&m.content); err != nil {
+		return err
+	}
+	m.content["schemaVersion"] = m.target
+	for list := range migrationLists {
+		items, _ := m.content[list].([]interface{})
+		for i := range items {
+			m.sources[list] = append(m.sources[list], fmt.Sprintf("/%s/%d", list, i))
+		}
+	}
+	return nil
+}
+
+// transform transforms the fields that the target version supports in another form
+func (m *migration) transform(target *version.Version) {
+	if target.LessThan(schemaVersion210) {
+		if attributes, ok := m.content["attributes"].(map[string]interface{}); ok && len(attributes) > 0 {
+			metadata, _ := m.content["metadata"].(map[string]interface{})
+			if metadata == nil {
+				metadata = map[string]interface{}{}
+				m.content["metadata"] = metadata
+			}
+			metadataAttributes, _ := metadata["attributes"].(map[string]interface{})
+			if metadataAttributes == nil {
+				metadataAttributes = map[string]interface{}{}
+				metadata["attributes"] = metadataAttributes
+			}
+			for key, value := range attributes {
+				metadataAttributes[key] = value
+			}
+			m.change(errPkg.CodeFieldTransformed, []string{"attributes"},
+				fmt.Sprintf("the attributes are moved to the metadata attributes, as schema version %s does not support top-level attributes", m.target))
+			delete(m.content, "attributes")
+		}
+	}
+
+	if target.LessThan(schemaVersion222) {
+		dependentProjects, _ := m.content["dependentProjects"].([]interface{})
+		projects, _ := m.content["projects"].([]interface{})
+		names := map[string]bool{}
+		for i := range projects {
+			names[m.element([]string{"projects", strconv.Itoa(i)})] = true
+		}
+		var kept []interface{}
+		var keptSources []string
+		for i, project := range dependentProjects {
+			tokens := []string{"dependentProjects", strconv.Itoa(i)}
+			name := m.element(tokens)
+			// dependent projects named after a project are dropped
+			if names[name] {
+				kept = append(kept, project)
+				keptSources = append(keptSources, m.sources["dependentProjects"][i])
+				continue
+			}
+			m.change(errPkg.CodeFieldTransformed, tokens,
+				fmt.Sprintf("dependent project %s is moved to the projects, as schema version %s does not support dependent projects", name, m.target))
+			names[name] = true
+			projects = append(projects, project)
+			m.sources["projects"] = append(m.sources["projects"], m.sources["dependentProjects"][i])
+		}
+		if len(kept) < len(dependentProjects) {
+			m.content["projects"] = projects
+			m.content["dependentProjects"] = kept
+			m.sources["dependentProjects"] = keptSources
+			if len(kept) == 0 {
+				delete(m.content, "dependentProjects")
+			}
+		}
+	}
+}
+
+// prune drops the nodes of the content that are not valid against the JSON schema of the target version, until it is
+// valid. It returns an error if the content cannot be made valid.
+func (m *migration) prune(schema *gojsonschema.Schema) error {
+	for {
+		result, err := schema.Validate(gojsonschema.NewGoLoader(m.content))
+		if err != nil {
+			return errors.Wrapf(err, "failed to validate the devfile against schema version %s", m.target)
+		}
+		if result.Valid() {
+			return nil
+		}
+		targets, properties := pruneTargets(result.Errors())
+		dropped := false
+		for _, tokens := range targets {
+			if m.drop(tokens, properties) {
+				dropped = true
+			}
+		}
+		if !dropped {
+			return m.schemaError(result.Errors())
+		}
+	}
+}
+
+// pruneTargets returns the JSON pointer tokens of the nodes to drop to fix the schema errors. The properties that are not
+// allowed are returned first if there are any, as dropping them can fix the other errors. The nodes are sorted so that
+// dropping one does not change the pointers of the following ones, and nodes within another one are left out.
+func pruneTargets(resultErrors []gojsonschema.ResultError) ([][]string, bool) {
+	var properties, others [][]string
+	for _, resultError := range resultErrors {
+		tokens := devfileCtx.SchemaErrorTokens(resultError.Context())
+		if resultError.Type() == "additional_property_not_allowed" {
+			if property, ok := resultError.Details()["property"].(string); ok {
+				properties = append(properties, append(tokens, property))
+			}
+			continue
+		}
+		others = append(others, tokens)
+	}
+	targets := others
+	if len(properties) > 0 {
+		targets = properties
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return comparePointerTokens(targets[i], targets[j]) < 0
+	})
+	var pruned [][]string
+	for _, tokens := range targets {
+		if len(pruned) > 0 && hasPointerPrefix(tokens, pruned[len(pruned)-1]) {
+			continue
+		}
+		pruned = append(pruned, tokens)
+	}
+	for i, j := 0, len(pruned)-1; i < j; i, j = i+1, j-1 {
+		pruned[i], pruned[j] = pruned[j], pruned[i]
+	}
+	return pruned, len(properties) > 0
+}
+
+// drop drops the node of the content at tokens and reports it. The items of lists other than the top-level lists of named
+// elements are dropped along with their list. Top-level properties are only dropped if topLevel is true.
+// It returns false if the node cannot be dropped.
+func (m *migration) drop(tokens []string, topLevel bool) bool {
+	if len(tokens) == 0 || (len(tokens) == 1 && !topLevel) {
+		return false
+	}
+	key := tokens[len(tokens)-1]
+	switch parent := m.node(tokens[:len(tokens)-1]).(type) {
+	case map[string]interface{}:
+		if _, ok := parent[key]; !ok {
+			return false
+		}
+		m.forgetDropped(tokens)
+		m.change(errPkg.CodeFieldDropped, tokens,
+			fmt.Sprintf("field %s is not supported by schema version %s", m.sourcePointer(tokens), m.target))
+		delete(parent, key)
+		return true
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(parent) {
+			return false
+		}
+		kind, ok := migrationLists[tokens[0]]
+		if !ok || len(tokens) != 2 {
+			return m.drop(tokens[:len(tokens)-1], topLevel)
+		}
+		m.forgetDropped(tokens)
+		m.change(errPkg.CodeFieldDropped, tokens,
+			fmt.Sprintf("%s %s is not supported by schema version %s", kind, m.element(tokens), m.target))
+		m.removeElement(tokens[0], i)
+		return true
+	}
+	return false
+}
+
+// forgetDropped forgets the changes about the nodes dropped so far within the node at tokens, which is being dropped,
+// so that the node is reported as a whole
+func (m *migration) forgetDropped(tokens []string) {
+	source := m.sourcePointer(tokens)
+	var changes errPkg.Issues
+	for _, change := range m.changes {
+		if change.Code != errPkg.CodeFieldDropped || !strings.HasPrefix(change.Field, source+"/") {
+			changes = append(changes, change)
+		}
+	}
+	m.changes = changes
+}
+
+// removeElement removes the element at index i of a top-level list of the content
+func (m *migration) removeElement(list string, i int) {
+	items, _ := m.content[list].([]interface{})
+	m.content[list] = append(items[:i:i], items[i+1:]...)
+	m.sources[list] = append(m.sources[list][:i:i], m.sources[list][i+1:]...)
+}
+
+// dropDanglingCommands drops the exec and apply commands referencing the components dropped by the conversion,
+// as the commands cannot be run without their component
+func (m *migration) dropDanglingCommands() {
+	components := map[string]bool{}
+	items, _ := m.content["components"].([]interface{})
+	for i := range items {
+		components[m.element([]string{"components", strconv.Itoa(i)})] = true
+	}
+	commands, _ := m.content["commands"].([]interface{})
+	for i := len(commands) - 1; i >= 0; i-- {
+		tokens := []string{"commands", strconv.Itoa(i)}
+		var component string
+		for _, commandType := range []string{"exec", "apply"} {
+			if command, ok := m.node(append(tokens, commandType)).(map[string]interface{}); ok {
+				component, _ = command["component"].(string)
+			}
+		}
+		if component == "" || components[component] {
+			continue
+		}
+		dropped := false
+		for _, change := range m.changes {
+			dropped = dropped || (change.Code == errPkg.CodeFieldDropped && change.Element == component &&
+				strings.HasPrefix(change.Field, "/components/"))
+		}
+		if !dropped {
+			continue
+		}
+		m.forgetDropped(tokens)
+		m.change(errPkg.CodeFieldDropped, tokens,
+			fmt.Sprintf("command %s is dropped along with its component %s", m.element(tokens), component))
+		m.removeElement("commands", i)
+	}
+}
+
+// schemaError returns the error of the content not being valid against the JSON schema of the target version
+func (m *migration) schemaError(resultErrors []gojsonschema.ResultError) error {
+	errMsg := fmt.Sprintf("invalid devfile schema for version %s. errors :\n", m.target)
+	var issues errPkg.Issues
+	for _, desc := range resultErrors {
+		errMsg = errMsg + fmt.Sprintf("- %s\n", desc)
+		tokens := devfileCtx.SchemaErrorTokens(desc.Context())
+		issues = append(issues, &errPkg.Issue{
+			Code:     errPkg.CodeSchemaViolation,
+			Severity: errPkg.SeverityError,
+			Message:  desc.String(),
+			Field:    m.sourcePointer(tokens),
+			Element:  m.element(tokens),
+		})
+	}
+	return &errPkg.NonCompliantDevfile{Err: errMsg, Issues: issues}
+}
+
+// change reports a change made to the node of the content at tokens
+func (m *migration) change(code errPkg.IssueCode, tokens []string, message string) {
+	m.changes = append(m.changes, &errPkg.Issue{
+		Code:     code,
+		Severity: errPkg.SeverityWarning,
+		Message:  message,
+		Field:    m.sourcePointer(tokens),
+		Element:  m.element(tokens),
+	})
+}
+
+// node returns the node of the content at tokens, nil if there is none
+func (m *migration) node(tokens []string) interface{} {
+	var node interface{} = m.content
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil
+			}
+			node = n[i]
+		default:
+			return nil
+		}
+	}
+	return node
+}
+
+// element returns the name, or the id for commands, of the element of a top-level list the node at tokens belongs to,
+// if any
+func (m *migration) element(tokens []string) string {
+	if len(tokens) < 2 {
+		return ""
+	}
+	if _, ok := migrationLists[tokens[0]]; !ok {
+		return ""
+	}
+	element, _ := m.node(tokens[:2]).(map[string]interface{})
+	if name, ok := element["name"].(string); ok {
+		return name
+	}
+	id, _ := element["id"].(string)
+	return id
+}
+
+// sourcePointer returns the JSON pointer, in the data before the conversion, of the node of the content at tokens
+func (m *migration) sourcePointer(tokens []string) string {
+	var pointer string
+	for i, token := range tokens {
+		if i == 1 {
+			if sources, ok := m.sources[tokens[0]]; ok {
+				if index, err := strconv.Atoi(token); err == nil && index >= 0 && index < len(sources) {
+					pointer = sources[index]
+					continue
+				}
+			}
+		}
+		pointer += "/" + devfileCtx.EscapeJSONPointerToken(token)
+	}
+	return pointer
+}
+
+// comparePointerTokens compares two lists of JSON pointer tokens, comparing array indexes numerically
+func comparePointerTokens(a []string, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		ai, aErr := strconv.Atoi(a[i])
+		bi, bErr := strconv.Atoi(b[i])
+		if aErr == nil && bErr == nil {
+			if ai < bi {
+				return -1
+			}
+			return 1
+		}
+		if a[i] < b[i] {
+			return -1
+		}
+		return 1
+	}
+	return len(a) - len(b)
+}
+
+// hasPointerPrefix returns true if the tokens are the prefix ones or start with them
+func hasPointerPrefix(tokens []string, prefix []string) bool {
+	if len(tokens) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if tokens[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,259 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDevfileObj_Migrate(t *testing.T) {
+	const devfile222 = `schemaVersion: 2.2.2
+metadata:
+  name: main
+attributes:
+  team: devtools
+variables:
+  IMAGE: quay.io/runtime
+components:
+  - name: runtime
+    container:
+      image: "{{IMAGE}}"
+      annotation:
+        deployment:
+          key: value
+  - name: builder
+    image:
+      imageName: builder
+      dockerfile:
+        uri: Dockerfile
+  - name: deploy
+    kubernetes:
+      deployByDefault: true
+      inlined: "kind: Pod"
+commands:
+  - id: build
+    apply:
+      component: builder
+  - id: run
+    exec:
+      component: runtime
+      commandLine: run
+  - id: deploy
+    apply:
+      component: deploy
+      group:
+        kind: deploy
+projects:
+  - name: main
+    git:
+      remotes:
+        origin: https://github.com/devfile/main
+dependentProjects:
+  - name: library
+    git:
+      remotes:
+        origin: https://github.com/devfile/library
+  - name: main
+    git:
+      remotes:
+        origin: https://github.com/devfile/other
+`
+	const devfile200 = `schemaVersion: 2.0.0
+metadata:
+  name: main
+  attributes:
+    team: devtools
+components:
+  - name: runtime
+    container:
+      image: quay.io/runtime
+commands:
+  - id: run
+    exec:
+      component: runtime
+      commandLine: run
+`
+
+	change := func(code errPkg.IssueCode, field string, element string, message string) *errPkg.Issue {
+		return &errPkg.Issue{Code: code, Severity: errPkg.SeverityWarning, Field: field, Element: element, Message: message}
+	}
+	tests := []struct {
+		name        string
+		devfile     string
+		version     string
+		upgrade     bool
+		wantChanges errPkg.Issues
+		wantDevfile string
+		wantErr     string
+	}{
+		{
+			name:    "downgrade to 2.0.0",
+			devfile: devfile222,
+			version: "2.0.0",
+			wantChanges: errPkg.Issues{
+				change(errPkg.CodeFieldTransformed, "/variables", "",
+					"the variables are replaced with their values, as schema version 2.0.0 does not support variables"),
+				change(errPkg.CodeFieldTransformed, "/attributes", "",
+					"the attributes are moved to the metadata attributes, as schema version 2.0.0 does not support top-level attributes"),
+				change(errPkg.CodeFieldTransformed, "/dependentProjects/0", "library",
+					"dependent project library is moved to the projects, as schema version 2.0.0 does not support dependent projects"),
+				change(errPkg.CodeFieldDropped, "/dependentProjects", "",
+					"field /dependentProjects is not supported by schema version 2.0.0"),
+				change(errPkg.CodeFieldDropped, "/components/2/kubernetes/deployByDefault", "deploy",
+					"field /components/2/kubernetes/deployByDefault is not supported by schema version 2.0.0"),
+				change(errPkg.CodeFieldDropped, "/components/0/container/annotation", "runtime",
+					"field /components/0/container/annotation is not supported by schema version 2.0.0"),
+				change(errPkg.CodeFieldDropped, "/components/1", "builder",
+					"component builder is not supported by schema version 2.0.0"),
+				change(errPkg.CodeFieldDropped, "/commands/2/apply/group", "deploy",
+					"field /commands/2/apply/group is not supported by schema version 2.0.0"),
+				change(errPkg.CodeFieldDropped, "/commands/0", "build",
+					"command build is dropped along with its component builder"),
+			},
+			wantDevfile: `commands:
+- exec:
+    commandLine: run
+    component: runtime
+  id: run
+- apply:
+    component: deploy
+  id: deploy
+components:
+- container:
+    image: quay.io/runtime
+  name: runtime
+- kubernetes:
+    inlined: 'kind: Pod'
+  name: deploy
+metadata:
+  attributes:
+    team: devtools
+  name: main
+projects:
+- git:
+    remotes:
+      origin: https://github.com/devfile/main
+  name: main
+- git:
+    remotes:
+      origin: https://github.com/devfile/library
+  name: library
+schemaVersion: 2.0.0
+`,
+		},
+		{
+			name:    "downgrade to 2.2.0",
+			devfile: devfile222,
+			version: "2.2.0",
+			wantChanges: errPkg.Issues{
+				change(errPkg.CodeFieldTransformed, "/dependentProjects/0", "library",
+					"dependent project library is moved to the projects, as schema version 2.2.0 does not support dependent projects"),
+				change(errPkg.CodeFieldDropped, "/dependentProjects", "",
+					"field /dependentProjects is not supported by schema version 2.2.0"),
+			},
+		},
+		{
+			name:        "upgrade from 2.0.0",
+			devfile:     devfile200,
+			version:     "2.3.0",
+			upgrade:     true,
+			wantDevfile: "commands:\n- exec:\n    commandLine: run\n    component: runtime\n  id: run\ncomponents:\n- container:\n    image: quay.io/runtime\n  name: runtime\nmetadata:\n  attributes:\n    team: devtools\n  name: main\nschemaVersion: 2.3.0\n",
+		},
+		{
+			name:    "upgrade to an older version",
+			devfile: devfile222,
+			version: "2.1.0",
+			upgrade: true,
+			wantErr: "cannot upgrade the devfile from schema version 2.2.2 to the older version 2.1.0",
+		},
+		{
+			name:    "downgrade to a newer version",
+			devfile: devfile200,
+			version: "2.2.0",
+			wantErr: "cannot downgrade the devfile from schema version 2.0.0 to the newer version 2.2.0",
+		},
+		{
+			name:    "unsupported version",
+			devfile: devfile200,
+			version: "v1alpha2",
+			upgrade: true,
+			wantErr: `schema version "v1alpha2" is not supported`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			falseValue := false
+			d, err := ParseDevfile(ParserArgs{Data: []byte(tt.devfile), SetBooleanDefaults: &falseValue})
+			if err != nil {
+				t.Fatalf("TestDevfileObj_Migrate() unexpected error parsing the devfile: %v", err)
+			}
+			migrate := d.Downgrade
+			if tt.upgrade {
+				migrate = d.Upgrade
+			}
+			report, err := migrate(tt.version)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				assert.Equal(t, d.Data.GetSchemaVersion(), d.Ctx.GetApiVersion(), "TestDevfileObj_Migrate(): the data should be left as it is")
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.version, report.To)
+			assert.Equal(t, d.Ctx.GetApiVersion(), report.From)
+			// locations are covered by the location tests
+			for _, change := range report.Changes {
+				change.Locations = nil
+			}
+			assert.Equal(t, tt.wantChanges, report.Changes, "TestDevfileObj_Migrate(): changes should match")
+			assert.Equal(t, tt.version, d.Data.GetSchemaVersion())
+			if tt.wantDevfile != "" {
+				content, err := yaml.Marshal(d.Data)
+				if err != nil {
+					t.Fatalf("TestDevfileObj_Migrate() unexpected error marshalling the devfile: %v", err)
+				}
+				assert.Equal(t, tt.wantDevfile, string(content), "TestDevfileObj_Migrate(): devfile should match")
+			}
+		})
+	}
+}
+
+func Test_migration_schemaErrorsUnderEscapedKeys(t *testing.T) {
+	const schema = `{"properties": {"metadata": {"properties": {"attributes": {"additionalProperties": {"type": "string"}}}}}}`
+	m := &migration{
+		target:  "2.0.0",
+		content: map[string]interface{}{"metadata": map[string]interface{}{"attributes": map[string]interface{}{"app.kubernetes.io/~name": 1}}},
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(schema), gojsonschema.NewGoLoader(m.content))
+	if err != nil {
+		t.Fatalf("Test_migration_schemaErrorsUnderEscapedKeys() unexpected error: %v", err)
+	}
+
+	targets, _ := pruneTargets(result.Errors())
+	assert.Equal(t, [][]string{{"metadata", "attributes", "app.kubernetes.io/~name"}}, targets, "Test_migration_schemaErrorsUnderEscapedKeys(): prune targets should match")
+	issues := errPkg.GetIssues(m.schemaError(result.Errors()))
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, "/metadata/attributes/app.kubernetes.io~1~0name", issues[0].Field, "Test_migration_schemaErrorsUnderEscapedKeys(): field should match")
+	}
+}
@@ -0,0 +1,203 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	"github.com/devfile/library/v2/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+)
+
+// MonorepoArgs are the arguments to discover and parse the devfiles of a monorepo
+type MonorepoArgs struct {
+	// Root is the directory walked to discover the devfiles. Paths matching the ignore rules of the .odoignore or
+	// .gitignore file of Root or of the directories they are in, and .git directories, are skipped.
+	Root string
+	// ParserArgs are the arguments used to parse each devfile, their Path is set to the path of the devfile, while Data,
+	// URL and FS are ignored
+	ParserArgs ParserArgs
+	// Parser, if set, parses the devfiles, reusing the devfiles it cached
+	Parser *Parser
+	// Concurrency is the maximum number of devfiles parsed concurrently, the number of CPUs is used if it is not set
+	Concurrency int
+}
+
+// MonorepoDevfile is a devfile discovered in a monorepo
+type MonorepoDevfile struct {
+	// Path is the path of the devfile relative to the root of the monorepo, with forward slashes, or the path of the
+	// directory that could not be walked
+	Path string
+	// Devfile is the parsed devfile, it is only set if parsing succeeded
+	Devfile DevfileObj
+	// Err is the error parsing the devfile, or walking the directory, if any
+	Err error
+}
+
+// MonorepoResult is the result of parsing the devfiles of a monorepo
+type MonorepoResult struct {
+	// Devfiles are the discovered devfiles, sorted by path
+	Devfiles []MonorepoDevfile
+	// NameCollisions are the metadata names shared by several parsed devfiles, mapped to the paths of these devfiles
+	NameCollisions map[string][]string
+}
+
+// ParseMonorepo discovers the devfiles under the root directory of a monorepo, at most one per directory looked up in the
+// same order as when parsing a directory, and parses them concurrently. An error is returned if the root cannot be walked,
+// the errors parsing a devfile, or walking a directory under the root, are reported in the result of its path.
+func ParseMonorepo(args MonorepoArgs) (MonorepoResult, error) {
+	discovered, err := discoverDevfiles(args.Root)
+	if err != nil {
+		return MonorepoResult{}, err
+	}
+
+	concurrency := args.Concurrency
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+	result := MonorepoResult{Devfiles: discovered}
+	// a failed devfile does not stop the others from being parsed, as its error is reported in its result
+	runInOrder(len(discovered), concurrency, func(i int) error {
+		if discovered[i].Err != nil {
+			return nil
+		}
+		parserArgs := args.ParserArgs
+		parserArgs.Path = filepath.Join(args.Root, filepath.FromSlash(discovered[i].Path))
+		parserArgs.Data = nil
+		parserArgs.URL = ""
+		parserArgs.FS = nil
+		devfile := MonorepoDevfile{Path: discovered[i].Path}
+		if args.Parser != nil {
+			devfile.Devfile, devfile.Err = args.Parser.ParseDevfile(parserArgs)
+		} else {
+			devfile.Devfile, devfile.Err = ParseDevfile(parserArgs)
+		}
+		if devfile.Err != nil {
+			devfile.Devfile = DevfileObj{}
+		}
+		result.Devfiles[i] = devfile
+		return nil
+	})
+
+	namePaths := map[string][]string{}
+	for _, devfile := range result.Devfiles {
+		if devfile.Err != nil || devfile.Devfile.Data == nil {
+			continue
+		}
+		if name := devfile.Devfile.Data.GetMetadata().Name; name != "" {
+			namePaths[name] = append(namePaths[name], devfile.Path)
+		}
+	}
+	for name, paths := range namePaths {
+		if len(paths) > 1 {
+			if result.NameCollisions == nil {
+				result.NameCollisions = map[string][]string{}
+			}
+			result.NameCollisions[name] = paths
+		}
+	}
+	return result, nil
+}
+
+// discoverDevfiles returns the devfiles found under root, sorted by path relative to root. The directories that cannot be
+// walked are returned with their error, the walk going on with the other directories.
+func discoverDevfiles(root string) ([]MonorepoDevfile, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the absolute path of %s", root)
+	}
+	rootRules, err := util.GetIgnoreRulesFromDirectory(absRoot)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the ignore rules of %s", root)
+	}
+	// the ignore rules of a directory apply to its subdirectories, along with the rules of its parent directories
+	dirRules := map[string][]string{absRoot: util.GetAbsGlobExps(absRoot, rootRules)}
+
+	var devfiles []MonorepoDevfile
+	addErr := func(path string, err error) {
+		relPath, relErr := filepath.Rel(absRoot, path)
+		if relErr != nil {
+			relPath = path
+		}
+		devfiles = append(devfiles, MonorepoDevfile{Path: filepath.ToSlash(relPath), Err: err})
+	}
+	err = filepath.WalkDir(absRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// the root cannot be walked, while the error of another directory is reported in its result
+			if path == absRoot {
+				return err
+			}
+			addErr(path, errors.Wrapf(err, "failed to walk %s", path))
+			return nil
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		absRules, walked := dirRules[path]
+		if !walked {
+			absRules = dirRules[filepath.Dir(path)]
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			ignored, err := util.IsGlobExpMatch(path, absRules)
+			if err != nil {
+				return err
+			}
+			if ignored {
+				return filepath.SkipDir
+			}
+			rules, err := util.GetIgnoreRulesFromDirectory(path)
+			if err != nil {
+				addErr(path, errors.Wrapf(err, "failed to read the ignore rules of %s", path))
+			} else {
+				absRules = append(append([]string(nil), absRules...), util.GetAbsGlobExps(path, rules)...)
+			}
+			dirRules[path] = absRules
+		}
+		for _, name := range devfileCtx.PossibleDevfileNames() {
+			devfilePath := filepath.Join(path, name)
+			if !util.CheckPathExists(devfilePath) {
+				continue
+			}
+			ignored, err := util.IsGlobExpMatch(devfilePath, absRules)
+			if err != nil {
+				return err
+			}
+			if !ignored {
+				relPath, err := filepath.Rel(absRoot, devfilePath)
+				if err != nil {
+					return err
+				}
+				klog.V(4).Infof("found devfile %s", devfilePath)
+				devfiles = append(devfiles, MonorepoDevfile{Path: filepath.ToSlash(relPath)})
+			}
+			break
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to discover the devfiles under %s", root)
+	}
+	sort.SliceStable(devfiles, func(i, j int) bool {
+		return devfiles[i].Path < devfiles[j].Path
+	})
+	return devfiles, nil
+}
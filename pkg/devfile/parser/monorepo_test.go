@@ -0,0 +1,134 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMonorepo(t *testing.T) {
+	devfile := func(name string) string {
+		return fmt.Sprintf("schemaVersion: 2.2.0\nmetadata:\n  name: %s\ncomponents:\n  - name: runtime\n    container:\n      image: quay.io/%s\n", name, name)
+	}
+	files := map[string]string{
+		"devfile.yaml":                      devfile("root"),
+		"services/a/devfile.yaml":           devfile("service"),
+		"services/b/.devfile.yaml":          devfile("b"),
+		"services/c/devfile.yaml":           devfile("service"),
+		"services/c/devfile.yml":            devfile("lower-priority"),
+		"services/d/nested/devfile.yml":     devfile("nested"),
+		"services/broken/devfile.yaml":      "schemaVersion: 2.2.0\ncomponents: not-a-list\n",
+		"services/e/.gitignore":             "generated\n",
+		"services/e/generated/devfile.yaml": devfile("ignored"),
+		"services/f/generated/devfile.yaml": devfile("generated"),
+		"vendor/devfile.yaml":               devfile("vendored"),
+		".git/devfile.yaml":                 devfile("git"),
+		".gitignore":                        "# dependencies\nvendor\n",
+	}
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("TestParseMonorepo() failed to create the directory of %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("TestParseMonorepo() failed to write %s: %v", name, err)
+		}
+	}
+
+	for _, concurrency := range []int{0, 1} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			result, err := ParseMonorepo(MonorepoArgs{
+				Root:        root,
+				ParserArgs:  ParserArgs{DownloadGitResources: &isFalse},
+				Concurrency: concurrency,
+			})
+			if err != nil {
+				t.Fatalf("TestParseMonorepo() unexpected error: %v", err)
+			}
+
+			wantNames := map[string]string{
+				"devfile.yaml":                      "root",
+				"services/a/devfile.yaml":           "service",
+				"services/b/.devfile.yaml":          "b",
+				"services/broken/devfile.yaml":      "",
+				"services/c/devfile.yaml":           "service",
+				"services/d/nested/devfile.yml":     "nested",
+				"services/f/generated/devfile.yaml": "generated",
+			}
+			var paths []string
+			for _, devfile := range result.Devfiles {
+				paths = append(paths, devfile.Path)
+				if devfile.Path == "services/broken/devfile.yaml" {
+					assert.Error(t, devfile.Err, "TestParseMonorepo(): parsing %s should fail", devfile.Path)
+					continue
+				}
+				if assert.NoError(t, devfile.Err, "TestParseMonorepo(): unexpected error parsing %s", devfile.Path) {
+					assert.Equal(t, wantNames[devfile.Path], devfile.Devfile.Data.GetMetadata().Name)
+				}
+			}
+			assert.Equal(t, []string{
+				"devfile.yaml",
+				"services/a/devfile.yaml",
+				"services/b/.devfile.yaml",
+				"services/broken/devfile.yaml",
+				"services/c/devfile.yaml",
+				"services/d/nested/devfile.yml",
+				"services/f/generated/devfile.yaml",
+			}, paths, "TestParseMonorepo(): discovered devfiles should match")
+			assert.Equal(t, map[string][]string{"service": {"services/a/devfile.yaml", "services/c/devfile.yaml"}}, result.NameCollisions,
+				"TestParseMonorepo(): name collisions should match")
+		})
+	}
+
+	t.Run("missing root", func(t *testing.T) {
+		_, err := ParseMonorepo(MonorepoArgs{Root: filepath.Join(root, "missing")})
+		assert.Error(t, err)
+	})
+
+	t.Run("unreadable directory", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("directories are always readable by root")
+		}
+		unreadable := filepath.Join(root, "services", "a")
+		if err := os.Chmod(unreadable, 0); err != nil {
+			t.Fatalf("TestParseMonorepo() failed to make %s unreadable: %v", unreadable, err)
+		}
+		defer func() {
+			_ = os.Chmod(unreadable, 0750)
+		}()
+
+		result, err := ParseMonorepo(MonorepoArgs{Root: root, ParserArgs: ParserArgs{DownloadGitResources: &isFalse}})
+		if err != nil {
+			t.Fatalf("TestParseMonorepo() unexpected error: %v", err)
+		}
+		var paths []string
+		for _, devfile := range result.Devfiles {
+			paths = append(paths, devfile.Path)
+			if devfile.Path == "services/a" {
+				assert.Error(t, devfile.Err, "TestParseMonorepo(): walking %s should fail", devfile.Path)
+			}
+		}
+		assert.Contains(t, paths, "services/a", "TestParseMonorepo(): the error of the unreadable directory should be reported")
+		assert.Contains(t, paths, "services/f/generated/devfile.yaml", "TestParseMonorepo(): the other directories should be walked")
+	})
+}
@@ -0,0 +1,207 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	parserUtil "github.com/devfile/library/v2/pkg/devfile/parser/util"
+	"github.com/devfile/library/v2/pkg/util"
+	registryLibrary "github.com/devfile/registry-support/registry-library/library"
+	"github.com/distribution/reference"
+	"oras.land/oras-go/pkg/auth"
+	dockerAuth "oras.land/oras-go/pkg/auth/docker"
+	"oras.land/oras-go/pkg/content"
+	orasctx "oras.land/oras-go/pkg/context"
+	"oras.land/oras-go/pkg/oras"
+)
+
+const (
+	// OCIURIPrefix is the prefix of import reference uris pointing to an OCI artifact
+	OCIURIPrefix = "oci://"
+
+	// ociArchiveFile is the layer of a devfile stack holding its resources as a tar archive
+	ociArchiveFile = "archive.tar"
+)
+
+// OCIResolver resolves import references defined with an uri of the form oci://registry/repository:tag@digest,
+// by pulling the devfile and its sibling resources from an OCI registry.
+// The credentials provided for the registry by ResolverArgs.Credentials are used if any, otherwise credentials are read
+// from the standard Docker config, i.e. $DOCKER_CONFIG/config.json or ~/.docker/config.json, including its credential
+// helpers. Registries on localhost are accessed over plain HTTP.
+type OCIResolver struct{}
+
+func (r OCIResolver) CanResolve(importReference v1.ImportReference) bool {
+	return strings.HasPrefix(importReference.Uri, OCIURIPrefix)
+}
+
+func (r OCIResolver) Resolve(args ResolverArgs, importReference v1.ImportReference) (ResolvedImport, error) {
+	var d DevfileObj
+	ref, err := parseOCIReference(importReference.Uri)
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+
+	stackDir, err := os.MkdirTemp(os.TempDir(), "oci-resources")
+	if err != nil {
+		return ResolvedImport{}, fmt.Errorf("failed to create dir: %s, error: %v", stackDir, err)
+	}
+	defer os.RemoveAll(stackDir)
+
+	err = args.vault.directory("oci "+ref, importReference.Uri, stackDir, func(dir string) error {
+		return pullOCIArtifact(util.GetContextOrBackground(args.Context), ref, dir, args.HTTPTimeout, args.Credentials)
+	})
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+
+	devfilePath := ""
+	for _, devfile := range parserUtil.DevfilePossibilities {
+		if util.CheckPathExists(filepath.Join(stackDir, devfile)) {
+			devfilePath = filepath.Join(stackDir, devfile)
+			break
+		}
+	}
+	if devfilePath == "" {
+		return ResolvedImport{}, &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("OCI artifact %s does not contain a devfile", ref)}
+	}
+	devfileContent, err := os.ReadFile(filepath.Clean(devfilePath))
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+	d.Ctx, err = devfileCtx.NewByteContentDevfileCtx(devfileContent)
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+
+	// resources can only be copied next to a devfile on disk
+	if args.DevfileCtx.GetAbsPath() != "" && args.DevfileCtx.GetIOFS() == nil {
+		destDir, err := args.stage.dir(path.Dir(args.DevfileCtx.GetAbsPath()))
+		if err != nil {
+			return ResolvedImport{}, err
+		}
+		err = util.CopyAllDirFiles(stackDir, destDir)
+		if err != nil {
+			return ResolvedImport{}, err
+		}
+	}
+
+	return ResolvedImport{ImportReference: importReference, Devfile: d}, nil
+}
+
+// parseOCIReference validates an oci:// uri and returns the OCI reference it refers to, defaulting to the latest tag
+func parseOCIReference(uri string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(uri, OCIURIPrefix))
+	if err != nil {
+		return "", &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("the provided uri: %s is not a valid OCI reference: %v", uri, err)}
+	}
+	return reference.TagNameOnly(named).String(), nil
+}
+
+// pullOCIArtifact pulls the layers of the OCI artifact ref to destDir, and extracts the devfile stack archive if present
+func pullOCIArtifact(ctx context.Context, ref string, destDir string, httpTimeout *int, credentials util.CredentialProvider) error {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return err
+	}
+	plainHTTP, err := docker.MatchLocalhost(reference.Domain(named))
+	if err != nil {
+		return err
+	}
+
+	timeout := util.HTTPRequestResponseTimeout
+	if httpTimeout != nil && *httpTimeout >= 0 {
+		timeout = time.Duration(*httpTimeout) * time.Second
+	}
+	resolverOpts := []auth.ResolverOption{auth.WithResolverClient(&http.Client{Timeout: timeout})}
+	if plainHTTP {
+		resolverOpts = append(resolverOpts, auth.WithResolverPlainHTTP())
+	}
+
+	resolver, err := ociResolver(reference.Domain(named), credentials, resolverOpts)
+	if err != nil {
+		return err
+	}
+
+	fileStore := content.NewFile(destDir)
+	defer fileStore.Close()
+
+	_, err = oras.Copy(orasctx.WithLoggerDiscarded(ctx), resolver, ref, fileStore, ref)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("failed to pull OCI artifact %s: %v", ref, err)
+	}
+
+	archivePath := filepath.Join(destDir, ociArchiveFile)
+	if util.CheckPathExists(archivePath) {
+		err = util.Untar(archivePath, destDir, registryLibrary.ExcludedFiles)
+		if err != nil {
+			return fmt.Errorf("failed to extract %s of OCI artifact %s: %v", ociArchiveFile, ref, err)
+		}
+		return os.RemoveAll(archivePath)
+	}
+
+	return nil
+}
+
+// ociResolver returns the resolver of the artifacts of registry, authenticated with the credentials provided for the
+// registry, or with the ones of the Docker config if there are none
+func ociResolver(registry string, credentials util.CredentialProvider, resolverOpts []auth.ResolverOption) (remotes.Resolver, error) {
+	var registryCredentials *util.Credentials
+	var err error
+	if credentials != nil {
+		if registryCredentials, err = credentials.Credentials(registry); err != nil {
+			return nil, err
+		}
+	}
+	if registryCredentials == nil {
+		authClient, err := dockerAuth.NewClientWithDockerFallback()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load docker config: %v", err)
+		}
+		return authClient.ResolverWithOpts(resolverOpts...)
+	}
+
+	settings := &auth.ResolverSettings{}
+	for _, option := range resolverOpts {
+		option(settings)
+	}
+	options := docker.ResolverOptions{Client: settings.Client, PlainHTTP: settings.PlainHTTP}
+	if registryCredentials.Token != "" {
+		// a registry token is sent as is, rather than exchanged for a token by the registry authorizer
+		options.Headers = http.Header{}
+		registryCredentials.SetAuthHeader(options.Headers)
+	} else {
+		options.Credentials = func(string) (string, string, error) {
+			return registryCredentials.Username, registryCredentials.Password, nil
+		}
+	}
+	return docker.NewResolver(options), nil
+}
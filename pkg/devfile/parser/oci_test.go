@@ -0,0 +1,259 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/docker/cli/cli/config"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// testOCIRegistry is a minimal in-process OCI distribution registry serving read-only artifacts
+type testOCIRegistry struct {
+	// manifests maps repository:reference, where reference is a tag or a digest, to a manifest
+	manifests map[string][]byte
+	blobs     map[digest.Digest][]byte
+	// username and password are required as basic auth credentials if set
+	username string
+	password string
+}
+
+func newTestOCIRegistry() *testOCIRegistry {
+	return &testOCIRegistry{manifests: map[string][]byte{}, blobs: map[digest.Digest][]byte{}}
+}
+
+// push stores an artifact made of the given named layers under repository:tag, and returns the digest of its manifest
+func (r *testOCIRegistry) push(repository, tag string, layers []ocispec.Descriptor, contents [][]byte) digest.Digest {
+	configBlob := []byte("{}")
+	r.blobs[digest.FromBytes(configBlob)] = configBlob
+	for i := range layers {
+		layers[i].Digest = digest.FromBytes(contents[i])
+		layers[i].Size = int64(len(contents[i]))
+		r.blobs[layers[i].Digest] = contents[i]
+	}
+	manifest, _ := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config: ocispec.Descriptor{
+			MediaType: "application/vnd.devfileio.devfile.config.v2+json",
+			Digest:    digest.FromBytes(configBlob),
+			Size:      int64(len(configBlob)),
+		},
+		Layers: layers,
+	})
+	manifestDigest := digest.FromBytes(manifest)
+	r.manifests[repository+":"+tag] = manifest
+	r.manifests[repository+":"+manifestDigest.String()] = manifest
+	return manifestDigest
+}
+
+func (r *testOCIRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.username != "" {
+		if username, password, ok := req.BasicAuth(); !ok || username != r.username || password != r.password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	urlPath := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case strings.Contains(urlPath, "/manifests/"):
+		parts := strings.SplitN(urlPath, "/manifests/", 2)
+		manifest, ok := r.manifests[parts[0]+":"+parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+		w.Header().Set("Content-Length", fmt.Sprint(len(manifest)))
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(manifest)
+		}
+	case strings.Contains(urlPath, "/blobs/"):
+		parts := strings.SplitN(urlPath, "/blobs/", 2)
+		blob, ok := r.blobs[digest.Digest(parts[1])]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(blob)))
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(blob)
+		}
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func newTarArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		_, err = tw.Write([]byte(content))
+		if err != nil {
+			t.Fatalf("failed to write tar content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func titled(name, mediaType string) ocispec.Descriptor {
+	return ocispec.Descriptor{MediaType: mediaType, Annotations: map[string]string{ocispec.AnnotationTitle: name}}
+}
+
+func TestParseDevfile_OCIParent(t *testing.T) {
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: parent-runtime
+  container:
+    image: quay.io/parent-image
+`
+	registry := newTestOCIRegistry()
+	stackDigest := registry.push("stacks/nodejs", "1.0.0",
+		[]ocispec.Descriptor{titled("devfile.yaml", "application/vnd.devfileio.devfile.layer.v1"), titled("archive.tar", "application/x-tar")},
+		[][]byte{[]byte(parentDevfile), newTarArchive(t, map[string]string{"outerloop-deploy.yaml": "kind: Deployment", "OWNERS": "approvers: []"})})
+	registry.push("stacks/empty", "latest",
+		[]ocispec.Descriptor{titled("README.md", "text/markdown")},
+		[][]byte{[]byte("no devfile here")})
+	testServer := httptest.NewServer(registry)
+	defer testServer.Close()
+	host := strings.TrimPrefix(testServer.URL, "http://")
+
+	// point the standard Docker config at credentials for the test registry
+	dockerConfigDir := t.TempDir()
+	dockerConfig := fmt.Sprintf(`{"auths": {"%s": {"auth": "%s"}}}`, host, base64.StdEncoding.EncodeToString([]byte("devfile:secret")))
+	if err := os.WriteFile(filepath.Join(dockerConfigDir, "config.json"), []byte(dockerConfig), 0600); err != nil {
+		t.Fatalf("failed to write docker config: %v", err)
+	}
+	originalDockerConfigDir := config.Dir()
+	config.SetDir(dockerConfigDir)
+	defer config.SetDir(originalDockerConfigDir)
+
+	tests := []struct {
+		name          string
+		uri           string
+		username      string
+		password      string
+		wantResources []string
+		wantErr       string
+	}{
+		{
+			name:          "should pull the parent devfile and its resources by tag",
+			uri:           fmt.Sprintf("oci://%s/stacks/nodejs:1.0.0", host),
+			wantResources: []string{"outerloop-deploy.yaml"},
+		},
+		{
+			name:          "should pull the parent devfile by tag and digest",
+			uri:           fmt.Sprintf("oci://%s/stacks/nodejs:1.0.0@%s", host, stackDigest),
+			wantResources: []string{"outerloop-deploy.yaml"},
+		},
+		{
+			name:          "should use the credentials of the docker config",
+			uri:           fmt.Sprintf("oci://%s/stacks/nodejs:1.0.0", host),
+			username:      "devfile",
+			password:      "secret",
+			wantResources: []string{"outerloop-deploy.yaml"},
+		},
+		{
+			name:     "should fail if the credentials of the docker config are rejected",
+			uri:      fmt.Sprintf("oci://%s/stacks/nodejs:1.0.0", host),
+			username: "devfile",
+			password: "another-secret",
+			wantErr:  "failed to pull OCI artifact",
+		},
+		{
+			name:    "should fail if the artifact does not exist",
+			uri:     fmt.Sprintf("oci://%s/stacks/nodejs:2.0.0", host),
+			wantErr: "failed to pull OCI artifact .*stacks/nodejs:2.0.0",
+		},
+		{
+			name:    "should fail if the artifact does not contain a devfile",
+			uri:     fmt.Sprintf("oci://%s/stacks/empty", host),
+			wantErr: "OCI artifact .*stacks/empty:latest does not contain a devfile",
+		},
+		{
+			name:    "should fail if the uri is not a valid OCI reference",
+			uri:     "oci://Invalid/Reference",
+			wantErr: "is not a valid OCI reference",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry.username, registry.password = tt.username, tt.password
+
+			devfileDir := t.TempDir()
+			devfilePath := filepath.Join(devfileDir, "devfile.yaml")
+			devfile := fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: %s
+`, tt.uri)
+			if err := os.WriteFile(devfilePath, []byte(devfile), 0600); err != nil {
+				t.Fatalf("failed to write devfile: %v", err)
+			}
+
+			d, err := ParseDevfile(ParserArgs{Path: devfilePath, DownloadGitResources: &isFalse})
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("TestParseDevfile_OCIParent() expected an error matching %q", tt.wantErr)
+				}
+				assert.Regexp(t, tt.wantErr, err.Error(), "TestParseDevfile_OCIParent(): Error message should match")
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestParseDevfile_OCIParent() unexpected error: %v", err)
+			}
+
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("TestParseDevfile_OCIParent() unexpected error getting components: %v", err)
+			}
+			if assert.Len(t, components, 1) {
+				assert.Equal(t, "parent-runtime", components[0].Name)
+				assert.Equal(t, "uri: "+tt.uri, components[0].Attributes.GetString(importSourceAttribute, nil))
+			}
+			for _, resource := range tt.wantResources {
+				assert.FileExists(t, filepath.Join(devfileDir, resource), "TestParseDevfile_OCIParent(): resources should be copied next to the devfile")
+			}
+			assert.NoFileExists(t, filepath.Join(devfileDir, "OWNERS"), "TestParseDevfile_OCIParent(): excluded files should not be copied")
+			assert.NoFileExists(t, filepath.Join(devfileDir, "archive.tar"), "TestParseDevfile_OCIParent(): the archive should be extracted")
+		})
+	}
+}
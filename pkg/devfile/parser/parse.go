@@ -19,21 +19,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"net/url"
 	"os"
 	"path"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/devfile/api/v2/pkg/attributes"
 	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
 	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/devfile/library/v2/pkg/devfile/registry"
 	"github.com/devfile/library/v2/pkg/util"
 	registryLibrary "github.com/devfile/registry-support/registry-library/library"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -68,11 +69,19 @@ func parseDevfile(d DevfileObj, resolveCtx *resolutionContextTree, tool resolver
 		return d, &errPkg.NonCompliantDevfile{Err: err.Error()}
 	}
 
+	err = tool.strict.checkDevfile(d, resolveCtx.importReference)
+	if err != nil {
+		return d, err
+	}
+
+	d.flattened = flattenedDevfile
 	if flattenedDevfile {
-		err = parseParentAndPlugin(d, resolveCtx, tool)
+		err = parseParentAndPlugin(&d, resolveCtx, tool)
 		if err != nil {
 			return DevfileObj{}, err
 		}
+	} else {
+		d.Provenance.setElements(d.Data.GetDevfileWorkspaceSpecContent(), nil)
 	}
 
 	// Successful
@@ -91,6 +100,11 @@ type ParserArgs struct {
 	URL string
 	// Data is the devfile content in []byte format.
 	Data []byte
+	// FS, if set, is the file system the devfile is read from instead of the disk, e.g. an embed.FS or a zip.Reader.
+	// Path is then a slash-separated path in FS, and defaults to the root of FS. Parents with a relative uri and
+	// Kubernetes component uris are also read from FS, and resources of remote parents are not downloaded since they
+	// cannot be written to it.
+	FS fs.FS
 	// FlattenedDevfile defines if the returned devfileObj is flattened content (true) or raw content (false).
 	// The value is default to be true.
 	FlattenedDevfile *bool
@@ -102,15 +116,31 @@ type ParserArgs struct {
 	RegistryURLs []string
 	// Token is a GitHub, GitLab, or Bitbucket personal access token used with a private git repo URL
 	Token string
+	// Credentials provides the credentials of the hosts the devfile, its parent and plugins are fetched from, e.g. git
+	// providers and devfile or OCI registries, by host. They are used by every request of the parser, except for the
+	// main devfile URL if Token is set. util.NewNetrcCredentials and util.NewDockerConfigCredentials read them from a
+	// netrc or Docker config file, util.CredentialProviders combines several providers.
+	Credentials util.CredentialProvider
 	// DefaultNamespace is the default namespace to use
 	// If namespace is defined under devfile's parent kubernetes object, this namespace will be ignored.
 	DefaultNamespace string
-	// Context is the context used for making Kubernetes requests
+	// Context is the context used for making Kubernetes and remote requests while resolving the devfile, its parent and plugins.
+	// If it is cancelled or its deadline is exceeded, parsing is aborted and a *errPkg.ParseCancelled error is returned.
 	Context context.Context
 	// K8sClient is the Kubernetes client instance used for interacting with a cluster
 	K8sClient client.Client
 	// ExternalVariables override variables defined in the Devfile
 	ExternalVariables map[string]string
+	// VariableProviders supply the values of the variables defined or referenced in the devfile, when substituted by
+	// ParseDevfileAndValidate. A variable takes its value from the first of ExternalVariables, the providers in order and
+	// the devfile variables that defines it, and a reference {{name:-default}} to an undefined variable takes the default
+	// value. The source of each value is reported in DevfileObj.Variables.
+	VariableProviders []VariableProvider
+	// SensitiveVariables are the names of the variables whose values are sensitive, e.g. credentials, in addition to the
+	// variables supplied by a SensitiveVariableProvider. Their values are substituted by ParseDevfileAndValidate, but
+	// WriteYamlDevfile writes {{name}} placeholders instead in the fields whose authored value references them, and they
+	// are redacted from the returned errors and warnings.
+	SensitiveVariables []string
 	// HTTPTimeout overrides the request and response timeout values for reading a parent devfile reference from the registry.  If a negative value is specified, the default timeout will be used.
 	HTTPTimeout *int
 	// SetBooleanDefaults sets the boolean properties to their default values after a devfile been parsed.
@@ -123,6 +153,32 @@ type ParserArgs struct {
 	DownloadGitResources *bool
 	// DevfileUtilsClient exposes the interface for mock implementation.
 	DevfileUtilsClient parserUtil.DevfileUtils
+	// Resolvers resolve the import references of the parent and plugins. They are consulted in order, before the
+	// resolvers returned by DefaultResolvers, and the first one that can resolve an import reference is used.
+	Resolvers []Resolver
+	// GenerateLockfile records the resolution of every parent and plugin in DevfileObj.Lockfile if true.
+	// The lockfile can be saved with Lockfile.WriteToFile and passed back in Lockfile to resolve the same content later on.
+	GenerateLockfile bool
+	// Lockfile, if set, enables the strict mode: parents and plugins are resolved from the URLs, registry versions and git commits
+	// recorded in the lockfile, and parsing fails with a *errPkg.LockfileMismatch error if an import reference is not recorded
+	// or if the content fetched for it does not match the recorded digest.
+	Lockfile *Lockfile
+	// OfflineVault, if set, is the path of a vault populated with VendorDevfile. Parents, plugins, Kubernetes component uris,
+	// git resources and the git commits recorded by GenerateLockfile are then served from the vault instead of being fetched,
+	// and parsing fails with a *errPkg.OfflineCacheMiss error for any of them that was not vendored.
+	OfflineVault string
+	// RecordProvenance reports in DevfileObj.Provenance the resolution tree of the devfile, and the devfile each element
+	// of the flattened devfile came from, if true.
+	RecordProvenance bool
+	// Concurrency is the maximum number of plugins, and of Kubernetes and OpenShift component uris, resolved concurrently
+	// for each devfile. They are resolved one after another if it is lower than 2. The parsed devfile, the errors and the
+	// generated lockfile are the same whatever the concurrency.
+	Concurrency int
+	// Strict, if set, enables the strict mode. Fields of the devfile, its parent and plugins that are unknown to the devfile
+	// API, at any depth and including overrides, then make parsing fail with a *errPkg.NonCompliantDevfile error holding an
+	// issue for each of them. Deprecated constructs, e.g. schema version 2.0.0, deprecated registry stacks or Kubernetes
+	// resources with a deprecated apiVersion, are reported as DevfileObj.Warnings, or as errors if configured so.
+	Strict *StrictArgs
 }
 
 // ImageSelectorArgs defines the structure to leverage for using image names as selectors after parsing the Devfile.
@@ -148,7 +204,21 @@ type ImageSelectorArgs struct {
 
 // ParseDevfile func populates the devfile data, parses and validates the devfile integrity.
 // Creates devfile context and runtime objects
-func ParseDevfile(args ParserArgs) (d DevfileObj, err error) {
+func ParseDevfile(args ParserArgs) (DevfileObj, error) {
+	var offlineVault *vault
+	if args.OfflineVault != "" {
+		offlineVault = &vault{dir: args.OfflineVault}
+	}
+	return parseDevfileWithVault(args, offlineVault)
+}
+
+// parseDevfileWithVault parses the devfile like ParseDevfile, serving or vendoring remote content with v if it is not nil
+func parseDevfileWithVault(args ParserArgs, v *vault) (DevfileObj, error) {
+	return parseDevfileWithCache(args, v, nil)
+}
+
+// parseDevfileWithCache parses the devfile like parseDevfileWithVault, reusing the devfiles stored in c if it is not nil
+func parseDevfileWithCache(args ParserArgs, v *vault, c *parserCache) (d DevfileObj, err error) {
 	if args.ImageNamesAsSelector != nil && strings.TrimSpace(args.ImageNamesAsSelector.Registry) == "" {
 		return DevfileObj{}, errors.New("registry is mandatory when setting ImageNamesAsSelector in the parser args")
 	}
@@ -158,6 +228,12 @@ func ParseDevfile(args ParserArgs) (d DevfileObj, err error) {
 		if err != nil {
 			return d, err
 		}
+	} else if args.FS != nil && args.URL == "" {
+		devfilePath := args.Path
+		if devfilePath == "" {
+			devfilePath = "."
+		}
+		d.Ctx = devfileCtx.NewFSDevfileCtx(args.FS, devfilePath)
 	} else if args.Path != "" {
 		d.Ctx = devfileCtx.NewDevfileCtx(args.Path)
 	} else if args.URL != "" {
@@ -187,16 +263,44 @@ func ParseDevfile(args ParserArgs) (d DevfileObj, err error) {
 		httpTimeout:          args.HTTPTimeout,
 		downloadGitResources: downloadGitResources,
 		devfileUtilsClient:   args.DevfileUtilsClient,
+		credentials:          args.Credentials,
+		resolvers:            args.Resolvers,
+		lock:                 newLockState(args.GenerateLockfile, args.Lockfile),
+		vault:                v,
+		sources:              &devfileSources{},
+		recordProvenance:     args.RecordProvenance,
+		concurrency:          args.Concurrency,
+		cache:                c,
+		strict:               newStrictState(args.Strict),
+		warnings:             &issueCollector{},
 	}
+	tool.warnings.checkHTTPTimeout(args.HTTPTimeout)
 
 	flattenedDevfile := true
 	if args.FlattenedDevfile != nil {
 		flattenedDevfile = *args.FlattenedDevfile
 	}
 
-	d, err = populateAndParseDevfile(d, &resolutionContextTree{}, tool, flattenedDevfile)
+	if args.RecordProvenance {
+		d.Provenance = newProvenance(ImportKindMain, v1.ImportReference{}, v1.ImportReference{})
+	}
+	var resultKey string
+	if c != nil {
+		// the flattened devfile is looked up once the devfile content is read
+		d, err = populateDevfile(d, &resolutionContextTree{}, tool)
+		if err == nil {
+			resultKey = c.resultKey(d, args)
+			if cached, ok := c.result(resultKey); ok {
+				return cached, nil
+			}
+			d, err = parseDevfile(d, &resolutionContextTree{}, tool, flattenedDevfile)
+		}
+	} else {
+		d, err = populateAndParseDevfile(d, &resolutionContextTree{}, tool, flattenedDevfile)
+	}
+	d.sources = tool.sources
 	if err != nil {
-		return d, err
+		return d, tool.cancellationErr(err, v1.ImportReference{})
 	}
 
 	setBooleanDefaults := true
@@ -205,6 +309,7 @@ func ParseDevfile(args ParserArgs) (d DevfileObj, err error) {
 	}
 	//set defaults only if parsing succeeded
 	if err == nil && setBooleanDefaults {
+		d.defaulted = defaultedFields{}
 		err := setDefaults(d)
 		if err != nil {
 			return d, errors.Wrap(err, "failed to setDefaults")
@@ -218,12 +323,29 @@ func ParseDevfile(args ParserArgs) (d DevfileObj, err error) {
 
 	if convertUriToInlined {
 		d.Ctx.SetConvertUriToInlined(true)
-		err = parseKubeResourceFromURI(d, tool.devfileUtilsClient)
+		err = parseKubeResourceFromURI(d, tool.getDevfileUtilsClient(), tool.concurrency)
 		if err != nil {
-			return d, err
+			return d, tool.cancellationErr(err, v1.ImportReference{})
 		}
 	}
 
+	if err = tool.strict.checkFlattened(d); err != nil {
+		return d, err
+	}
+	deprecations, err := tool.strict.result()
+	if err != nil {
+		return d, err
+	}
+	d.Warnings = append(tool.warnings.list(), deprecations...)
+
+	if args.GenerateLockfile {
+		d.Lockfile = tool.lock.getLockfile()
+	}
+	if err = d.SetUnchanged(); err != nil {
+		return d, err
+	}
+	c.storeResult(resultKey, d)
+
 	return d, err
 }
 
@@ -244,26 +366,159 @@ type resolverTools struct {
 	downloadGitResources bool
 	// devfileUtilsClient exposes the Git Interface to be able to use mock implementation.
 	devfileUtilsClient parserUtil.DevfileUtils
+	// credentials provides the credentials of the requests by host
+	credentials util.CredentialProvider
+	// resolvers are the consumer provided resolvers for import references, consulted before the default ones
+	resolvers []Resolver
+	// lock records the resolution of import references for the lockfile, and pins them when resolving strictly from a lockfile
+	lock *lockState
+	// vault serves remote content when parsing offline, or stores it when vendoring
+	vault *vault
+	// sources records the devfiles imported while flattening, to locate errors in them
+	sources *devfileSources
+	// recordProvenance records the provenance of the imported devfiles if true
+	recordProvenance bool
+	// concurrency is the maximum number of plugins or Kubernetes uris resolved concurrently for a devfile
+	concurrency int
+	// cache stores the parsed parents and plugins to reuse them, nil if they are not cached
+	cache *parserCache
+	// strict reports the unknown fields and deprecated constructs of the devfiles in strict mode, nil otherwise
+	strict *strictState
+	// warnings collects the non-fatal issues found while resolving the devfiles
+	warnings *issueCollector
+	// stage stages the resources copied while resolving a plugin concurrently with others, nil if they are copied as is
+	stage *resourceStage
+}
+
+// fork returns a copy of the tools to resolve an import reference concurrently with others,
+// the lockfile entries and sources it records are added back with join
+func (tool resolverTools) fork() resolverTools {
+	forked := tool
+	forked.lock = tool.lock.fork()
+	forked.sources = tool.sources.fork()
+	forked.strict = tool.strict.fork()
+	forked.warnings = tool.warnings.fork()
+	return forked
+}
+
+// join adds the lockfile entries and sources recorded by the forked tools, in the given order
+func (tool resolverTools) join(forks ...resolverTools) {
+	for _, forked := range forks {
+		tool.lock.join(forked.lock)
+		tool.sources.join(forked.sources)
+		tool.strict.join(forked.strict)
+		tool.warnings.join(forked.warnings)
+	}
+}
+
+// getContext returns the context used for remote requests, context.Background() is used if none was provided
+func (tool resolverTools) getContext() context.Context {
+	return util.GetContextOrBackground(tool.context)
+}
+
+// getDevfileUtilsClient returns the devfileUtilsClient with the resolver context bound to every download made through it
+func (tool resolverTools) getDevfileUtilsClient() parserUtil.DevfileUtils {
+	client := tool.devfileUtilsClient
+	if client == nil {
+		client = parserUtil.NewDevfileUtilsClient()
+	}
+	contextClient := contextDevfileUtils{ctx: tool.getContext(), client: client, warnings: tool.warnings, credentials: tool.credentials}
+	if tool.vault != nil {
+		return vaultDevfileUtils{vault: tool.vault, client: contextClient}
+	}
+	return contextClient
+}
+
+// cancellationErr returns a ParseCancelled error for importReference if the resolver context has been cancelled or its
+// deadline exceeded, and err otherwise. An err that already is a ParseCancelled error is returned as is, so that the
+// innermost reference being resolved is reported.
+func (tool resolverTools) cancellationErr(err error, importReference v1.ImportReference) error {
+	ctxErr := tool.getContext().Err()
+	if ctxErr == nil {
+		return err
+	}
+	var cancelledErr *errPkg.ParseCancelled
+	if errors.As(err, &cancelledErr) {
+		return err
+	}
+	return &errPkg.ParseCancelled{Reference: resolveImportReference(importReference), Err: ctxErr}
+}
+
+// contextDevfileUtils binds a context to the downloads made through a DevfileUtils client, authenticates them with the
+// credentials provided for their host if they have no token, and records their warnings
+type contextDevfileUtils struct {
+	ctx         context.Context
+	client      parserUtil.DevfileUtils
+	warnings    *issueCollector
+	credentials util.CredentialProvider
+}
+
+func (c contextDevfileUtils) DownloadInMemory(params util.HTTPRequestParams) ([]byte, error) {
+	if params.Context == nil {
+		params.Context = c.ctx
+	}
+	if params.Credentials == nil {
+		params.Credentials = c.credentials
+	}
+	if params.OnWarning == nil && c.warnings != nil {
+		params.OnWarning = c.warnings.httpWarning(params.URL)
+	}
+	return c.client.DownloadInMemory(params)
+}
+
+func (c contextDevfileUtils) DownloadGitRepoResources(url string, destDir string, token string) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	if token == "" {
+		credentials, err := util.CredentialsForURL(c.credentials, url)
+		if err != nil {
+			return err
+		}
+		token = credentials.GitToken()
+	}
+	if client, ok := c.client.(parserUtil.DevfileUtilsWithContext); ok {
+		return client.DownloadGitRepoResourcesWithContext(c.ctx, url, destDir, token)
+	}
+	return c.client.DownloadGitRepoResources(url, destDir, token)
 }
 
 func populateAndParseDevfile(d DevfileObj, resolveCtx *resolutionContextTree, tool resolverTools, flattenedDevfile bool) (DevfileObj, error) {
+	start := time.Now()
+	d, err := populateDevfile(d, resolveCtx, tool)
+	if err != nil {
+		return d, err
+	}
+	if d.Provenance != nil {
+		d.Provenance.Root.FetchDuration = time.Since(start)
+		d.Provenance.setLocation(d)
+	}
+
+	return parseDevfile(d, resolveCtx, tool, flattenedDevfile)
+}
+
+// populateDevfile fills the devfile context, fetching the devfile content if needed
+func populateDevfile(d DevfileObj, resolveCtx *resolutionContextTree, tool resolverTools) (DevfileObj, error) {
 	var err error
 	if err = resolveCtx.hasCycle(); err != nil {
 		return DevfileObj{}, &errPkg.NonCompliantDevfile{Err: err.Error()}
 	}
+	if err = tool.cancellationErr(nil, resolveCtx.importReference); err != nil {
+		return DevfileObj{}, err
+	}
 	// Fill the fields of DevfileCtx struct
 	if d.Ctx.GetURL() != "" {
-		err = d.Ctx.PopulateFromURL(tool.devfileUtilsClient)
+		err = d.Ctx.PopulateFromURL(tool.getDevfileUtilsClient())
 	} else if d.Ctx.GetDevfileContent() != nil {
 		err = d.Ctx.PopulateFromRaw()
 	} else {
-		err = d.Ctx.Populate(tool.devfileUtilsClient)
+		err = d.Ctx.Populate(tool.getDevfileUtilsClient())
 	}
 	if err != nil {
-		return d, err
+		return d, tool.cancellationErr(err, resolveCtx.importReference)
 	}
 
-	return parseDevfile(d, resolveCtx, tool, flattenedDevfile)
+	return d, nil
 }
 
 // Parse func populates the flattened devfile data, parses and validates the devfile integrity.
@@ -303,8 +558,9 @@ func ParseFromData(data []byte) (d DevfileObj, err error) {
 	return populateAndParseDevfile(d, &resolutionContextTree{}, resolverTools{}, true)
 }
 
-func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool resolverTools) (err error) {
+func parseParentAndPlugin(d *DevfileObj, resolveCtx *resolutionContextTree, tool resolverTools) (err error) {
 	flattenedParent := &v1.DevWorkspaceTemplateSpecContent{}
+	importedElements := map[provenanceKey]ElementProvenance{}
 	var mainDevfileVersion, parentDevfileVerson, pluginDevfileVerson *versionpkg.Version
 	var devfileVersion string
 	if devfileVersion = d.Ctx.GetApiVersion(); devfileVersion == "" {
@@ -322,18 +578,13 @@ func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool
 		if !reflect.DeepEqual(parent, &v1.Parent{}) {
 
 			var parentDevfileObj DevfileObj
-			switch {
-			case parent.Uri != "":
-				parentDevfileObj, err = parseFromURI(parent.ImportReference, d.Ctx, resolveCtx, tool)
-			case parent.Id != "":
-				parentDevfileObj, err = parseFromRegistry(parent.ImportReference, resolveCtx, tool)
-			case parent.Kubernetes != nil:
-				parentDevfileObj, err = parseFromKubeCRD(parent.ImportReference, resolveCtx, tool)
-			default:
+			if resolver := tool.getResolver(parent.ImportReference); resolver != nil {
+				parentDevfileObj, err = parseFromResolver(resolver, parent.ImportReference, d.Ctx, resolveCtx, tool)
+			} else {
 				err = &errPkg.NonCompliantDevfile{Err: "devfile parent does not define any resources"}
 			}
 			if err != nil {
-				return err
+				return tool.cancellationErr(err, parent.ImportReference)
 			}
 			var devfileVersion string
 			if devfileVersion = parentDevfileObj.Ctx.GetApiVersion(); devfileVersion == "" {
@@ -350,22 +601,24 @@ func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool
 					return &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("the parent devfile version from %v is greater than the child devfile version from %v", resolveImportReference(parent.ImportReference), resolveImportReference(resolveCtx.importReference))}
 				}
 			}
+			d.Provenance.addImport(ImportKindParent, parentDevfileObj.Provenance, parentOverrideKeys(parent.ParentOverrides), importedElements)
 			parentWorkspaceContent := parentDevfileObj.Data.GetDevfileWorkspaceSpecContent()
+			d.parentContent = parentWorkspaceContent.DeepCopy()
 			// add attribute to parent elements
-			err = addSourceAttributesForOverrideAndMerge(parent.ImportReference, parentWorkspaceContent)
+			err = addSourceAttributesForOverrideAndMerge(importSourceReference(parent.ImportReference, parentDevfileObj.resolvedReference), parentWorkspaceContent)
 			if err != nil {
 				return err
 			}
 			if !reflect.DeepEqual(parent.ParentOverrides, v1.ParentOverrides{}) {
 				// add attribute to parentOverrides elements
 				curNodeImportReference := resolveCtx.importReference
-				err = addSourceAttributesForOverrideAndMerge(curNodeImportReference, &parent.ParentOverrides)
+				err = addSourceAttributesForOverrideAndMerge(resolveImportReference(curNodeImportReference), &parent.ParentOverrides)
 				if err != nil {
 					return err
 				}
 				flattenedParent, err = apiOverride.OverrideDevWorkspaceTemplateSpec(parentWorkspaceContent, parent.ParentOverrides)
 				if err != nil {
-					return err
+					return locateConflicts(err, d.Ctx, "/parent")
 				}
 			} else {
 				flattenedParent = parentWorkspaceContent
@@ -380,207 +633,171 @@ func parseParentAndPlugin(d DevfileObj, resolveCtx *resolutionContextTree, tool
 	if err != nil {
 		return err
 	}
+	var pluginComponents []v1.Component
 	for _, component := range components {
 		if component.Plugin != nil && !reflect.DeepEqual(component.Plugin, &v1.PluginComponent{}) {
-			plugin := component.Plugin
-			var pluginDevfileObj DevfileObj
-			switch {
-			case plugin.Uri != "":
-				pluginDevfileObj, err = parseFromURI(plugin.ImportReference, d.Ctx, resolveCtx, tool)
-			case plugin.Id != "":
-				pluginDevfileObj, err = parseFromRegistry(plugin.ImportReference, resolveCtx, tool)
-			case plugin.Kubernetes != nil:
-				pluginDevfileObj, err = parseFromKubeCRD(plugin.ImportReference, resolveCtx, tool)
-			default:
-				err = &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("plugin %s does not define any resources", component.Name)}
-			}
-			if err != nil {
+			pluginComponents = append(pluginComponents, component)
+		}
+	}
+	// plugins are resolved concurrently, each one with forked tools so that the lockfile and the sources are recorded
+	// in the same order as when resolving them one after another. They are then processed in order.
+	pluginDevfileObjs := make([]DevfileObj, len(pluginComponents))
+	pluginTools := make([]resolverTools, len(pluginComponents))
+	// plugins resolved concurrently stage the resources they copy, to copy them in the order of the plugins
+	stageResources := tool.concurrency > 1 && len(pluginComponents) > 1
+	defer func() {
+		for _, pluginTool := range pluginTools {
+			pluginTool.stage.discard()
+		}
+	}()
+	resolveErrs := runInOrder(len(pluginComponents), tool.concurrency, func(i int) error {
+		plugin := pluginComponents[i].Plugin
+		pluginTools[i] = tool.fork()
+		if stageResources {
+			pluginTools[i].stage = newResourceStage(tool.stage)
+		}
+		resolver := tool.getResolver(plugin.ImportReference)
+		if resolver == nil {
+			return &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("plugin %s does not define any resources", pluginComponents[i].Name)}
+		}
+		var err error
+		pluginDevfileObjs[i], err = parseFromResolver(resolver, plugin.ImportReference, d.Ctx, resolveCtx, pluginTools[i])
+		return err
+	})
+	for i, component := range pluginComponents {
+		plugin := component.Plugin
+		if resolveErrs[i] != nil {
+			return tool.cancellationErr(resolveErrs[i], plugin.ImportReference)
+		}
+		tool.join(pluginTools[i])
+		if stageResources {
+			if err := pluginTools[i].stage.commit(); err != nil {
 				return err
 			}
-			var devfileVersion string
-			if devfileVersion = pluginDevfileObj.Ctx.GetApiVersion(); devfileVersion == "" {
-				devfileVersion = pluginDevfileObj.Data.GetSchemaVersion()
-			}
+		}
+		pluginDevfileObj := pluginDevfileObjs[i]
+		var devfileVersion string
+		if devfileVersion = pluginDevfileObj.Ctx.GetApiVersion(); devfileVersion == "" {
+			devfileVersion = pluginDevfileObj.Data.GetSchemaVersion()
+		}
 
-			if devfileVersion != "" {
-				pluginDevfileVerson, err = versionpkg.NewVersion(devfileVersion)
-				if err != nil {
-					return fmt.Errorf("fail to parse version of plugin devfile from: %v", resolveImportReference(component.Plugin.ImportReference))
-				}
-				if pluginDevfileVerson.GreaterThan(mainDevfileVersion) {
-					return &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("the plugin devfile version from %v is greater than the child devfile version from %v", resolveImportReference(component.Plugin.ImportReference), resolveImportReference(resolveCtx.importReference))}
-				}
+		if devfileVersion != "" {
+			pluginDevfileVerson, err = versionpkg.NewVersion(devfileVersion)
+			if err != nil {
+				return fmt.Errorf("fail to parse version of plugin devfile from: %v", resolveImportReference(component.Plugin.ImportReference))
 			}
-			pluginWorkspaceContent := pluginDevfileObj.Data.GetDevfileWorkspaceSpecContent()
-			// add attribute to plugin elements
-			err = addSourceAttributesForOverrideAndMerge(plugin.ImportReference, pluginWorkspaceContent)
+			if pluginDevfileVerson.GreaterThan(mainDevfileVersion) {
+				return &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("the plugin devfile version from %v is greater than the child devfile version from %v", resolveImportReference(component.Plugin.ImportReference), resolveImportReference(resolveCtx.importReference))}
+			}
+		}
+		d.Provenance.addImport(ImportKindPlugin, pluginDevfileObj.Provenance, pluginOverrideKeys(plugin.PluginOverrides), importedElements)
+		pluginWorkspaceContent := pluginDevfileObj.Data.GetDevfileWorkspaceSpecContent()
+		// add attribute to plugin elements
+		err = addSourceAttributesForOverrideAndMerge(importSourceReference(plugin.ImportReference, pluginDevfileObj.resolvedReference), pluginWorkspaceContent)
+		if err != nil {
+			return err
+		}
+		flattenedPlugin := pluginWorkspaceContent
+		if !reflect.DeepEqual(plugin.PluginOverrides, v1.PluginOverrides{}) {
+			// add attribute to pluginOverrides elements
+			curNodeImportReference := resolveCtx.importReference
+			err = addSourceAttributesForOverrideAndMerge(resolveImportReference(curNodeImportReference), &plugin.PluginOverrides)
 			if err != nil {
 				return err
 			}
-			flattenedPlugin := pluginWorkspaceContent
-			if !reflect.DeepEqual(plugin.PluginOverrides, v1.PluginOverrides{}) {
-				// add attribute to pluginOverrides elements
-				curNodeImportReference := resolveCtx.importReference
-				err = addSourceAttributesForOverrideAndMerge(curNodeImportReference, &plugin.PluginOverrides)
-				if err != nil {
-					return err
-				}
-				flattenedPlugin, err = apiOverride.OverrideDevWorkspaceTemplateSpec(pluginWorkspaceContent, plugin.PluginOverrides)
-				if err != nil {
-					return err
-				}
+			flattenedPlugin, err = apiOverride.OverrideDevWorkspaceTemplateSpec(pluginWorkspaceContent, plugin.PluginOverrides)
+			if err != nil {
+				return locateConflicts(err, d.Ctx, "/components/*/plugin")
 			}
-			flattenedPlugins = append(flattenedPlugins, flattenedPlugin)
 		}
+		flattenedPlugins = append(flattenedPlugins, flattenedPlugin)
 	}
 
 	mergedContent, err := apiOverride.MergeDevWorkspaceTemplateSpec(d.Data.GetDevfileWorkspaceSpecContent(), flattenedParent, flattenedPlugins...)
 	if err != nil {
-		return err
+		return locateConflicts(err, d.Ctx, "")
 	}
 	d.Data.SetDevfileWorkspaceSpecContent(*mergedContent)
+	d.Provenance.setElements(mergedContent, importedElements)
 	// remove parent from flatterned devfile
 	d.Data.SetParent(nil)
 
 	return nil
 }
 
+// parseFromURI resolves a uri import reference with the default URIResolver and parses the devfile it refers to
 func parseFromURI(importReference v1.ImportReference, curDevfileCtx devfileCtx.DevfileCtx, resolveCtx *resolutionContextTree, tool resolverTools) (DevfileObj, error) {
-	uri := importReference.Uri
-	// validate URI
-	err := validation.ValidateURI(uri)
-	if err != nil {
-		return DevfileObj{}, err
-	}
-	// NewDevfileCtx
-	var d DevfileObj
-	absoluteURL := strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://")
-	var newUri string
-
-	// relative path on disk
-	if !absoluteURL && curDevfileCtx.GetAbsPath() != "" {
-		newUri = path.Join(path.Dir(curDevfileCtx.GetAbsPath()), uri)
-		d.Ctx = devfileCtx.NewDevfileCtx(newUri)
-		if util.ValidateFile(newUri) != nil {
-			return DevfileObj{}, &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("the provided path is not a valid filepath %s", newUri)}
-		}
-		srcDir := path.Dir(newUri)
-		destDir := path.Dir(curDevfileCtx.GetAbsPath())
-		if srcDir != destDir {
-			err := util.CopyAllDirFiles(srcDir, destDir)
-			if err != nil {
-				return DevfileObj{}, err
-			}
-		}
-	} else {
-		if absoluteURL {
-			// absolute URL address
-			newUri = uri
-		} else if curDevfileCtx.GetURL() != "" {
-			// relative path to a URL
-			u, err := url.Parse(curDevfileCtx.GetURL())
-			if err != nil {
-				return DevfileObj{}, err
-			}
-			u.Path = path.Join(u.Path, uri)
-			newUri = u.String()
-		} else {
-			return DevfileObj{}, fmt.Errorf("failed to resolve parent uri, devfile context is missing absolute url and path to devfile. %s", resolveImportReference(importReference))
-		}
-
-		token := curDevfileCtx.GetToken()
-		d.Ctx = devfileCtx.NewURLDevfileCtx(newUri)
-		if token != "" {
-			d.Ctx.SetToken(token)
-		}
-
-		if tool.downloadGitResources {
-			destDir := path.Dir(curDevfileCtx.GetAbsPath())
-			err = tool.devfileUtilsClient.DownloadGitRepoResources(newUri, destDir, token)
-			if err != nil {
-				return DevfileObj{}, err
-			}
-		}
-	}
-	importReference.Uri = newUri
-	newResolveCtx := resolveCtx.appendNode(importReference)
-
-	return populateAndParseDevfile(d, newResolveCtx, tool, true)
+	return parseFromResolver(URIResolver{}, importReference, curDevfileCtx, resolveCtx, tool)
 }
 
-func parseFromRegistry(importReference v1.ImportReference, resolveCtx *resolutionContextTree, tool resolverTools) (d DevfileObj, err error) {
-	id := importReference.Id
-	registryURL := importReference.RegistryUrl
-	destDir := path.Dir(d.Ctx.GetAbsPath())
-
-	if registryURL != "" {
-		devfileContent, err := getDevfileFromRegistry(id, registryURL, importReference.Version, tool.httpTimeout)
-		if err != nil {
-			return DevfileObj{}, err
-		}
-		d.Ctx, err = devfileCtx.NewByteContentDevfileCtx(devfileContent)
-		if err != nil {
-			return d, err
-		}
-		newResolveCtx := resolveCtx.appendNode(importReference)
-
-		err = getResourcesFromRegistry(id, registryURL, destDir)
-		if err != nil {
-			return DevfileObj{}, err
-		}
-
-		return populateAndParseDevfile(d, newResolveCtx, tool, true)
-
-	} else if tool.registryURLs != nil {
-		for _, registryURL := range tool.registryURLs {
-			devfileContent, err := getDevfileFromRegistry(id, registryURL, importReference.Version, tool.httpTimeout)
-			if devfileContent != nil && err == nil {
-				d.Ctx, err = devfileCtx.NewByteContentDevfileCtx(devfileContent)
-				if err != nil {
-					return d, errors.Wrap(err, "failed to set devfile content from bytes")
-				}
-				importReference.RegistryUrl = registryURL
-				newResolveCtx := resolveCtx.appendNode(importReference)
-
-				err := getResourcesFromRegistry(id, registryURL, destDir)
-				if err != nil {
-					return DevfileObj{}, err
-				}
-
-				return populateAndParseDevfile(d, newResolveCtx, tool, true)
-			}
-		}
-	} else {
-		return DevfileObj{}, &errPkg.NonCompliantDevfile{Err: "failed to fetch from registry, registry URL is not provided"}
-	}
-
-	return DevfileObj{}, fmt.Errorf("failed to get id: %s from registry URLs provided", id)
+// parseFromRegistry resolves a registry import reference with the default RegistryResolver and parses the devfile it refers to
+func parseFromRegistry(importReference v1.ImportReference, resolveCtx *resolutionContextTree, tool resolverTools) (DevfileObj, error) {
+	return parseFromResolver(RegistryResolver{}, importReference, devfileCtx.DevfileCtx{}, resolveCtx, tool)
 }
 
-func getDevfileFromRegistry(id, registryURL, version string, httpTimeout *int) ([]byte, error) {
+func getDevfileFromRegistry(ctx context.Context, id, registryURL, version string, httpTimeout *int, credentials util.CredentialProvider, v *vault) ([]byte, error) {
 	if !strings.HasPrefix(registryURL, "http://") && !strings.HasPrefix(registryURL, "https://") {
 		return nil, &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("the provided registryURL: %s is not a valid URL", registryURL)}
 	}
 	param := util.HTTPRequestParams{
-		URL: fmt.Sprintf("%s/devfiles/%s/%s", registryURL, id, version),
+		URL:         fmt.Sprintf("%s/devfiles/%s/%s", registryURL, id, version),
+		Context:     ctx,
+		Credentials: credentials,
 	}
 
 	param.Timeout = httpTimeout
 	//suppress telemetry for parent uri references
 	param.TelemetryClientName = util.TelemetryIndirectDevfileCall
-	return util.HTTPGetRequest(param, 0)
+	return v.content("url "+param.URL, param.URL, func() ([]byte, error) {
+		return util.HTTPGetRequest(param, 0)
+	})
 }
 
-func getResourcesFromRegistry(id, registryURL, destDir string) error {
+func getResourcesFromRegistry(ctx context.Context, id, registryURL, version, destDir string, credentials util.CredentialProvider, v *vault) error {
+	// the registry library cannot cancel a pull once it has started, so the context is only checked beforehand
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	registryCredentials, err := util.CredentialsForURL(credentials, registryURL)
+	if err != nil {
+		return err
+	}
 	stackDir, err := os.MkdirTemp(os.TempDir(), fmt.Sprintf("registry-resources-%s", id))
 	if err != nil {
 		return fmt.Errorf("failed to create dir: %s, error: %v", stackDir, err)
 	}
 	defer os.RemoveAll(stackDir)
-	//suppress telemetry for downloading resources from parent reference
-	err = registryLibrary.PullStackFromRegistry(registryURL, id, stackDir, registryLibrary.RegistryOptions{Telemetry: registryLibrary.TelemetryData{Client: util.TelemetryIndirectDevfileCall}})
+	stack := id
+	options := registryLibrary.RegistryOptions{Telemetry: registryLibrary.TelemetryData{Client: util.TelemetryIndirectDevfileCall}}
+	if version != "" {
+		// versions are only listed by the index with the new schema
+		stack = fmt.Sprintf("%s:%s", id, version)
+		options.NewIndexSchema = true
+	}
+	reference := fmt.Sprintf("resources of %s from registry %s", stack, registryURL)
+	err = v.directory("registry "+registryURL+" "+stack, reference, stackDir, func(dir string) error {
+		// the registry library does not authenticate its requests, so the stack is pulled with the registry client when
+		// the registry has credentials
+		if registryCredentials != nil {
+			client := registry.NewClient(registry.Registry{URL: registryURL, Auth: &registry.Auth{
+				Token:    registryCredentials.Token,
+				Username: registryCredentials.Username,
+				Password: registryCredentials.Password,
+			}})
+			if _, err := client.PullStack(ctx, id, version, dir); err != nil {
+				return errors.Wrapf(err, "failed to pull stack from registry %s", registryURL)
+			}
+			return nil
+		}
+		//suppress telemetry for downloading resources from parent reference
+		err := registryLibrary.PullStackFromRegistry(registryURL, stack, dir, options)
+		if err != nil {
+			return fmt.Errorf("failed to pull stack from registry %s", registryURL)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to pull stack from registry %s", registryURL)
+		return err
 	}
 
 	err = util.CopyAllDirFiles(stackDir, destDir)
@@ -591,50 +808,120 @@ func getResourcesFromRegistry(id, registryURL, destDir string) error {
 	return nil
 }
 
-func parseFromKubeCRD(importReference v1.ImportReference, resolveCtx *resolutionContextTree, tool resolverTools) (d DevfileObj, err error) {
+// parseFromKubeCRD resolves a Kubernetes import reference with the default KubernetesResolver and parses the devfile it refers to
+func parseFromKubeCRD(importReference v1.ImportReference, resolveCtx *resolutionContextTree, tool resolverTools) (DevfileObj, error) {
+	return parseFromResolver(KubernetesResolver{}, importReference, devfileCtx.DevfileCtx{}, resolveCtx, tool)
+}
 
-	if tool.k8sClient == nil || tool.context == nil {
-		return DevfileObj{}, fmt.Errorf("kubernetes client and context are required to parse from Kubernetes CRD")
+// parseFromResolver resolves the import reference with resolver, then parses the devfile it refers to,
+// recursively resolving its own parent and plugins
+func parseFromResolver(resolver Resolver, importReference v1.ImportReference, curDevfileCtx devfileCtx.DevfileCtx, resolveCtx *resolutionContextTree, tool resolverTools) (d DevfileObj, err error) {
+	defer func(recordedDeprecations int, recordedWarnings int) {
+		setIssuesImportReference(err, importReference)
+		tool.strict.setImportReference(recordedDeprecations, importReference)
+		tool.warnings.setImportReference(recordedWarnings, importReference)
+	}(tool.strict.recorded(), tool.warnings.recorded())
+	key := tool.cache.importKey(resolver, importReference, curDevfileCtx, tool)
+	if key == "" {
+		return resolveAndParse(resolver, importReference, curDevfileCtx, resolveCtx, tool)
 	}
-	namespace := importReference.Kubernetes.Namespace
-
-	if namespace == "" {
-		// if namespace is not set in devfile, use default namespace provided in by consumer
-		if tool.defaultNamespace != "" {
-			namespace = tool.defaultNamespace
-		} else {
-			// use current namespace if namespace is not set in devfile and not provided by consumer
-			loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-			configOverrides := &clientcmd.ConfigOverrides{}
-			config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-			namespace, _, err = config.Namespace()
-			if err != nil {
-				return DevfileObj{}, fmt.Errorf("kubernetes namespace is not provided, and cannot get current running cluster's namespace: %v", err)
-			}
-		}
+	if cached, ok := tool.cache.imported(key, resolveImportReference(resolveCtx.importReference), tool.sources); ok {
+		return cached, nil
 	}
-
-	var dwTemplate v1.DevWorkspaceTemplate
-	namespacedName := types.NamespacedName{
-		Name:      importReference.Kubernetes.Name,
-		Namespace: namespace,
+	// the devfiles imported are recorded apart, to be recorded again when reusing the cached devfile
+	forked := tool.fork()
+	d, err = resolveAndParse(resolver, importReference, curDevfileCtx, resolveCtx, forked)
+	tool.join(forked)
+	if err == nil {
+		tool.cache.storeImported(key, d, forked.sources)
 	}
-	err = tool.k8sClient.Get(tool.context, namespacedName, &dwTemplate)
+	return d, err
+}
+
+// resolveAndParse resolves importReference with resolver and parses the devfile it refers to
+func resolveAndParse(resolver Resolver, importReference v1.ImportReference, curDevfileCtx devfileCtx.DevfileCtx, resolveCtx *resolutionContextTree, tool resolverTools) (d DevfileObj, err error) {
+	start := time.Now()
+	importedFrom := resolveImportReference(resolveCtx.importReference)
+	// resolvers may modify the reference through its pointers, e.g. default the namespace of a Kubernetes reference, so
+	// a copy is resolved and the reference is kept as authored for the lockfile
+	pinnedReference, lockEntry, err := tool.lock.pin(*importReference.DeepCopy(), importedFrom)
 	if err != nil {
 		return DevfileObj{}, err
 	}
-
-	d, err = convertDevWorskapceTemplateToDevObj(dwTemplate)
+	resolved, err := resolver.Resolve(tool.resolverArgs(curDevfileCtx), pinnedReference)
 	if err != nil {
 		return DevfileObj{}, err
 	}
-
-	importReference.Kubernetes.Namespace = namespace
-	newResolveCtx := resolveCtx.appendNode(importReference)
-
-	err = parseParentAndPlugin(d, newResolveCtx, tool)
+	resolved.ImportReference = tool.lock.unpin(resolved.ImportReference, importReference, lockEntry)
+	newResolveCtx := resolveCtx.appendNode(resolved.ImportReference)
+
+	d = resolved.Devfile
+	d.Provenance = nil
+	d.resolvedReference = resolved.ImportReference
+	if tool.recordProvenance {
+		d.Provenance = newProvenance("", importReference, resolved.ImportReference)
+		d.Provenance.Root.CacheHit = tool.vault.servedDevfile(resolver, d)
+	}
+	if d.Data == nil {
+		d, err = populateDevfile(d, newResolveCtx, tool)
+		if err != nil {
+			return d, err
+		}
+		if d.Provenance != nil {
+			d.Provenance.Root.FetchDuration = time.Since(start)
+			d.Provenance.setLocation(d)
+		}
+		tool.sources.add(importSourceReference(importReference, resolved.ImportReference), resolved.ImportReference, importedFrom, d.Ctx)
+		err = tool.lock.record(tool, importReference, importedFrom, resolved.ImportReference, d, lockEntry, d.Ctx.GetToken())
+		if err != nil {
+			return d, err
+		}
+		return parseDevfile(d, newResolveCtx, tool, true)
+	}
+	if err = newResolveCtx.hasCycle(); err != nil {
+		return DevfileObj{}, &errPkg.NonCompliantDevfile{Err: err.Error()}
+	}
+	if d.Provenance != nil {
+		d.Provenance.Root.FetchDuration = time.Since(start)
+		d.Provenance.setLocation(d)
+	}
+	tool.sources.add(importSourceReference(importReference, resolved.ImportReference), resolved.ImportReference, importedFrom, d.Ctx)
+	err = tool.lock.record(tool, importReference, importedFrom, resolved.ImportReference, d, lockEntry, d.Ctx.GetToken())
+	if err != nil {
+		return d, err
+	}
+	err = parseParentAndPlugin(&d, newResolveCtx, tool)
 	return d, err
+}
 
+// getResolver returns the first resolver, from the ones passed in by the consumer then the default ones, that can resolve
+// the import reference. Nil is returned if none can.
+func (tool resolverTools) getResolver(importReference v1.ImportReference) Resolver {
+	for _, resolvers := range [][]Resolver{tool.resolvers, DefaultResolvers()} {
+		for _, resolver := range resolvers {
+			if resolver.CanResolve(importReference) {
+				return resolver
+			}
+		}
+	}
+	return nil
+}
+
+// resolverArgs returns the arguments passed to resolvers for resolving an import reference of the devfile with context curDevfileCtx
+func (tool resolverTools) resolverArgs(curDevfileCtx devfileCtx.DevfileCtx) ResolverArgs {
+	return ResolverArgs{
+		DevfileCtx:           curDevfileCtx,
+		Context:              tool.context,
+		RegistryURLs:         tool.registryURLs,
+		DefaultNamespace:     tool.defaultNamespace,
+		K8sClient:            tool.k8sClient,
+		HTTPTimeout:          tool.httpTimeout,
+		DownloadGitResources: tool.downloadGitResources,
+		DevfileUtilsClient:   tool.getDevfileUtilsClient(),
+		Credentials:          tool.credentials,
+		vault:                tool.vault,
+		stage:                tool.stage,
+	}
 }
 
 func convertDevWorskapceTemplateToDevObj(dwTemplate v1.DevWorkspaceTemplate) (d DevfileObj, err error) {
@@ -652,7 +939,8 @@ func convertDevWorskapceTemplateToDevObj(dwTemplate v1.DevWorkspaceTemplate) (d
 
 }
 
-// setDefaults sets the default values for nil boolean properties after the merging of devWorkspaceTemplateSpec is complete
+// setDefaults sets the default values for nil boolean properties after the merging of devWorkspaceTemplateSpec is complete.
+// The fields that are set are recorded in d.defaulted, to be left out of the minimal encoding of the devfile.
 func setDefaults(d DevfileObj) (err error) {
 
 	var devfileVersion string
@@ -667,31 +955,10 @@ func setDefaults(d DevfileObj) (err error) {
 	}
 
 	//set defaults on the commands
-	var cmdGroup *v1.CommandGroup
 	for i := range commands {
-		command := commands[i]
-		cmdGroup = nil
-
-		if command.Exec != nil {
-			exec := command.Exec
-			val := exec.GetHotReloadCapable()
-			exec.HotReloadCapable = &val
-			cmdGroup = exec.Group
-
-		} else if command.Composite != nil {
-			composite := command.Composite
-			val := composite.GetParallel()
-			composite.Parallel = &val
-			cmdGroup = composite.Group
-
-		} else if command.Apply != nil {
-			cmdGroup = command.Apply.Group
+		for _, field := range commandBooleans(&commands[i]) {
+			d.defaulted.setDefault(commandElement(commands[i]), field)
 		}
-
-		if cmdGroup != nil {
-			setIsDefault(cmdGroup)
-		}
-
 	}
 
 	//set defaults on the components
@@ -702,73 +969,18 @@ func setDefaults(d DevfileObj) (err error) {
 		return err
 	}
 
-	var endpoints []v1.Endpoint
 	for i := range components {
-		component := components[i]
-		endpoints = nil
-
-		if component.Container != nil {
-			container := component.Container
-			val := container.GetDedicatedPod()
-			container.DedicatedPod = &val
-
-			msVal := container.GetMountSources()
-			container.MountSources = &msVal
-
-			endpoints = container.Endpoints
-
-		} else if component.Kubernetes != nil {
-			endpoints = component.Kubernetes.Endpoints
-			if devfileVersion != string(data.APISchemaVersion200) && devfileVersion != string(data.APISchemaVersion210) {
-				val := component.Kubernetes.GetDeployByDefault()
-				component.Kubernetes.DeployByDefault = &val
-			}
-		} else if component.Openshift != nil {
-			endpoints = component.Openshift.Endpoints
-			if devfileVersion != string(data.APISchemaVersion200) && devfileVersion != string(data.APISchemaVersion210) {
-				val := component.Openshift.GetDeployByDefault()
-				component.Openshift.DeployByDefault = &val
-			}
-
-		} else if component.Volume != nil && devfileVersion != string(data.APISchemaVersion200) {
-			volume := component.Volume
-			val := volume.GetEphemeral()
-			volume.Ephemeral = &val
-
-		} else if component.Image != nil { //we don't need to do a schema version check since Image in v2.2.0.  If used in older specs, a parser error would occur
-			dockerImage := component.Image.Dockerfile
-			if dockerImage != nil {
-				val := dockerImage.GetRootRequired()
-				dockerImage.RootRequired = &val
-			}
-			val := component.Image.GetAutoBuild()
-			component.Image.AutoBuild = &val
-		}
-
-		if endpoints != nil {
-			setEndpoints(endpoints)
+		for _, field := range componentBooleans(&components[i], devfileVersion) {
+			d.defaulted.setDefault(componentElement(components[i]), field)
 		}
 	}
 
 	return nil
 }
 
-// setIsDefault sets the default value of CommandGroup.IsDefault if nil
-func setIsDefault(cmdGroup *v1.CommandGroup) {
-	val := cmdGroup.GetIsDefault()
-	cmdGroup.IsDefault = &val
-}
-
-// setEndpoints sets the default value of Endpoint.Secure if nil
-func setEndpoints(endpoints []v1.Endpoint) {
-	for i := range endpoints {
-		val := endpoints[i].GetSecure()
-		endpoints[i].Secure = &val
-	}
-}
-
-// parseKubeResourceFromURI iterate through all kubernetes & openshift components, and parse from uri and update the content to inlined field in devfileObj
-func parseKubeResourceFromURI(devObj DevfileObj, devfileUtilsClient parserUtil.DevfileUtils) error {
+// parseKubeResourceFromURI iterate through all kubernetes & openshift components, and parse from uri and update the content to inlined field in devfileObj.
+// At most concurrency uris are fetched at a time, the components are updated in order.
+func parseKubeResourceFromURI(devObj DevfileObj, devfileUtilsClient parserUtil.DevfileUtils, concurrency int) error {
 	getKubeCompOptions := common.DevfileOptions{
 		ComponentOptions: common.ComponentOptions{
 			ComponentType: v1.KubernetesComponentType,
@@ -787,30 +999,30 @@ func parseKubeResourceFromURI(devObj DevfileObj, devfileUtilsClient parserUtil.D
 	if err != nil {
 		return err
 	}
+	var uriComponents []v1.Component
 	for _, kubeComp := range kubeComponents {
 		if kubeComp.Kubernetes != nil && kubeComp.Kubernetes.Uri != "" {
-			/* #nosec G601 -- not an issue, kubeComp is de-referenced in sequence*/
-			err := convertK8sLikeCompUriToInlined(&kubeComp, devObj.Ctx, devfileUtilsClient)
-			if err != nil {
-				return errors.Wrapf(err, "failed to convert kubernetes uri to inlined for component '%s'", kubeComp.Name)
-			}
-			err = devObj.Data.UpdateComponent(kubeComp)
-			if err != nil {
-				return err
-			}
+			uriComponents = append(uriComponents, kubeComp)
 		}
 	}
 	for _, openshiftComp := range openshiftComponents {
 		if openshiftComp.Openshift != nil && openshiftComp.Openshift.Uri != "" {
-			/* #nosec G601 -- not an issue, openshiftComp is de-referenced in sequence*/
-			err := convertK8sLikeCompUriToInlined(&openshiftComp, devObj.Ctx, devfileUtilsClient)
-			if err != nil {
-				return errors.Wrapf(err, "failed to convert openshift uri to inlined for component '%s'", openshiftComp.Name)
-			}
-			err = devObj.Data.UpdateComponent(openshiftComp)
-			if err != nil {
-				return err
+			uriComponents = append(uriComponents, openshiftComp)
+		}
+	}
+	errs := runInOrder(len(uriComponents), concurrency, func(i int) error {
+		return convertK8sLikeCompUriToInlined(&uriComponents[i], devObj.Ctx, devfileUtilsClient)
+	})
+	for i, component := range uriComponents {
+		if errs[i] != nil {
+			if component.Kubernetes != nil {
+				return errors.Wrapf(errs[i], "failed to convert kubernetes uri to inlined for component '%s'", component.Name)
 			}
+			return errors.Wrapf(errs[i], "failed to convert openshift uri to inlined for component '%s'", component.Name)
+		}
+		err = devObj.Data.UpdateComponent(component)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -875,11 +1087,12 @@ func getKubernetesDefinitionFromUri(uri string, d devfileCtx.DevfileCtx, devfile
 			// absolute URL address
 			newUri = uri
 		}
-		params := util.HTTPRequestParams{URL: newUri}
-		if d.GetToken() != "" {
-			params.Token = d.GetToken()
+		// the token of the devfile is only sent to its own host, the client authenticates the other hosts
+		token, err := inheritedToken(nil, d, newUri)
+		if err != nil {
+			return nil, err
 		}
-		data, err = devfileUtilsClient.DownloadInMemory(params)
+		data, err = devfileUtilsClient.DownloadInMemory(util.HTTPRequestParams{URL: newUri, Token: token})
 		if err != nil {
 			return nil, errors.Wrapf(err, "error getting kubernetes resources definition information")
 		}
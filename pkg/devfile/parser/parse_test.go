@@ -18,6 +18,7 @@ package parser
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -30,6 +31,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/devfile/library/v2/pkg/util"
 
@@ -40,6 +42,7 @@ import (
 	"github.com/devfile/library/v2/pkg/devfile/parser/data"
 	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
 	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
 	parserUtil "github.com/devfile/library/v2/pkg/devfile/parser/util"
 	"github.com/devfile/library/v2/pkg/testingutil"
 	"github.com/kylelemons/godebug/pretty"
@@ -2864,7 +2867,7 @@ func Test_parseParentAndPluginFromURI(t *testing.T) {
 				tt.args.devFileObj.Data.AddComponents(plugincomp)
 
 			}
-			err := parseParentAndPlugin(tt.args.devFileObj, &resolutionContextTree{}, resolverTools{devfileUtilsClient: parserUtil.NewDevfileUtilsClient()})
+			err := parseParentAndPlugin(&tt.args.devFileObj, &resolutionContextTree{}, resolverTools{devfileUtilsClient: parserUtil.NewDevfileUtilsClient()})
 
 			// Unexpected error
 			if (err != nil) != (tt.wantErr != nil) {
@@ -3098,7 +3101,7 @@ func Test_parseParentAndPlugin_RecursivelyReference(t *testing.T) {
 			devfileUtilsClient: parserUtil.NewDevfileUtilsClient(),
 		}
 
-		err := parseParentAndPlugin(devFileObj, &resolutionContextTree{}, tool)
+		err := parseParentAndPlugin(&devFileObj, &resolutionContextTree{}, tool)
 		// devfile has a cycle in references: main devfile -> uri: http://127.0.0.1:8080 -> name: testcrd, namespace: defaultnamespace -> uri: http://127.0.0.1:8090 -> uri: http://127.0.0.1:8080
 		expectedErr := fmt.Sprintf("devfile has an cycle in references: main devfile -> uri: %s%s -> name: %s, namespace: %s -> uri: %s%s -> uri: %s%s", httpPrefix, uri1, name, namespace,
 			httpPrefix, uri2, httpPrefix, uri1)
@@ -3240,7 +3243,7 @@ func Test_parseParentFromRegistry(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			err := parseParentAndPlugin(tt.mainDevfile, &resolutionContextTree{}, tool)
+			err := parseParentAndPlugin(&tt.mainDevfile, &resolutionContextTree{}, tool)
 
 			// Unexpected error
 			if (err != nil) != (tt.wantErr != nil) {
@@ -4028,7 +4031,7 @@ func Test_parseParentFromKubeCRD(t *testing.T) {
 				k8sClient: testK8sClient,
 				context:   context.Background(),
 			}
-			err := parseParentAndPlugin(tt.mainDevfile, &resolutionContextTree{}, tool)
+			err := parseParentAndPlugin(&tt.mainDevfile, &resolutionContextTree{}, tool)
 			// Unexpected error
 			if (err != nil) != (tt.wantErr != nil) {
 				t.Errorf("Test_parseParentFromKubeCRD() unexpected error: %v, wantErr %v", err, tt.wantErr)
@@ -5165,6 +5168,71 @@ func Test_setDefaults(t *testing.T) {
 	}
 }
 
+func Test_setDefaults_MountSources(t *testing.T) {
+	tests := []struct {
+		name             string
+		dedicatedPod     *bool
+		mountSources     *bool
+		wantDedicatedPod bool
+		wantMountSources bool
+	}{
+		{
+			name:             "sources are mounted by default",
+			wantMountSources: true,
+		},
+		{
+			name:             "sources are not mounted by default in a dedicated pod",
+			dedicatedPod:     &isTrue,
+			wantDedicatedPod: true,
+			wantMountSources: false,
+		},
+		{
+			name:             "sources are mounted by default in a pod that is not dedicated",
+			dedicatedPod:     &isFalse,
+			wantMountSources: true,
+		},
+		{
+			name:             "sources mounted in a dedicated pod",
+			dedicatedPod:     &isTrue,
+			mountSources:     &isTrue,
+			wantDedicatedPod: true,
+			wantMountSources: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devfileData, err := data.NewDevfileData(string(data.APISchemaVersion220))
+			if err != nil {
+				t.Fatalf("Test_setDefaults_MountSources() unexpected error: %v", err)
+			}
+			err = devfileData.AddComponents([]v1.Component{{
+				Name: "runtime",
+				ComponentUnion: v1.ComponentUnion{
+					Container: &v1.ContainerComponent{
+						Container: v1.Container{Image: "quay.io/nodejs-12", DedicatedPod: tt.dedicatedPod, MountSources: tt.mountSources},
+					},
+				},
+			}})
+			if err != nil {
+				t.Fatalf("Test_setDefaults_MountSources() unexpected error: %v", err)
+			}
+
+			if err := setDefaults(DevfileObj{Data: devfileData}); err != nil {
+				t.Fatalf("Test_setDefaults_MountSources() unexpected error setting defaults: %v", err)
+			}
+			components, err := devfileData.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("Test_setDefaults_MountSources() unexpected error: %v", err)
+			}
+			container := components[0].Container
+			if assert.NotNil(t, container.DedicatedPod) && assert.NotNil(t, container.MountSources) {
+				assert.Equal(t, tt.wantDedicatedPod, *container.DedicatedPod, "Test_setDefaults_MountSources(): dedicatedPod should match")
+				assert.Equal(t, tt.wantMountSources, *container.MountSources, "Test_setDefaults_MountSources(): mountSources should match")
+			}
+		})
+	}
+}
+
 func Test_getKubernetesDefinitionFromUri(t *testing.T) {
 	const (
 		uri1                = "127.0.0.1:8080"
@@ -5366,6 +5434,90 @@ commands:
 	}
 }
 
+func Test_ParseDevfile_Cancellation(t *testing.T) {
+	// the test server only responds once the request has been cancelled
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer testServer.Close()
+
+	parentURIDevfile := fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: cancel-test
+parent:
+  uri: %s/parent/devfile.yaml
+`, testServer.URL)
+
+	kubeURIDevfile := fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: cancel-test
+components:
+- name: deploy
+  kubernetes:
+    uri: %s/deploy.yaml
+`, testServer.URL)
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		data      string
+		ctx       func() (context.Context, context.CancelFunc)
+		wantErr   error
+		wantInRef string
+	}{
+		{
+			name: "should return a cancellation error if the context is cancelled before parsing",
+			data: parentURIDevfile,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return cancelledCtx, func() {}
+			},
+			wantErr:   context.Canceled,
+			wantInRef: "main devfile",
+		},
+		{
+			name: "should return a cancellation error if the deadline is exceeded while fetching the parent",
+			data: parentURIDevfile,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 200*time.Millisecond)
+			},
+			wantErr:   context.DeadlineExceeded,
+			wantInRef: testServer.URL + "/parent/devfile.yaml",
+		},
+		{
+			name: "should return a cancellation error if the deadline is exceeded while fetching a kubernetes uri",
+			data: kubeURIDevfile,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 200*time.Millisecond)
+			},
+			wantErr:   context.DeadlineExceeded,
+			wantInRef: "main devfile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			start := time.Now()
+			_, err := ParseDevfile(ParserArgs{
+				Data:                 []byte(tt.data),
+				Context:              ctx,
+				DownloadGitResources: &isFalse,
+			})
+			if time.Since(start) > 10*time.Second {
+				t.Errorf("Test_ParseDevfile_Cancellation() parsing was not aborted in time")
+			}
+			var cancelledErr *errPkg.ParseCancelled
+			if !errors.As(err, &cancelledErr) {
+				t.Fatalf("Test_ParseDevfile_Cancellation() expected a ParseCancelled error, got: %v", err)
+			}
+			assert.True(t, errors.Is(err, tt.wantErr), "Test_ParseDevfile_Cancellation(): error should wrap %v, got: %v", tt.wantErr, err)
+			assert.Contains(t, cancelledErr.Reference, tt.wantInRef, "Test_ParseDevfile_Cancellation(): error should name the reference being resolved")
+		})
+	}
+}
+
 // getUnsetBooleanDevfileObj returns a DevfileData object that contains unset boolean properties
 func getUnsetBooleanDevfileTestData(apiVersion string) (devfileData data.DevfileData, err error) {
 	devfileData = &v2.DevfileV2{
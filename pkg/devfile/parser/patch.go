@@ -0,0 +1,394 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog"
+)
+
+// defaultYAMLIndent is the indentation of the patched devfiles whose indentation cannot be detected
+const defaultYAMLIndent = 2
+
+// patchedContent returns the original content of the devfile with the changes made to its data since it was set as
+// unchanged, data being the content to write. Comments, key ordering, anchors and the fields set to their default values
+// by the parser are kept. The changes made to the data of a flattened devfile are made to the devfile as it was authored,
+// see Unflatten, the changes made to the elements of its parent being written as parent overrides. It returns nil if the
+// devfile has no original YAML content or unchanged data to patch, or if the changes cannot be unflattened.
+func (d *DevfileObj) patchedContent(data interface{}) ([]byte, error) {
+	original := d.Ctx.GetOriginalContent()
+	// JSON devfiles are written as YAML, as they have no comments to keep
+	trimmed := bytes.TrimLeftFunc(original, unicode.IsSpace)
+	if len(d.unchanged) == 0 || len(trimmed) == 0 || trimmed[0] == '{' {
+		return nil, nil
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(original, &document); err != nil {
+		return nil, err
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) != 1 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	base, err := decodeJSONValue(d.unchanged)
+	if err != nil {
+		return nil, err
+	}
+	// the sensitive values are compared as placeholders, as they are written
+	if baseMap, ok := base.(map[string]interface{}); ok {
+		d.restorePlaceholders(baseMap)
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := decodeJSONValue(content)
+	if err != nil {
+		return nil, err
+	}
+	if d.flattened {
+		if base, err = d.unflattenedValue(base); err == nil {
+			desired, err = d.unflattenedValue(desired)
+		}
+		if err != nil {
+			klog.V(4).Infof("writing the flattened content of the devfile, the changes cannot be unflattened: %v", err)
+			return nil, nil
+		}
+	}
+	if err = patchNode(document.Content[0], base, desired); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(yamlIndent(original))
+	if err = encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err = encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// unflattenedValue returns the JSON value of the devfile as it was authored that reproduces the JSON value of its
+// flattened data once its parent is merged
+func (d *DevfileObj) unflattenedValue(value interface{}) (interface{}, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	flattened, err := data.NewDevfileData(d.Ctx.GetApiVersion())
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(content, &flattened); err != nil {
+		return nil, err
+	}
+	authored, err := data.NewDevfileData(d.Ctx.GetApiVersion())
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(d.Ctx.GetDevfileContent(), &authored); err != nil {
+		return nil, err
+	}
+	// the metadata and schema version are the ones of the data, the elements and the parent the authored ones
+	raw := copyDevfileData(flattened)
+	raw.SetDevfileWorkspaceSpecContent(*authored.GetDevfileWorkspaceSpecContent())
+	raw.SetParent(authored.GetParent())
+
+	unflattened, err := Unflatten(raw, d.parentContent, *flattened.GetDevfileWorkspaceSpecContent())
+	if err != nil {
+		return nil, err
+	}
+	if content, err = json.Marshal(unflattened); err != nil {
+		return nil, err
+	}
+	return decodeJSONValue(content)
+}
+
+// patchNode patches the YAML node, whose value was base, to have the desired value, only touching the differing fields.
+// Mappings are patched key by key, and the lists of named items, e.g. components or environment variables, item by item.
+func patchNode(node *yaml.Node, base interface{}, desired interface{}) error {
+	if reflect.DeepEqual(base, desired) {
+		return nil
+	}
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		// the other uses of the anchor are left as they are
+		*node = *copyNode(node.Alias)
+		node.Anchor = ""
+	}
+
+	baseMap, baseIsMap := base.(map[string]interface{})
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+	if baseIsMap && desiredIsMap && node.Kind == yaml.MappingNode {
+		return patchMapping(node, baseMap, desiredMap)
+	}
+
+	baseList, baseIsList := base.([]interface{})
+	desiredList, desiredIsList := desired.([]interface{})
+	if baseIsList && desiredIsList && node.Kind == yaml.SequenceNode {
+		baseItems, baseNamed := namedValues(baseList)
+		desiredItems, desiredNamed := namedValues(desiredList)
+		if baseNamed && desiredNamed {
+			return patchSequence(node, baseItems, desiredList, desiredItems)
+		}
+	}
+	return replaceNode(node, desired)
+}
+
+// patchMapping patches the keys of a YAML mapping node
+func patchMapping(node *yaml.Node, base map[string]interface{}, desired map[string]interface{}) error {
+	var keys []string
+	for key := range base {
+		keys = append(keys, key)
+	}
+	for key := range desired {
+		if _, ok := base[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		baseValue, inBase := base[key]
+		desiredValue, inDesired := desired[key]
+		if inBase && inDesired && reflect.DeepEqual(baseValue, desiredValue) {
+			continue
+		}
+		valueNode := mappingValue(node, key)
+		switch {
+		case !inDesired:
+			deleteMappingKey(node, key)
+		case valueNode == nil:
+			// the fields of the objects set by default that are not written are only written if they changed
+			_, baseIsMap := baseValue.(map[string]interface{})
+			_, desiredIsMap := desiredValue.(map[string]interface{})
+			if inBase && baseIsMap && desiredIsMap {
+				valueNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				if err := patchNode(valueNode, baseValue, desiredValue); err != nil {
+					return err
+				}
+			} else {
+				var err error
+				if valueNode, err = encodeNode(desiredValue); err != nil {
+					return err
+				}
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, valueNode)
+		case !inBase:
+			if err := replaceNode(valueNode, desiredValue); err != nil {
+				return err
+			}
+		default:
+			if err := patchNode(valueNode, baseValue, desiredValue); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// patchSequence patches the items of a YAML sequence node of named items, identified by their name or id. New items
+// are appended.
+func patchSequence(node *yaml.Node, base map[string]interface{}, desiredList []interface{}, desired map[string]interface{}) error {
+	var content []*yaml.Node
+	for _, item := range node.Content {
+		_, inBase := base[nodeName(item)]
+		_, inDesired := desired[nodeName(item)]
+		if inBase && !inDesired {
+			continue
+		}
+		content = append(content, item)
+	}
+	node.Content = content
+
+	for _, desiredItem := range desiredList {
+		name := itemName(desiredItem)
+		baseItem, inBase := base[name]
+		if inBase && reflect.DeepEqual(baseItem, desiredItem) {
+			continue
+		}
+		var itemNode *yaml.Node
+		for _, item := range node.Content {
+			if nodeName(item) == name {
+				itemNode = item
+				break
+			}
+		}
+		var err error
+		switch {
+		case itemNode == nil:
+			if itemNode, err = encodeNode(desiredItem); err != nil {
+				return err
+			}
+			node.Content = append(node.Content, itemNode)
+		case !inBase:
+			err = replaceNode(itemNode, desiredItem)
+		default:
+			err = patchNode(itemNode, baseItem, desiredItem)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceNode replaces the value of the YAML node, keeping its comments and the quoting of strings
+func replaceNode(node *yaml.Node, value interface{}) error {
+	replacement, err := encodeNode(value)
+	if err != nil {
+		return err
+	}
+	replacement.HeadComment = node.HeadComment
+	replacement.LineComment = node.LineComment
+	replacement.FootComment = node.FootComment
+	if node.Kind == yaml.ScalarNode && replacement.Kind == yaml.ScalarNode && node.Tag == replacement.Tag && replacement.Style == 0 {
+		replacement.Style = node.Style
+	}
+	*node = *replacement
+	return nil
+}
+
+// encodeNode returns the YAML node of a JSON value
+func encodeNode(value interface{}) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(yamlValue(value)); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// yamlValue returns the JSON value with its numbers converted to integers or floats, to be encoded in YAML
+func yamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = yamlValue(item)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[key] = yamlValue(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// mappingValue returns the value node of the key of a YAML mapping node, nil if the key is not defined
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// deleteMappingKey deletes the key of a YAML mapping node
+func deleteMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// copyNode returns a deep copy of a YAML node
+func copyNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = copyNode(child)
+	}
+	return &copied
+}
+
+// nodeName returns the name, or the id, of a YAML mapping node
+func nodeName(node *yaml.Node) string {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	for _, key := range []string{"name", "id"} {
+		if value := mappingValue(node, key); value != nil && value.Kind == yaml.ScalarNode {
+			return value.Value
+		}
+	}
+	return ""
+}
+
+// namedValues returns the items of a JSON list of objects by their unique name or id, and false if the items are not
+// all named
+func namedValues(list []interface{}) (map[string]interface{}, bool) {
+	items := map[string]interface{}{}
+	for _, item := range list {
+		name := itemName(item)
+		if _, duplicate := items[name]; name == "" || duplicate {
+			return nil, false
+		}
+		items[name] = item
+	}
+	return items, true
+}
+
+// decodeJSONValue decodes JSON content, keeping its numbers as they are
+func decodeJSONValue(content []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	return value, err
+}
+
+// yamlIndent returns the indentation of YAML content, i.e. the smallest indentation of its lines
+func yamlIndent(content []byte) int {
+	indent := 0
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if spaces := len(line) - len(trimmed); spaces > 0 && (indent == 0 || spaces < indent) {
+			indent = spaces
+		}
+	}
+	if indent < 2 {
+		return defaultYAMLIndent
+	}
+	return indent
+}
@@ -0,0 +1,157 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevfileObj_WriteYamlDevfile_PatchesOriginalContent(t *testing.T) {
+	const devfile = `# the devfile of the main project
+schemaVersion: 2.2.0
+metadata:
+  name: main # the project name
+  version: 1.0.0
+components:
+  # the runtime container
+  - name: runtime
+    container:
+      image: &image quay.io/runtime:1.0
+      memoryLimit: 512Mi
+      env:
+        - name: DEBUG
+          value: "false"
+  - name: tools
+    container:
+      image: *image
+commands:
+  - id: run
+    exec:
+      component: runtime
+      commandLine: run
+`
+
+	tests := []struct {
+		name        string
+		update      func(d DevfileObj) error
+		wantDevfile string
+	}{
+		{
+			name:        "unchanged devfile",
+			update:      func(d DevfileObj) error { return nil },
+			wantDevfile: devfile,
+		},
+		{
+			name: "changed fields",
+			update: func(d DevfileObj) error {
+				if err := d.SetMetadataName("renamed"); err != nil {
+					return err
+				}
+				if err := d.SetMemory("1Gi"); err != nil {
+					return err
+				}
+				return d.AddEnvVars(map[string][]v1.EnvVar{"runtime": {{Name: "PORT", Value: "8080"}}})
+			},
+			wantDevfile: `# the devfile of the main project
+schemaVersion: 2.2.0
+metadata:
+  name: renamed # the project name
+  version: 1.0.0
+components:
+  # the runtime container
+  - name: runtime
+    container:
+      image: &image quay.io/runtime:1.0
+      memoryLimit: 1Gi
+      env:
+        - name: DEBUG
+          value: "false"
+        - name: PORT
+          value: "8080"
+  - name: tools
+    container:
+      image: *image
+      memoryLimit: 1Gi
+commands:
+  - id: run
+    exec:
+      component: runtime
+      commandLine: run
+`,
+		},
+		{
+			name: "changed anchor use and removed command",
+			update: func(d DevfileObj) error {
+				components, err := d.Data.GetComponents(common.DevfileOptions{FilterByName: "tools"})
+				if err != nil {
+					return err
+				}
+				components[0].Container.Image = "quay.io/tools:2.0"
+				if err = d.Data.UpdateComponent(components[0]); err != nil {
+					return err
+				}
+				return d.Data.DeleteCommand("run")
+			},
+			wantDevfile: `# the devfile of the main project
+schemaVersion: 2.2.0
+metadata:
+  name: main # the project name
+  version: 1.0.0
+components:
+  # the runtime container
+  - name: runtime
+    container:
+      image: &image quay.io/runtime:1.0
+      memoryLimit: 512Mi
+      env:
+        - name: DEBUG
+          value: "false"
+  - name: tools
+    container:
+      image: quay.io/tools:2.0
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devfilePath := filepath.Join(t.TempDir(), "devfile.yaml")
+			if err := os.WriteFile(devfilePath, []byte(devfile), 0600); err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_PatchesOriginalContent() failed to write the devfile: %v", err)
+			}
+			d, err := ParseDevfile(ParserArgs{Path: devfilePath})
+			if err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_PatchesOriginalContent() unexpected error parsing the devfile: %v", err)
+			}
+			if err = tt.update(d); err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_PatchesOriginalContent() unexpected error updating the devfile: %v", err)
+			}
+			if err = d.WriteYamlDevfile(); err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_PatchesOriginalContent() unexpected error writing the devfile: %v", err)
+			}
+			written, err := os.ReadFile(devfilePath)
+			if err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_PatchesOriginalContent() failed to read the written devfile: %v", err)
+			}
+			assert.Equal(t, tt.wantDevfile, string(written), "TestDevfileObj_WriteYamlDevfile_PatchesOriginalContent(): devfile should match")
+		})
+	}
+}
@@ -0,0 +1,195 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"time"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+)
+
+// ImportKind is the way a devfile is imported
+type ImportKind string
+
+const (
+	// ImportKindMain is the kind of the main devfile, which is not imported
+	ImportKindMain ImportKind = "main"
+	// ImportKindParent is the kind of devfiles imported as a parent
+	ImportKindParent ImportKind = "parent"
+	// ImportKindPlugin is the kind of devfiles imported as a plugin
+	ImportKindPlugin ImportKind = "plugin"
+)
+
+// Provenance reports where the content of a flattened devfile came from
+type Provenance struct {
+	// Root is the node of the main devfile, the nodes of its parent and plugins are its imports
+	Root *ProvenanceNode
+	// Elements are the components, commands, projects and starter projects of the flattened devfile, in order,
+	// along with the node they came from
+	Elements []ElementProvenance
+}
+
+// ProvenanceNode describes a devfile of the resolution tree of a flattened devfile
+type ProvenanceNode struct {
+	// Kind is the way the devfile is imported
+	Kind ImportKind
+	// ImportReference is the import reference of the devfile as written in the importing devfile, empty for the main devfile
+	ImportReference v1.ImportReference
+	// ResolvedReference is the import reference the devfile was resolved with, e.g. with the uri relative to the importing
+	// devfile resolved or the registry URL it was found in
+	ResolvedReference v1.ImportReference
+	// Location is the resolved location of the devfile: its path, its URL, or its resolved import reference
+	// for devfiles fetched from a registry or a cluster. It is empty for a main devfile parsed from its content.
+	Location string
+	// SchemaVersion is the schema version of the devfile
+	SchemaVersion string
+	// RegistryURL is the URL of the registry the devfile was fetched from, if any
+	RegistryURL string
+	// FetchDuration is the time spent resolving and fetching the devfile, excluding its own parent and plugins
+	FetchDuration time.Duration
+	// CacheHit is true if the devfile was served from a cache, e.g. an offline vault, instead of being fetched
+	CacheHit bool
+	// Imports are the nodes of the parent and plugins of the devfile
+	Imports []*ProvenanceNode
+}
+
+// ElementProvenance describes where an element of a flattened devfile came from
+type ElementProvenance struct {
+	// List is the list of the devfile the element is part of: components, commands, projects or starterProjects
+	List string
+	// Name is the name of the element, or its id for commands
+	Name string
+	// Source is the node of the devfile defining the element
+	Source *ProvenanceNode
+	// Overridden is true if the element was overridden by a devfile importing it as a parent or plugin
+	Overridden bool
+	// OverriddenBy is the node of the devfile overriding the element, the outermost one if it is overridden several times
+	OverriddenBy *ProvenanceNode
+}
+
+// provenanceKey identifies an element of a devfile by list and name
+type provenanceKey struct {
+	list string
+	name string
+}
+
+// newProvenance returns the provenance of a devfile imported with importReference, resolved in resolved
+func newProvenance(kind ImportKind, importReference v1.ImportReference, resolved v1.ImportReference) *Provenance {
+	node := &ProvenanceNode{Kind: kind, ImportReference: importReference, ResolvedReference: resolved}
+	if resolved.Id != "" {
+		node.RegistryURL = resolved.RegistryUrl
+	}
+	return &Provenance{Root: node}
+}
+
+// setLocation records the location and schema version of the devfile d in the root node
+func (p *Provenance) setLocation(d DevfileObj) {
+	if p == nil {
+		return
+	}
+	switch {
+	case d.Ctx.GetAbsPath() != "":
+		p.Root.Location = d.Ctx.GetAbsPath()
+	case d.Ctx.GetURL() != "":
+		p.Root.Location = d.Ctx.GetURL()
+	case p.Root.Kind != ImportKindMain:
+		p.Root.Location = resolveImportReference(p.Root.ResolvedReference)
+	}
+	if p.Root.SchemaVersion = d.Ctx.GetApiVersion(); p.Root.SchemaVersion == "" && d.Data != nil {
+		p.Root.SchemaVersion = d.Data.GetSchemaVersion()
+	}
+}
+
+// addImport adds the provenance of a devfile imported as kind to the one of the importing devfile. The elements of the
+// imported devfile with a name in overridden are marked as overridden by the importing devfile.
+func (p *Provenance) addImport(kind ImportKind, imported *Provenance, overridden map[provenanceKey]bool, importedElements map[provenanceKey]ElementProvenance) {
+	if p == nil || imported == nil {
+		return
+	}
+	imported.Root.Kind = kind
+	p.Root.Imports = append(p.Root.Imports, imported.Root)
+	for _, element := range imported.Elements {
+		if overridden[provenanceKey{list: element.List, name: element.Name}] {
+			element.Overridden = true
+			element.OverriddenBy = p.Root
+		}
+		importedElements[provenanceKey{list: element.List, name: element.Name}] = element
+	}
+}
+
+// setElements records the elements of the flattened content of the devfile, looking up the ones that were imported in
+// importedElements. The other ones are defined in the devfile itself.
+func (p *Provenance) setElements(content *v1.DevWorkspaceTemplateSpecContent, importedElements map[provenanceKey]ElementProvenance) {
+	if p == nil || content == nil {
+		return
+	}
+	p.Elements = nil
+	for _, key := range contentKeys(content) {
+		if element, ok := importedElements[key]; ok {
+			p.Elements = append(p.Elements, element)
+			continue
+		}
+		p.Elements = append(p.Elements, ElementProvenance{List: key.list, Name: key.name, Source: p.Root})
+	}
+}
+
+// contentKeys returns the keys of the elements of a devfile content, in order
+func contentKeys(content *v1.DevWorkspaceTemplateSpecContent) []provenanceKey {
+	var keys []provenanceKey
+	for _, component := range content.Components {
+		keys = append(keys, provenanceKey{list: "components", name: component.Name})
+	}
+	for _, command := range content.Commands {
+		keys = append(keys, provenanceKey{list: "commands", name: command.Id})
+	}
+	for _, project := range content.Projects {
+		keys = append(keys, provenanceKey{list: "projects", name: project.Name})
+	}
+	for _, starterProject := range content.StarterProjects {
+		keys = append(keys, provenanceKey{list: "starterProjects", name: starterProject.Name})
+	}
+	return keys
+}
+
+// parentOverrideKeys returns the keys of the elements overridden by parent overrides
+func parentOverrideKeys(overrides v1.ParentOverrides) map[provenanceKey]bool {
+	keys := map[provenanceKey]bool{}
+	for _, component := range overrides.Components {
+		keys[provenanceKey{list: "components", name: component.Name}] = true
+	}
+	for _, command := range overrides.Commands {
+		keys[provenanceKey{list: "commands", name: command.Id}] = true
+	}
+	for _, project := range overrides.Projects {
+		keys[provenanceKey{list: "projects", name: project.Name}] = true
+	}
+	for _, starterProject := range overrides.StarterProjects {
+		keys[provenanceKey{list: "starterProjects", name: starterProject.Name}] = true
+	}
+	return keys
+}
+
+// pluginOverrideKeys returns the keys of the elements overridden by plugin overrides
+func pluginOverrideKeys(overrides v1.PluginOverrides) map[provenanceKey]bool {
+	keys := map[provenanceKey]bool{}
+	for _, component := range overrides.Components {
+		keys[provenanceKey{list: "components", name: component.Name}] = true
+	}
+	for _, command := range overrides.Commands {
+		keys[provenanceKey{list: "commands", name: command.Id}] = true
+	}
+	return keys
+}
@@ -0,0 +1,111 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDevfile_Provenance(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"devfile.yaml": `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+  components:
+    - name: runtime
+      container:
+        image: quay.io/overridden-image
+components:
+  - name: main-runtime
+    container:
+      image: quay.io/main-image
+commands:
+  - id: build
+    exec:
+      component: main-runtime
+      commandLine: make
+`,
+		"parent.yaml": `schemaVersion: 2.1.0
+metadata:
+  name: parent
+parent:
+  uri: grandparent.yaml
+commands:
+  - id: test
+    exec:
+      component: runtime
+      commandLine: make test
+`,
+		"grandparent.yaml": `schemaVersion: 2.0.0
+metadata:
+  name: grandparent
+components:
+  - name: runtime
+    container:
+      image: quay.io/grandparent-image
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("TestParseDevfile_Provenance() failed to write %s: %v", name, err)
+		}
+	}
+
+	d, err := ParseDevfile(ParserArgs{Path: filepath.Join(dir, "devfile.yaml"), DownloadGitResources: &isFalse, RecordProvenance: true})
+	if err != nil {
+		t.Fatalf("TestParseDevfile_Provenance() unexpected error: %v", err)
+	}
+	if d.Provenance == nil {
+		t.Fatalf("TestParseDevfile_Provenance() expected a provenance report")
+	}
+
+	root := d.Provenance.Root
+	assert.Equal(t, ImportKindMain, root.Kind)
+	assert.Equal(t, filepath.Join(dir, "devfile.yaml"), root.Location)
+	assert.Equal(t, "2.2.0", root.SchemaVersion)
+	if !assert.Len(t, root.Imports, 1, "TestParseDevfile_Provenance(): the main devfile should import a parent") {
+		return
+	}
+	parent := root.Imports[0]
+	assert.Equal(t, ImportKindParent, parent.Kind)
+	assert.Equal(t, v1.ImportReference{ImportReferenceUnion: v1.ImportReferenceUnion{Uri: "parent.yaml"}}, parent.ImportReference)
+	assert.Equal(t, filepath.Join(dir, "parent.yaml"), parent.Location)
+	assert.Equal(t, "2.1.0", parent.SchemaVersion)
+	assert.False(t, parent.CacheHit)
+	if !assert.Len(t, parent.Imports, 1, "TestParseDevfile_Provenance(): the parent should import the grandparent") {
+		return
+	}
+	grandparent := parent.Imports[0]
+	assert.Equal(t, ImportKindParent, grandparent.Kind)
+	assert.Equal(t, filepath.Join(dir, "grandparent.yaml"), grandparent.Location)
+	assert.Equal(t, "2.0.0", grandparent.SchemaVersion)
+
+	wantElements := []ElementProvenance{
+		{List: "components", Name: "main-runtime", Source: root},
+		{List: "components", Name: "runtime", Source: grandparent, Overridden: true, OverriddenBy: root},
+		{List: "commands", Name: "build", Source: root},
+		{List: "commands", Name: "test", Source: parent},
+	}
+	assert.ElementsMatch(t, wantElements, d.Provenance.Elements, "TestParseDevfile_Provenance(): element provenance should match")
+}
@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 
 	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
 	parserUtil "github.com/devfile/library/v2/pkg/devfile/parser/util"
@@ -44,6 +45,9 @@ type YamlSrc struct {
 	Token string
 	// Data is the yaml content in []byte format
 	Data []byte
+	// FS, if set, is the file system Path is read from, e.g. an embed.FS, instead of the afero file system
+	// passed to ReadKubernetesYaml. Path is then a slash-separated path in FS.
+	FS fs.FS
 }
 
 // KubernetesResources struct contains the Deployments, Services,
@@ -78,6 +82,9 @@ func ReadKubernetesYaml(src YamlSrc, fs *afero.Afero, devfileUtilsClient parserU
 			return nil, errors.Wrapf(err, "failed to download file %q", src.URL)
 		}
 	} else if src.Path != "" {
+		if src.FS != nil {
+			fs = &afero.Afero{Fs: afero.FromIOFS{FS: src.FS}}
+		}
 		if fs == nil {
 			return nil, fmt.Errorf("cannot read from %s because fs passed in was nil", src.Path)
 		}
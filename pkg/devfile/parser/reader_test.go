@@ -21,6 +21,7 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"testing/fstest"
 
 	parserUtil "github.com/devfile/library/v2/pkg/devfile/parser/util"
 	"github.com/devfile/library/v2/pkg/util"
@@ -124,6 +125,20 @@ func TestReadAndParseKubernetesYaml(t *testing.T) {
 			wantIngressNames:    []string{"ingress-sample", "ingress-sample-2"},
 			wantOtherNames:      []string{"pvc-sample", "pvc-sample-2"},
 		},
+		{
+			name: "Read the YAML from the Path in an fs.FS",
+			src: YamlSrc{
+				Path: "manifests/resources.yaml",
+				FS:   fstest.MapFS{"manifests/resources.yaml": &fstest.MapFile{Data: data}},
+			},
+			fs:                  nil,
+			devfileUtilsClient:  devfileUtilsClient,
+			wantDeploymentNames: []string{"deploy-sample", "deploy-sample-2"},
+			wantServiceNames:    []string{"service-sample", "service-sample-2"},
+			wantRouteNames:      []string{"route-sample", "route-sample-2"},
+			wantIngressNames:    []string{"ingress-sample", "ingress-sample-2"},
+			wantOtherNames:      []string{"pvc-sample", "pvc-sample-2"},
+		},
 		{
 			name: "Read the YAML from the Path with no fs passed",
 			src: YamlSrc{
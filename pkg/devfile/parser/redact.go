@@ -0,0 +1,255 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Redact replaces the values of the sensitive variables of the devfile in s with placeholders to the variables,
+// e.g. {{name}}. It can be used to redact the messages built from the devfile data, e.g. before logging them.
+func (d DevfileObj) Redact(s string) string {
+	replacer := d.sensitiveReplacer()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// sensitiveReplacer returns a replacer of the values of the sensitive variables with placeholders, or nil if there are
+// no sensitive values
+func (d DevfileObj) sensitiveReplacer() *strings.Replacer {
+	var names []string
+	for name, variable := range d.Variables {
+		if variable.Sensitive && variable.Value != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	// replace the longest values first, so that a value containing another one is replaced as a whole
+	sort.Slice(names, func(i, j int) bool {
+		vi, vj := d.Variables[names[i]].Value, d.Variables[names[j]].Value
+		if len(vi) != len(vj) {
+			return len(vi) > len(vj)
+		}
+		return names[i] < names[j]
+	})
+	var oldnew []string
+	for _, name := range names {
+		oldnew = append(oldnew, d.Variables[name].Value, "{{"+name+"}}")
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// redactedData returns the devfile data with the values of the sensitive variables replaced with placeholders,
+// or the data as is if there are no sensitive values. See restorePlaceholders for the fields that are redacted.
+func (d DevfileObj) redactedData() (interface{}, error) {
+	if d.sensitiveReplacer() == nil {
+		return d.Data, nil
+	}
+	content, err := json.Marshal(d.Data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if err = decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	d.restorePlaceholders(values)
+	return values, nil
+}
+
+// variableReferencePattern matches the variable references of a devfile, e.g. {{name}} or {{name:-default}}, capturing
+// the name of the variable
+var variableReferencePattern = regexp.MustCompile(`\{\{\s*(.*?)(?::-.*?)?\s*\}\}`)
+
+// restorePlaceholders restores the references to the sensitive variables in the string fields of the JSON values of the
+// devfile data whose authored value, in the devfile or in the parents and plugins defining them, references these
+// variables. The other fields, the names, ids, metadata and top-level variables are left as they are, so that a value
+// that happens to contain a sensitive value is not rewritten.
+func (d DevfileObj) restorePlaceholders(values map[string]interface{}) {
+	sensitive := map[string]bool{}
+	for name, variable := range d.Variables {
+		if variable.Sensitive && variable.Value != "" {
+			sensitive[name] = true
+		}
+	}
+	if len(sensitive) == 0 {
+		return
+	}
+	references := sensitiveReferences{}
+	authored := [][]byte{d.Ctx.GetDevfileContent()}
+	if d.sources != nil {
+		for _, imported := range d.sources.imports {
+			authored = append(authored, imported.ctx.GetDevfileContent())
+		}
+	}
+	for _, content := range authored {
+		if content == nil {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		var authoredValues map[string]interface{}
+		if err := decoder.Decode(&authoredValues); err != nil {
+			continue
+		}
+		references.add(authoredValues, sensitive)
+		// the overrides of the parent apply to the elements of the flattened devfile with the same path
+		if parent, ok := authoredValues["parent"].(map[string]interface{}); ok {
+			references.add(parent, sensitive)
+		}
+	}
+
+	var restore func(path string, value interface{}) interface{}
+	restore = func(path string, value interface{}) interface{} {
+		switch v := value.(type) {
+		case string:
+			return references.restore(path, v, d.Variables)
+		case []interface{}:
+			for i, item := range v {
+				v[i] = restore(listItemPath(path, i, item), item)
+			}
+		case map[string]interface{}:
+			for key, item := range v {
+				if key != "name" && key != "id" {
+					v[key] = restore(path+"/"+key, item)
+				}
+			}
+		}
+		return value
+	}
+	for key, value := range values {
+		if key != "metadata" && key != "variables" && key != "parent" {
+			values[key] = restore("/"+key, value)
+		}
+	}
+}
+
+// sensitiveReferences are the authored values of the string fields of a devfile referencing sensitive variables, by path.
+// The items of lists are identified by their name or id when they have one, e.g. /components/runtime/container/image.
+type sensitiveReferences map[string][]string
+
+// add records the string fields of the authored JSON values of a devfile referencing sensitive variables
+func (r sensitiveReferences) add(values map[string]interface{}, sensitive map[string]bool) {
+	var add func(path string, value interface{})
+	add = func(path string, value interface{}) {
+		switch v := value.(type) {
+		case string:
+			for _, match := range variableReferencePattern.FindAllStringSubmatch(v, -1) {
+				if sensitive[match[1]] {
+					r[path] = append(r[path], v)
+					break
+				}
+			}
+		case []interface{}:
+			for i, item := range v {
+				add(listItemPath(path, i, item), item)
+			}
+		case map[string]interface{}:
+			for key, item := range v {
+				add(path+"/"+key, item)
+			}
+		}
+	}
+	for key, value := range values {
+		if key != "metadata" && key != "variables" && key != "parent" {
+			add("/"+key, value)
+		}
+	}
+}
+
+// restore restores the references to the sensitive variables in the value of the string field at path. A value left as
+// authored gets its authored references to sensitive variables back, the references to the other variables staying
+// replaced. In a changed value, the values of the sensitive variables referenced by the authored value are replaced
+// with placeholders, the longest values first so that a value containing another one is replaced as a whole.
+func (r sensitiveReferences) restore(path string, value string, variables map[string]ResolvedVariable) string {
+	authoredValues := r[path]
+	if len(authoredValues) == 0 {
+		return value
+	}
+	var names []string
+	for _, authored := range authoredValues {
+		var replaced, restored strings.Builder
+		last := 0
+		for _, match := range variableReferencePattern.FindAllStringSubmatchIndex(authored, -1) {
+			replaced.WriteString(authored[last:match[0]])
+			restored.WriteString(authored[last:match[0]])
+			reference, name := authored[match[0]:match[1]], authored[match[2]:match[3]]
+			variable, ok := variables[name]
+			switch {
+			case !ok:
+				replaced.WriteString(reference)
+				restored.WriteString(reference)
+			case variable.Sensitive:
+				replaced.WriteString(variable.Value)
+				restored.WriteString(reference)
+				names = append(names, name)
+			default:
+				replaced.WriteString(variable.Value)
+				restored.WriteString(variable.Value)
+			}
+			last = match[1]
+		}
+		replaced.WriteString(authored[last:])
+		restored.WriteString(authored[last:])
+		if replaced.String() == value {
+			return restored.String()
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		vi, vj := variables[names[i]].Value, variables[names[j]].Value
+		if len(vi) != len(vj) {
+			return len(vi) > len(vj)
+		}
+		return names[i] < names[j]
+	})
+	var oldnew []string
+	for _, name := range names {
+		if variables[name].Value != "" {
+			oldnew = append(oldnew, variables[name].Value, "{{"+name+"}}")
+		}
+	}
+	return strings.NewReplacer(oldnew...).Replace(value)
+}
+
+// listItemPath returns the path of the item at index in the list at path, identified by its name or id if it has one
+func listItemPath(path string, index int, item interface{}) string {
+	if name := itemName(item); name != "" {
+		return path + "/" + name
+	}
+	return path + "/" + strconv.Itoa(index)
+}
+
+// itemName returns the name, or the id, of a JSON object
+func itemName(value interface{}) string {
+	object, _ := value.(map[string]interface{})
+	if name, ok := object["name"].(string); ok {
+		return name
+	}
+	id, _ := object["id"].(string)
+	return id
+}
@@ -0,0 +1,115 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/devfile/library/v2/pkg/util"
+	indexSchema "github.com/devfile/registry-support/index/generator/schema"
+	versionpkg "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+)
+
+// latestVersion is the version of a registry import reference resolved to the highest version of the stack
+const latestVersion = "latest"
+
+// isVersionConstraint returns true if the version of a registry import reference is a semver constraint, e.g. ~2.1 or
+// >=1.2 <2, rather than an exact version or latest
+func isVersionConstraint(version string) bool {
+	return version != latestVersion && util.IsVersionConstraint(version)
+}
+
+// matchVersion returns the highest of the versions matching the constraint. An error listing the versions is returned
+// if none matches.
+func matchVersion(id string, registryURL string, constraint string, versions []string) (string, error) {
+	ranges, err := util.ParseVersionConstraint(constraint)
+	if err != nil {
+		return "", &errPkg.NonCompliantDevfile{Err: err.Error()}
+	}
+	var highest *versionpkg.Version
+	for _, version := range versions {
+		v, err := versionpkg.NewVersion(version)
+		if err != nil {
+			continue
+		}
+		for _, constraints := range ranges {
+			if constraints.Check(v) && (highest == nil || v.GreaterThan(highest)) {
+				highest = v
+			}
+		}
+	}
+	if highest == nil {
+		return "", &errPkg.NoMatchingVersion{Id: id, RegistryURL: registryURL, Constraint: constraint, Versions: versions}
+	}
+	return highest.Original(), nil
+}
+
+// getStackVersionsFromRegistry returns the versions of stack id listed in the index of the registry
+func getStackVersionsFromRegistry(ctx context.Context, id, registryURL string, httpTimeout *int, credentials util.CredentialProvider, v *vault) ([]string, error) {
+	param := util.HTTPRequestParams{
+		URL:         fmt.Sprintf("%s/v2index", registryURL),
+		Context:     ctx,
+		Timeout:     httpTimeout,
+		Credentials: credentials,
+		//suppress telemetry for parent uri references
+		TelemetryClientName: util.TelemetryIndirectDevfileCall,
+	}
+	content, err := v.content("url "+param.URL, param.URL, func() ([]byte, error) {
+		return util.HTTPGetRequest(param, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var index []indexSchema.Schema
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, errors.Wrapf(err, "failed to read the index of registry %s", registryURL)
+	}
+	for _, entry := range index {
+		if entry.Name != id {
+			continue
+		}
+		var versions []string
+		for _, version := range entry.Versions {
+			versions = append(versions, version.Version)
+		}
+		if len(versions) == 0 && entry.Version != "" {
+			versions = append(versions, entry.Version)
+		}
+		return versions, nil
+	}
+	return nil, &errPkg.NoMatchingVersion{Id: id, RegistryURL: registryURL}
+}
+
+// resolveRegistryVersion returns the version of stack id matching the version of a registry import reference. Only
+// semver constraints are resolved, exact versions and latest being resolved by the registry.
+func resolveRegistryVersion(ctx context.Context, id, registryURL, version string, httpTimeout *int, credentials util.CredentialProvider, v *vault) (string, error) {
+	if !isVersionConstraint(version) {
+		return version, nil
+	}
+	if !strings.HasPrefix(registryURL, "http://") && !strings.HasPrefix(registryURL, "https://") {
+		return "", &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("the provided registryURL: %s is not a valid URL", registryURL)}
+	}
+	versions, err := getStackVersionsFromRegistry(ctx, id, registryURL, httpTimeout, credentials, v)
+	if err != nil {
+		return "", err
+	}
+	return matchVersion(id, registryURL, version, versions)
+}
@@ -0,0 +1,198 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/devfile/library/v2/pkg/testingutil"
+	indexSchema "github.com/devfile/registry-support/index/generator/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchVersion(t *testing.T) {
+	versions := []string{"1.0.0", "1.2.0", "1.2.5", "2.0.0", "2.1.0", "2.1.3", "2.2.0", "3.0.0-beta"}
+
+	tests := []struct {
+		name        string
+		constraint  string
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name:        "tilde allows patch updates",
+			constraint:  "~2.1",
+			wantVersion: "2.1.3",
+		},
+		{
+			name:        "caret allows minor updates",
+			constraint:  "^1.0.0",
+			wantVersion: "1.2.5",
+		},
+		{
+			name:        "space separated range",
+			constraint:  ">=1.2 <2",
+			wantVersion: "1.2.5",
+		},
+		{
+			name:        "comma separated range with spaced operators",
+			constraint:  ">= 1.2, < 2.1",
+			wantVersion: "2.0.0",
+		},
+		{
+			name:        "wildcard",
+			constraint:  "2.x",
+			wantVersion: "2.2.0",
+		},
+		{
+			name:        "partial upper bound",
+			constraint:  "<=2.1",
+			wantVersion: "2.1.3",
+		},
+		{
+			name:        "alternative ranges",
+			constraint:  "~1.0 || ~2.1",
+			wantVersion: "2.1.3",
+		},
+		{
+			name:        "pessimistic operator",
+			constraint:  "~> 2.1",
+			wantVersion: "2.2.0",
+		},
+		{
+			name:       "no matching version",
+			constraint: "^4",
+			wantErr:    "no version of stack nodejs in registry https://registry.example.com matches ^4, available versions: 1.0.0, 1.2.0, 1.2.5, 2.0.0, 2.1.0, 2.1.3, 2.2.0, 3.0.0-beta",
+		},
+		{
+			name:       "invalid constraint",
+			constraint: ">= 1.a",
+			wantErr:    "invalid version constraint >= 1.a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := matchVersion("nodejs", "https://registry.example.com", tt.constraint, versions)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantVersion, version, "TestMatchVersion(): version should match")
+			}
+		})
+	}
+}
+
+func TestParseDevfile_RegistryVersionConstraint(t *testing.T) {
+	// the resources pulled from the registry are copied to the working directory
+	t.Chdir(t.TempDir())
+	parentDevfile := "schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\n  version: %s\ncomponents:\n- name: runtime\n  container:\n    image: quay.io/nodejs:%s\n"
+	fakeRegistry := &testingutil.FakeRegistry{
+		Index: []indexSchema.Schema{{
+			Name:    "nodejs",
+			Type:    indexSchema.StackDevfileType,
+			Version: "2.1.0",
+		}},
+		Devfiles: map[string]string{},
+	}
+	for _, version := range []string{"2.1.0", "2.1.3", "2.2.0"} {
+		fakeRegistry.Index[0].Versions = append(fakeRegistry.Index[0].Versions, indexSchema.Version{
+			Version: version,
+			Default: version == "2.1.0",
+			Links:   map[string]string{"self": fmt.Sprintf("%s/nodejs:%s", testingutil.FakeRegistryCatalog, version)},
+		})
+		fakeRegistry.Devfiles["nodejs:"+version] = fmt.Sprintf(parentDevfile, version, version)
+	}
+	server := httptest.NewServer(fakeRegistry)
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		registryURL  string
+		registryURLs []string
+		version      string
+		wantVersion  string
+		wantSource   string
+		wantErr      string
+	}{
+		{
+			name:        "constraint resolved against the registry",
+			registryURL: server.URL,
+			version:     "~2.1",
+			wantVersion: "2.1.3",
+			wantSource:  fmt.Sprintf("id: nodejs, registryURL: %s, version: 2.1.3", server.URL),
+		},
+		{
+			name:         "constraint resolved against the registries passed in",
+			registryURLs: []string{server.URL},
+			version:      ">=2.1.1 <3",
+			wantVersion:  "2.2.0",
+			wantSource:   "id: nodejs, registryURL: , version: 2.2.0",
+		},
+		{
+			name:        "exact version",
+			registryURL: server.URL,
+			version:     "2.1.0",
+			wantVersion: "2.1.0",
+			wantSource:  fmt.Sprintf("id: nodejs, registryURL: %s", server.URL),
+		},
+		{
+			name:        "no matching version",
+			registryURL: server.URL,
+			version:     "^3",
+			wantErr:     fmt.Sprintf("no version of stack nodejs in registry %s matches ^3, available versions: 2.1.0, 2.1.3, 2.2.0", server.URL),
+		},
+		{
+			name:         "no matching version in the registries passed in",
+			registryURLs: []string{server.URL},
+			version:      "~2.3",
+			wantErr:      fmt.Sprintf("no version of stack nodejs in registry %s matches ~2.3, available versions: 2.1.0, 2.1.3, 2.2.0", server.URL),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mainDevfile := fmt.Sprintf("schemaVersion: 2.2.0\nmetadata:\n  name: main\nparent:\n  id: nodejs\n  version: %q\n", tt.version)
+			if tt.registryURL != "" {
+				mainDevfile += fmt.Sprintf("  registryUrl: %s\n", tt.registryURL)
+			}
+			d, err := ParseDevfile(ParserArgs{Data: []byte(mainDevfile), RegistryURLs: tt.registryURLs, RecordProvenance: true})
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if assert.NoError(t, err) && assert.Len(t, components, 1) {
+				assert.Equal(t, "quay.io/nodejs:"+tt.wantVersion, components[0].Container.Image)
+				assert.Equal(t, tt.wantSource, components[0].Attributes.GetString(importSourceAttribute, nil),
+					"TestParseDevfile_RegistryVersionConstraint(): the source attribute should record the version of a constraint only")
+			}
+			if assert.Len(t, d.Provenance.Root.Imports, 1) {
+				assert.Equal(t, tt.wantVersion, d.Provenance.Root.Imports[0].ResolvedReference.Version, "TestParseDevfile_RegistryVersionConstraint(): the provenance should record the version")
+			}
+		})
+	}
+}
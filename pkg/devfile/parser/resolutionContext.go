@@ -67,6 +67,8 @@ func resolveImportReference(importReference v1.ImportReference) string {
 		switch {
 		case importReference.Uri != "":
 			return fmt.Sprintf("uri: %s", importReference.Uri)
+		case importReference.Id != "" && isVersionConstraint(importReference.Version):
+			return fmt.Sprintf("id: %s, registryURL: %s, version: %s", importReference.Id, importReference.RegistryUrl, importReference.Version)
 		case importReference.Id != "":
 			return fmt.Sprintf("id: %s, registryURL: %s", importReference.Id, importReference.RegistryUrl)
 		case importReference.Kubernetes != nil:
@@ -0,0 +1,365 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/validation"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	parserUtil "github.com/devfile/library/v2/pkg/devfile/parser/util"
+	"github.com/devfile/library/v2/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Resolver resolves the ImportReference of a parent or a plugin to the devfile it refers to.
+// Resolvers can be registered through ParserArgs.Resolvers to support additional kinds of import references,
+// or to wrap the default resolvers returned by DefaultResolvers.
+type Resolver interface {
+	// CanResolve returns true if the resolver handles the import reference
+	CanResolve(importReference v1.ImportReference) bool
+	// Resolve fetches the devfile the import reference refers to.
+	// The parser takes care of detecting reference cycles and of resolving the parent and plugins of the returned devfile.
+	Resolve(args ResolverArgs, importReference v1.ImportReference) (ResolvedImport, error)
+}
+
+// ResolverArgs contains the information available to a Resolver when resolving an import reference
+type ResolverArgs struct {
+	// DevfileCtx is the context of the devfile containing the import reference.
+	// Relative references should be resolved against its absolute path or URL, and its token used for private repositories.
+	DevfileCtx devfileCtx.DevfileCtx
+	// Context is the context passed in ParserArgs, it may be nil.
+	// Use util.GetContextOrBackground to get a context for remote requests.
+	Context context.Context
+	// RegistryURLs is a list of registry hosts which parser should pull parent devfile from.
+	RegistryURLs []string
+	// DefaultNamespace is the default namespace to use for resolving Kubernetes ImportReferences that do not include one
+	DefaultNamespace string
+	// K8sClient is the Kubernetes client instance used for interacting with a cluster
+	K8sClient client.Client
+	// HTTPTimeout is the timeout value in seconds passed in from the client
+	HTTPTimeout *int
+	// DownloadGitResources downloads the resources from Git repository if true
+	DownloadGitResources bool
+	// DevfileUtilsClient is the client to use for downloads, it is bound to Context, authenticates the downloads without a
+	// token with Credentials, and serves them from the offline vault when parsing offline
+	DevfileUtilsClient parserUtil.DevfileUtils
+	// Credentials provides the credentials of the requests by host, it may be nil
+	Credentials util.CredentialProvider
+
+	// vault serves or vendors the content fetched by the default resolvers outside of DevfileUtilsClient
+	vault *vault
+	// stage stages the resources the default resolvers copy next to the devfiles when resolving plugins concurrently
+	stage *resourceStage
+}
+
+// ResolvedImport is the result of resolving an import reference
+type ResolvedImport struct {
+	// ImportReference is the resolved import reference, e.g. with relative URIs made absolute and the registry URL or namespace
+	// that was actually used filled in. It is used to detect reference cycles and is recorded in the source attributes.
+	ImportReference v1.ImportReference
+	// Devfile is the devfile the import reference refers to.
+	// If Devfile.Data is set, the devfile is used as is. Otherwise, Devfile.Ctx must have been created with one of
+	// NewDevfileCtx, NewURLDevfileCtx or NewByteContentDevfileCtx, and the devfile is populated and validated by the parser.
+	Devfile DevfileObj
+}
+
+// DefaultResolvers returns the resolvers used by the parser for OCI, URI, registry Id and Kubernetes import references.
+// They are consulted after the resolvers passed in ParserArgs.Resolvers.
+func DefaultResolvers() []Resolver {
+	return []Resolver{OCIResolver{}, URIResolver{}, RegistryResolver{}, KubernetesResolver{}}
+}
+
+// URIResolver resolves import references defined with an absolute or relative uri
+type URIResolver struct{}
+
+func (r URIResolver) CanResolve(importReference v1.ImportReference) bool {
+	return importReference.Uri != ""
+}
+
+func (r URIResolver) Resolve(args ResolverArgs, importReference v1.ImportReference) (ResolvedImport, error) {
+	uri := importReference.Uri
+	curDevfileCtx := args.DevfileCtx
+	// validate URI
+	err := validation.ValidateURI(uri)
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+	// NewDevfileCtx
+	var d DevfileObj
+	absoluteURL := strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://")
+	var newUri string
+
+	// relative path in the file system the current devfile was read from
+	if fsys := curDevfileCtx.GetIOFS(); !absoluteURL && fsys != nil {
+		newUri = path.Join(path.Dir(curDevfileCtx.GetAbsPath()), uri)
+		d.Ctx = devfileCtx.NewFSDevfileCtx(fsys, newUri)
+		if _, err := d.Ctx.GetFs().Stat(newUri); err != nil {
+			return ResolvedImport{}, &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("the provided path is not a valid filepath %s", newUri)}
+		}
+	} else if !absoluteURL && curDevfileCtx.GetAbsPath() != "" {
+		// relative path on disk
+		newUri = path.Join(path.Dir(curDevfileCtx.GetAbsPath()), uri)
+		d.Ctx = devfileCtx.NewDevfileCtx(newUri)
+		if util.ValidateFile(newUri) != nil {
+			return ResolvedImport{}, &errPkg.NonCompliantDevfile{Err: fmt.Sprintf("the provided path is not a valid filepath %s", newUri)}
+		}
+		srcDir := path.Dir(newUri)
+		destDir := path.Dir(curDevfileCtx.GetAbsPath())
+		if srcDir != destDir {
+			destDir, err = args.stage.dir(destDir)
+			if err != nil {
+				return ResolvedImport{}, err
+			}
+			err = util.CopyAllDirFiles(srcDir, destDir)
+			if err != nil {
+				return ResolvedImport{}, err
+			}
+		}
+	} else {
+		if absoluteURL {
+			// absolute URL address
+			newUri = uri
+		} else if curDevfileCtx.GetURL() != "" {
+			// relative path to a URL
+			u, err := url.Parse(curDevfileCtx.GetURL())
+			if err != nil {
+				return ResolvedImport{}, err
+			}
+			u.Path = path.Join(u.Path, uri)
+			newUri = u.String()
+		} else {
+			return ResolvedImport{}, fmt.Errorf("failed to resolve parent uri, devfile context is missing absolute url and path to devfile. %s", resolveImportReference(importReference))
+		}
+
+		token, err := inheritedToken(args.Credentials, curDevfileCtx, newUri)
+		if err != nil {
+			return ResolvedImport{}, err
+		}
+		d.Ctx = devfileCtx.NewURLDevfileCtx(newUri)
+		if token != "" {
+			d.Ctx.SetToken(token)
+		}
+
+		// resources can only be downloaded next to a devfile on disk
+		if args.DownloadGitResources && curDevfileCtx.GetIOFS() == nil {
+			destDir, err := args.stage.dir(path.Dir(curDevfileCtx.GetAbsPath()))
+			if err != nil {
+				return ResolvedImport{}, err
+			}
+			err = args.DevfileUtilsClient.DownloadGitRepoResources(newUri, destDir, token)
+			if err != nil {
+				return ResolvedImport{}, err
+			}
+		}
+	}
+	importReference.Uri = newUri
+
+	return ResolvedImport{ImportReference: importReference, Devfile: d}, nil
+}
+
+// inheritedToken returns the token of the importing devfile to fetch the imported devfile at newUri with. The token is
+// only inherited if the credentials have none for the host of newUri, and if the importing devfile is on the same host
+// or not fetched from a URL, since a token is only valid for its own git provider.
+func inheritedToken(credentials util.CredentialProvider, curDevfileCtx devfileCtx.DevfileCtx, newUri string) (string, error) {
+	token := curDevfileCtx.GetToken()
+	if token == "" {
+		return "", nil
+	}
+	hostCredentials, err := util.CredentialsForURL(credentials, newUri)
+	if err != nil || hostCredentials != nil {
+		return "", err
+	}
+	if curDevfileCtx.GetURL() == "" {
+		return token, nil
+	}
+	curURL, err := url.Parse(curDevfileCtx.GetURL())
+	if err != nil {
+		return "", err
+	}
+	newURL, err := url.Parse(newUri)
+	if err != nil {
+		return "", err
+	}
+	if tokenHost(curURL) != tokenHost(newURL) {
+		return "", nil
+	}
+	return token, nil
+}
+
+// tokenHost returns the host a token of a URL is valid for, the raw content of GitHub using the GitHub tokens
+func tokenHost(u *url.URL) string {
+	host := strings.ToLower(u.Host)
+	if host == util.RawGitHubHost {
+		return util.GitHubHost
+	}
+	return host
+}
+
+// RegistryResolver resolves import references defined with a devfile registry id
+type RegistryResolver struct{}
+
+func (r RegistryResolver) CanResolve(importReference v1.ImportReference) bool {
+	return importReference.Id != ""
+}
+
+func (r RegistryResolver) Resolve(args ResolverArgs, importReference v1.ImportReference) (ResolvedImport, error) {
+	var d DevfileObj
+	ctx := util.GetContextOrBackground(args.Context)
+	id := importReference.Id
+	registryURL := importReference.RegistryUrl
+	destDir, err := args.stage.dir(path.Dir(d.Ctx.GetAbsPath()))
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+
+	if registryURL != "" {
+		version, err := resolveRegistryVersion(ctx, id, registryURL, importReference.Version, args.HTTPTimeout, args.Credentials, args.vault)
+		if err != nil {
+			return ResolvedImport{}, err
+		}
+		devfileContent, err := getDevfileFromRegistry(ctx, id, registryURL, version, args.HTTPTimeout, args.Credentials, args.vault)
+		if err != nil {
+			return ResolvedImport{}, err
+		}
+		d.Ctx, err = devfileCtx.NewByteContentDevfileCtx(devfileContent)
+		if err != nil {
+			return ResolvedImport{}, err
+		}
+
+		err = getResourcesFromRegistry(ctx, id, registryURL, version, destDir, args.Credentials, args.vault)
+		if err != nil {
+			return ResolvedImport{}, err
+		}
+
+		importReference.Version = version
+		return ResolvedImport{ImportReference: importReference, Devfile: d}, nil
+
+	} else if args.RegistryURLs != nil {
+		var cacheMissErr *errPkg.OfflineCacheMiss
+		var noMatchErr *errPkg.NoMatchingVersion
+		for _, registryURL := range args.RegistryURLs {
+			version, err := resolveRegistryVersion(ctx, id, registryURL, importReference.Version, args.HTTPTimeout, args.Credentials, args.vault)
+			var devfileContent []byte
+			if err == nil {
+				devfileContent, err = getDevfileFromRegistry(ctx, id, registryURL, version, args.HTTPTimeout, args.Credentials, args.vault)
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ResolvedImport{}, ctxErr
+			}
+			if errors.As(err, &cacheMissErr) {
+				continue
+			}
+			// the constraint may be matched by the versions of the next registries
+			if errors.As(err, &noMatchErr) && noMatchErr.Constraint != "" {
+				continue
+			}
+			if devfileContent != nil && err == nil {
+				d.Ctx, err = devfileCtx.NewByteContentDevfileCtx(devfileContent)
+				if err != nil {
+					return ResolvedImport{}, errors.Wrap(err, "failed to set devfile content from bytes")
+				}
+				importReference.RegistryUrl = registryURL
+				importReference.Version = version
+
+				err := getResourcesFromRegistry(ctx, id, registryURL, version, destDir, args.Credentials, args.vault)
+				if err != nil {
+					return ResolvedImport{}, err
+				}
+
+				return ResolvedImport{ImportReference: importReference, Devfile: d}, nil
+			}
+		}
+		// when parsing offline, report the reference that was not vendored rather than a registry failure
+		if cacheMissErr != nil {
+			return ResolvedImport{}, &errPkg.OfflineCacheMiss{Reference: resolveImportReference(importReference), Vault: cacheMissErr.Vault}
+		}
+		if noMatchErr != nil && noMatchErr.Constraint != "" {
+			return ResolvedImport{}, noMatchErr
+		}
+	} else {
+		return ResolvedImport{}, &errPkg.NonCompliantDevfile{Err: "failed to fetch from registry, registry URL is not provided"}
+	}
+
+	return ResolvedImport{}, fmt.Errorf("failed to get id: %s from registry URLs provided", id)
+}
+
+// kubernetesNamespace returns defaultNamespace if it is set, and the namespace of the current Kubernetes context otherwise
+func kubernetesNamespace(defaultNamespace string) (string, error) {
+	if defaultNamespace != "" {
+		return defaultNamespace, nil
+	}
+	// use current namespace if namespace is not set in devfile and not provided by consumer
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+	namespace, _, err := config.Namespace()
+	if err != nil {
+		return "", fmt.Errorf("kubernetes namespace is not provided, and cannot get current running cluster's namespace: %v", err)
+	}
+	return namespace, nil
+}
+
+// KubernetesResolver resolves import references defined with the name and namespace of a DevWorkspaceTemplate
+type KubernetesResolver struct{}
+
+func (r KubernetesResolver) CanResolve(importReference v1.ImportReference) bool {
+	return importReference.Kubernetes != nil
+}
+
+func (r KubernetesResolver) Resolve(args ResolverArgs, importReference v1.ImportReference) (ResolvedImport, error) {
+	var err error
+	if args.K8sClient == nil || args.Context == nil {
+		return ResolvedImport{}, fmt.Errorf("kubernetes client and context are required to parse from Kubernetes CRD")
+	}
+	namespace := importReference.Kubernetes.Namespace
+
+	if namespace == "" {
+		// if namespace is not set in devfile, use default namespace provided in by consumer
+		namespace, err = kubernetesNamespace(args.DefaultNamespace)
+		if err != nil {
+			return ResolvedImport{}, err
+		}
+	}
+
+	var dwTemplate v1.DevWorkspaceTemplate
+	namespacedName := types.NamespacedName{
+		Name:      importReference.Kubernetes.Name,
+		Namespace: namespace,
+	}
+	err = args.K8sClient.Get(args.Context, namespacedName, &dwTemplate)
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+
+	d, err := convertDevWorskapceTemplateToDevObj(dwTemplate)
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+
+	importReference.Kubernetes.Namespace = namespace
+
+	return ResolvedImport{ImportReference: importReference, Devfile: d}, nil
+}
@@ -0,0 +1,336 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/devfile/library/v2/pkg/testingutil"
+	"github.com/devfile/library/v2/pkg/util"
+	indexSchema "github.com/devfile/registry-support/index/generator/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+// artifactResolver serves devfiles for import references with the artifact:// scheme from an in-memory store
+type artifactResolver struct {
+	devfiles map[string]string
+}
+
+func (r artifactResolver) CanResolve(importReference v1.ImportReference) bool {
+	return strings.HasPrefix(importReference.Uri, "artifact://")
+}
+
+func (r artifactResolver) Resolve(args ResolverArgs, importReference v1.ImportReference) (ResolvedImport, error) {
+	content, ok := r.devfiles[importReference.Uri]
+	if !ok {
+		return ResolvedImport{}, fmt.Errorf("artifact %s not found", importReference.Uri)
+	}
+	ctx, err := devfileCtx.NewByteContentDevfileCtx([]byte(content))
+	if err != nil {
+		return ResolvedImport{}, err
+	}
+	return ResolvedImport{ImportReference: importReference, Devfile: DevfileObj{Ctx: ctx}}, nil
+}
+
+// auditResolver wraps a resolver and records the import references it resolves
+type auditResolver struct {
+	Resolver
+	resolved *[]string
+}
+
+func (r auditResolver) Resolve(args ResolverArgs, importReference v1.ImportReference) (ResolvedImport, error) {
+	resolved, err := r.Resolver.Resolve(args, importReference)
+	if err == nil {
+		*r.resolved = append(*r.resolved, resolved.ImportReference.Uri)
+	}
+	return resolved, err
+}
+
+func TestParseDevfile_Resolvers(t *testing.T) {
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: parent-runtime
+  container:
+    image: quay.io/parent-image
+`
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(parentDevfile))
+		if err != nil {
+			t.Errorf("TestParseDevfile_Resolvers() unexpected error while writing data: %v", err)
+		}
+	}))
+	defer testServer.Close()
+
+	artifacts := artifactResolver{
+		devfiles: map[string]string{
+			"artifact://parent": parentDevfile,
+			"artifact://cycle-a": `schemaVersion: 2.2.0
+metadata:
+  name: cycle-a
+parent:
+  uri: artifact://cycle-b
+`,
+			"artifact://cycle-b": `schemaVersion: 2.2.0
+metadata:
+  name: cycle-b
+parent:
+  uri: artifact://cycle-a
+`,
+		},
+	}
+
+	var audited []string
+
+	tests := []struct {
+		name           string
+		devfile        string
+		resolvers      []Resolver
+		wantComponents []string
+		wantSource     string
+		wantAudited    []string
+		wantErr        string
+	}{
+		{
+			name: "should resolve a parent with a custom resolver",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: artifact://parent
+components:
+- name: runtime
+  container:
+    image: quay.io/main-image
+`,
+			resolvers:      []Resolver{artifacts},
+			wantComponents: []string{"parent-runtime", "runtime"},
+			wantSource:     "uri: artifact://parent",
+		},
+		{
+			name: "should wrap a default resolver",
+			devfile: fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: %s/devfile.yaml
+`, testServer.URL),
+			resolvers:      []Resolver{auditResolver{Resolver: URIResolver{}, resolved: &audited}},
+			wantComponents: []string{"parent-runtime"},
+			wantAudited:    []string{testServer.URL + "/devfile.yaml"},
+		},
+		{
+			name: "should detect reference cycles between custom import references",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: artifact://cycle-a
+`,
+			resolvers: []Resolver{artifacts},
+			wantErr:   "devfile has an cycle in references: main devfile -> uri: artifact://cycle-a -> uri: artifact://cycle-b -> uri: artifact://cycle-a",
+		},
+		{
+			name: "should fail if no resolver can resolve the import reference",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: artifact://parent
+`,
+			wantErr: "the provided path is not a valid filepath|failed to resolve parent uri",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audited = nil
+			d, err := ParseDevfile(ParserArgs{
+				Data:                 []byte(tt.devfile),
+				Resolvers:            tt.resolvers,
+				DownloadGitResources: &isFalse,
+			})
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("TestParseDevfile_Resolvers() expected an error matching %q", tt.wantErr)
+				}
+				assert.Regexp(t, tt.wantErr, err.Error(), "TestParseDevfile_Resolvers(): Error message should match")
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestParseDevfile_Resolvers() unexpected error: %v", err)
+			}
+
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("TestParseDevfile_Resolvers() unexpected error getting components: %v", err)
+			}
+			var names []string
+			for _, component := range components {
+				names = append(names, component.Name)
+				if component.Name == "parent-runtime" && tt.wantSource != "" {
+					assert.Equal(t, tt.wantSource, component.Attributes.GetString(importSourceAttribute, nil), "TestParseDevfile_Resolvers(): source attribute should match")
+				}
+			}
+			assert.ElementsMatch(t, tt.wantComponents, names, "TestParseDevfile_Resolvers(): flattened components should match")
+			assert.Equal(t, tt.wantAudited, audited, "TestParseDevfile_Resolvers(): wrapped resolver calls should match")
+		})
+	}
+}
+
+func TestParseDevfile_Credentials(t *testing.T) {
+	// the resources pulled from the registry are copied to the working directory
+	t.Chdir(t.TempDir())
+	fakeRegistry := &testingutil.FakeRegistry{
+		Index: []indexSchema.Schema{{
+			Name:     "nodejs",
+			Type:     indexSchema.StackDevfileType,
+			Version:  "2.2.0",
+			Versions: []indexSchema.Version{{Version: "2.2.0", Default: true, Resources: []string{"devfile.yaml"}}},
+		}},
+		Devfiles: map[string]string{
+			"nodejs:2.2.0": "schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\ncomponents:\n- name: runtime\n  container:\n    image: quay.io/nodejs:2.2.0\n",
+		},
+		Token: "registry-token",
+	}
+	registryServer := httptest.NewServer(fakeRegistry)
+	defer registryServer.Close()
+
+	// the parent devfile requires basic auth credentials, and imports the stack of the registry
+	parentServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, "schemaVersion: 2.2.0\nmetadata:\n  name: parent\nparent:\n  id: nodejs\n  registryUrl: %s\n  version: 2.2.0\n", registryServer.URL)
+	}))
+	defer parentServer.Close()
+
+	mainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "schemaVersion: 2.2.0\nmetadata:\n  name: main\nparent:\n  uri: %s/devfile.yaml\n", parentServer.URL)
+	}))
+	defer mainServer.Close()
+
+	tests := []struct {
+		name        string
+		credentials util.CredentialProvider
+		wantErr     string
+	}{
+		{
+			name: "credentials of each host",
+			credentials: util.StaticCredentials{
+				parentServer.URL:   {Username: "user", Password: "password"},
+				registryServer.URL: {Token: "registry-token"},
+			},
+		},
+		{
+			name: "credentials from several providers",
+			credentials: util.CredentialProviders{
+				util.StaticCredentials{registryServer.URL: {Token: "registry-token"}},
+				util.StaticCredentials{parentServer.URL: {Username: "user", Password: "password"}},
+			},
+		},
+		{
+			name:    "no credentials for the parent host",
+			wantErr: fmt.Sprintf("failed to retrieve %s/devfile.yaml, 401: Unauthorized", parentServer.URL),
+		},
+		{
+			name:        "missing registry credentials",
+			credentials: util.StaticCredentials{parentServer.URL: {Username: "user", Password: "password"}},
+			wantErr:     "401: Unauthorized",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flattened, downloadGitResources := true, false
+			d, err := ParseDevfile(ParserArgs{URL: mainServer.URL, Credentials: tt.credentials, FlattenedDevfile: &flattened, DownloadGitResources: &downloadGitResources})
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if assert.NoError(t, err) && assert.Len(t, components, 1) {
+				assert.Equal(t, "quay.io/nodejs:2.2.0", components[0].Container.Image, "TestParseDevfile_Credentials(): the stack of the registry should be imported")
+			}
+		})
+	}
+}
+
+func TestInheritedToken(t *testing.T) {
+	credentials := util.StaticCredentials{"gitlab.example.com": {Token: "gitlab-token"}}
+
+	tests := []struct {
+		name        string
+		devfileURL  string
+		devfilePath string
+		newUri      string
+		want        string
+	}{
+		{
+			name:       "same host",
+			devfileURL: "https://github.com/org/repo/blob/main/devfile.yaml",
+			newUri:     "https://github.com/org/parent/blob/main/devfile.yaml",
+			want:       "token",
+		},
+		{
+			name:       "GitHub raw content",
+			devfileURL: "https://github.com/org/repo/blob/main/devfile.yaml",
+			newUri:     "https://raw.githubusercontent.com/org/parent/main/devfile.yaml",
+			want:       "token",
+		},
+		{
+			name:       "other host",
+			devfileURL: "https://github.com/org/repo/blob/main/devfile.yaml",
+			newUri:     "https://bitbucket.org/org/parent/raw/main/devfile.yaml",
+		},
+		{
+			name:        "devfile on disk",
+			devfilePath: "/tmp/devfile.yaml",
+			newUri:      "https://github.com/org/parent/blob/main/devfile.yaml",
+			want:        "token",
+		},
+		{
+			name:        "credentials provided for the host",
+			devfilePath: "/tmp/devfile.yaml",
+			newUri:      "https://gitlab.example.com/org/parent/-/raw/main/devfile.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := devfileCtx.NewDevfileCtx(tt.devfilePath)
+			if tt.devfileURL != "" {
+				ctx = devfileCtx.NewURLDevfileCtx(tt.devfileURL)
+			}
+			ctx.SetToken("token")
+			got, err := inheritedToken(credentials, ctx, tt.newUri)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got, "TestInheritedToken(): token should match")
+			}
+		})
+	}
+}
@@ -29,85 +29,95 @@ const (
 	pluginOverrideAttribute = validation.PluginOverrideAttribute
 )
 
+// importSourceReference returns the source reference of a devfile imported with importReference and resolved as
+// resolved. The version a semver constraint was resolved to is added to it, so that it records the version that was
+// imported, the source reference of the other import references being unchanged.
+func importSourceReference(importReference v1.ImportReference, resolved v1.ImportReference) string {
+	if importReference.Id != "" && isVersionConstraint(importReference.Version) && resolved.Version != "" {
+		return fmt.Sprintf("id: %s, registryURL: %s, version: %s", importReference.Id, importReference.RegistryUrl, resolved.Version)
+	}
+	return resolveImportReference(importReference)
+}
+
 // addSourceAttributesForParentOverride adds an attribute 'api.devfile.io/imported-from=<source reference>'
 // to all elements of template spec content that support attributes.
-func addSourceAttributesForTemplateSpecContent(sourceImportReference v1.ImportReference, template *v1.DevWorkspaceTemplateSpecContent) {
+func addSourceAttributesForTemplateSpecContent(sourceReference string, template *v1.DevWorkspaceTemplateSpecContent) {
 	for idx, component := range template.Components {
 		if component.Attributes == nil {
 			template.Components[idx].Attributes = attributes.Attributes{}
 		}
-		template.Components[idx].Attributes.PutString(importSourceAttribute, resolveImportReference(sourceImportReference))
+		template.Components[idx].Attributes.PutString(importSourceAttribute, sourceReference)
 	}
 	for idx, command := range template.Commands {
 		if command.Attributes == nil {
 			template.Commands[idx].Attributes = attributes.Attributes{}
 		}
-		template.Commands[idx].Attributes.PutString(importSourceAttribute, resolveImportReference(sourceImportReference))
+		template.Commands[idx].Attributes.PutString(importSourceAttribute, sourceReference)
 	}
 	for idx, project := range template.Projects {
 		if project.Attributes == nil {
 			template.Projects[idx].Attributes = attributes.Attributes{}
 		}
-		template.Projects[idx].Attributes.PutString(importSourceAttribute, resolveImportReference(sourceImportReference))
+		template.Projects[idx].Attributes.PutString(importSourceAttribute, sourceReference)
 	}
 	for idx, project := range template.StarterProjects {
 		if project.Attributes == nil {
 			template.StarterProjects[idx].Attributes = attributes.Attributes{}
 		}
-		template.StarterProjects[idx].Attributes.PutString(importSourceAttribute, resolveImportReference(sourceImportReference))
+		template.StarterProjects[idx].Attributes.PutString(importSourceAttribute, sourceReference)
 	}
 }
 
 // addSourceAttributesForParentOverride adds an attribute 'api.devfile.io/parent-override-from=<source reference>'
 // to all elements of parent override that support attributes.
-func addSourceAttributesForParentOverride(sourceImportReference v1.ImportReference, parentOverrides *v1.ParentOverrides) {
+func addSourceAttributesForParentOverride(sourceReference string, parentOverrides *v1.ParentOverrides) {
 	for idx, component := range parentOverrides.Components {
 		if component.Attributes == nil {
 			parentOverrides.Components[idx].Attributes = attributes.Attributes{}
 		}
-		parentOverrides.Components[idx].Attributes.PutString(parentOverrideAttribute, resolveImportReference(sourceImportReference))
+		parentOverrides.Components[idx].Attributes.PutString(parentOverrideAttribute, sourceReference)
 	}
 	for idx, command := range parentOverrides.Commands {
 		if command.Attributes == nil {
 			parentOverrides.Commands[idx].Attributes = attributes.Attributes{}
 		}
-		parentOverrides.Commands[idx].Attributes.PutString(parentOverrideAttribute, resolveImportReference(sourceImportReference))
+		parentOverrides.Commands[idx].Attributes.PutString(parentOverrideAttribute, sourceReference)
 	}
 	for idx, project := range parentOverrides.Projects {
 		if project.Attributes == nil {
 			parentOverrides.Projects[idx].Attributes = attributes.Attributes{}
 		}
-		parentOverrides.Projects[idx].Attributes.PutString(parentOverrideAttribute, resolveImportReference(sourceImportReference))
+		parentOverrides.Projects[idx].Attributes.PutString(parentOverrideAttribute, sourceReference)
 	}
 	for idx, project := range parentOverrides.StarterProjects {
 		if project.Attributes == nil {
 			parentOverrides.StarterProjects[idx].Attributes = attributes.Attributes{}
 		}
-		parentOverrides.StarterProjects[idx].Attributes.PutString(parentOverrideAttribute, resolveImportReference(sourceImportReference))
+		parentOverrides.StarterProjects[idx].Attributes.PutString(parentOverrideAttribute, sourceReference)
 	}
 
 }
 
 // addSourceAttributesForPluginOverride adds an attribute 'api.devfile.io/plugin-override-from=<source reference>'
 // to all elements of plugin override that support attributes.
-func addSourceAttributesForPluginOverride(sourceImportReference v1.ImportReference, pluginOverrides *v1.PluginOverrides) {
+func addSourceAttributesForPluginOverride(sourceReference string, pluginOverrides *v1.PluginOverrides) {
 	for idx, component := range pluginOverrides.Components {
 		if component.Attributes == nil {
 			pluginOverrides.Components[idx].Attributes = attributes.Attributes{}
 		}
-		pluginOverrides.Components[idx].Attributes.PutString(pluginOverrideAttribute, resolveImportReference(sourceImportReference))
+		pluginOverrides.Components[idx].Attributes.PutString(pluginOverrideAttribute, sourceReference)
 	}
 	for idx, command := range pluginOverrides.Commands {
 		if command.Attributes == nil {
 			pluginOverrides.Commands[idx].Attributes = attributes.Attributes{}
 		}
-		pluginOverrides.Commands[idx].Attributes.PutString(pluginOverrideAttribute, resolveImportReference(sourceImportReference))
+		pluginOverrides.Commands[idx].Attributes.PutString(pluginOverrideAttribute, sourceReference)
 	}
 
 }
 
 // addSourceAttributesForOverrideAndMerge adds an attribute record the import reference to all elements of template that support attributes.
-func addSourceAttributesForOverrideAndMerge(sourceImportReference v1.ImportReference, template interface{}) error {
+func addSourceAttributesForOverrideAndMerge(sourceReference string, template interface{}) error {
 	if template == nil {
 		return fmt.Errorf("cannot add source attributes to nil")
 	}
@@ -118,11 +128,11 @@ func addSourceAttributesForOverrideAndMerge(sourceImportReference v1.ImportRefer
 
 	switch {
 	case isMainContent:
-		addSourceAttributesForTemplateSpecContent(sourceImportReference, mainContent)
+		addSourceAttributesForTemplateSpecContent(sourceReference, mainContent)
 	case isParentOverride:
-		addSourceAttributesForParentOverride(sourceImportReference, parentOverride)
+		addSourceAttributesForParentOverride(sourceReference, parentOverride)
 	case isPluginOverride:
-		addSourceAttributesForPluginOverride(sourceImportReference, pluginOverride)
+		addSourceAttributesForPluginOverride(sourceReference, pluginOverride)
 	default:
 		return fmt.Errorf("unknown template type")
 	}
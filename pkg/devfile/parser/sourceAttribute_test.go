@@ -158,7 +158,7 @@ func TestAddSourceAttributesForOverrideAndMerge(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := addSourceAttributesForOverrideAndMerge(tt.importReference, tt.template)
+			err := addSourceAttributesForOverrideAndMerge(resolveImportReference(tt.importReference), tt.template)
 
 			if (err != nil) != (tt.wantErr != nil) {
 				t.Errorf("Test_AddSourceAttributesForOverrideAndMerge() unexpected error: %v, wantErr %v", err, tt.wantErr)
@@ -0,0 +1,352 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// StrictArgs configures the strict mode of the parser
+type StrictArgs struct {
+	// DeprecationsAsErrors reports the deprecated constructs as errors instead of warnings
+	DeprecationsAsErrors bool
+}
+
+// deprecatedSchemaVersions are the deprecated devfile schema versions
+var deprecatedSchemaVersions = map[string]bool{
+	string(data.APISchemaVersion200): true,
+}
+
+// deprecatedRegistryStackTag is the tag of the devfile metadata marking the deprecated stacks of a registry
+const deprecatedRegistryStackTag = "Deprecated"
+
+// deprecatedKubernetesAPIVersions maps the deprecated apiVersions of Kubernetes resources, optionally followed by the
+// resource kind, to their replacement. The replacement is empty for the resources that have none, e.g. PodSecurityPolicy,
+// or whose replacement depends on a kind not listed.
+var deprecatedKubernetesAPIVersions = map[string]string{
+	"extensions/v1beta1 Deployment":     "apps/v1",
+	"extensions/v1beta1 DaemonSet":      "apps/v1",
+	"extensions/v1beta1 ReplicaSet":     "apps/v1",
+	"extensions/v1beta1 Ingress":        "networking.k8s.io/v1",
+	"extensions/v1beta1 NetworkPolicy":  "networking.k8s.io/v1",
+	"extensions/v1beta1":                "",
+	"networking.k8s.io/v1beta1 Ingress": "networking.k8s.io/v1",
+	"apps/v1beta1":                      "apps/v1",
+	"apps/v1beta2":                      "apps/v1",
+}
+
+// strictState reports the unknown fields and the deprecated constructs of the devfiles parsed in strict mode
+type strictState struct {
+	deprecationsAsErrors bool
+	// deprecations are the deprecated constructs found, in parsing order
+	deprecations *issueCollector
+}
+
+// newStrictState returns the state to use for parsing in strict mode, nil if args is nil
+func newStrictState(args *StrictArgs) *strictState {
+	if args == nil {
+		return nil
+	}
+	return &strictState{deprecationsAsErrors: args.DeprecationsAsErrors, deprecations: &issueCollector{}}
+}
+
+// fork returns a state recording the deprecations found in a branch of the resolution tree, so that branches can be
+// resolved concurrently. The deprecations of the fork are added back with join.
+func (s *strictState) fork() *strictState {
+	if s == nil {
+		return nil
+	}
+	return &strictState{deprecationsAsErrors: s.deprecationsAsErrors, deprecations: s.deprecations.fork()}
+}
+
+// join adds the deprecations recorded by forks, in order
+func (s *strictState) join(forks ...*strictState) {
+	if s == nil {
+		return
+	}
+	for _, fork := range forks {
+		s.deprecations.join(fork.deprecations)
+	}
+}
+
+// recorded returns the number of deprecations recorded so far
+func (s *strictState) recorded() int {
+	if s == nil {
+		return 0
+	}
+	return s.deprecations.recorded()
+}
+
+// setImportReference sets the import reference of the deprecations recorded after the first ones that do not have one,
+// i.e. of the deprecations found in the devfile imported with importReference
+func (s *strictState) setImportReference(first int, importReference v1.ImportReference) {
+	if s == nil {
+		return
+	}
+	s.deprecations.setImportReference(first, importReference)
+}
+
+// deprecated records a deprecated construct
+func (s *strictState) deprecated(issue *errPkg.Issue) {
+	issue.Code = errPkg.CodeDeprecated
+	issue.Severity = errPkg.SeverityWarning
+	if s.deprecationsAsErrors {
+		issue.Severity = errPkg.SeverityError
+	}
+	s.deprecations.add(issue)
+}
+
+// checkDevfile reports the unknown fields of the content of d, and records its deprecated schema version, or the
+// deprecation of the registry stack it was imported from with importReference
+func (s *strictState) checkDevfile(d DevfileObj, importReference v1.ImportReference) error {
+	if s == nil {
+		return nil
+	}
+	var content interface{}
+	if err := json.Unmarshal(d.Ctx.GetDevfileContent(), &content); err != nil {
+		return &errPkg.NonCompliantDevfile{Err: err.Error()}
+	}
+	var issues errPkg.Issues
+	for _, pointer := range unknownFields(content, reflect.TypeOf(v2.DevfileV2{}), "") {
+		issues = append(issues, &errPkg.Issue{
+			Code:      errPkg.CodeUnknownField,
+			Severity:  errPkg.SeverityError,
+			Message:   fmt.Sprintf("unknown field %s", pointer),
+			Field:     pointer,
+			Element:   d.Ctx.ElementAt(pointer),
+			Locations: []errPkg.Location{d.Ctx.Locate(pointer)},
+		})
+	}
+	if len(issues) > 0 {
+		return &errPkg.NonCompliantDevfile{Err: "devfile has unknown fields:\n" + issues.Error(), Issues: issues}
+	}
+
+	if schemaVersion := d.Data.GetSchemaVersion(); deprecatedSchemaVersions[schemaVersion] {
+		s.deprecated(&errPkg.Issue{
+			Message:   fmt.Sprintf("schema version %s is deprecated", schemaVersion),
+			Field:     "/schemaVersion",
+			Locations: []errPkg.Location{d.Ctx.Locate("/schemaVersion")},
+		})
+	}
+	if importReference.Id != "" {
+		for _, tag := range d.Data.GetMetadata().Tags {
+			if strings.EqualFold(tag, deprecatedRegistryStackTag) {
+				s.deprecated(&errPkg.Issue{
+					Message:   fmt.Sprintf("the stack %s is deprecated", resolveImportReference(importReference)),
+					Field:     "/metadata/tags",
+					Locations: []errPkg.Location{d.Ctx.Locate("/metadata/tags")},
+				})
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// checkFlattened records the Kubernetes and OpenShift components of the flattened devfile d defining resources with
+// a deprecated apiVersion
+func (s *strictState) checkFlattened(d DevfileObj) error {
+	if s == nil {
+		return nil
+	}
+	components, err := d.Data.GetComponents(common.DevfileOptions{})
+	if err != nil {
+		return err
+	}
+	for i, component := range components {
+		var inlined, field string
+		switch {
+		case component.Kubernetes != nil:
+			inlined, field = component.Kubernetes.Inlined, fmt.Sprintf("/components/%d/kubernetes/inlined", i)
+		case component.Openshift != nil:
+			inlined, field = component.Openshift.Inlined, fmt.Sprintf("/components/%d/openshift/inlined", i)
+		default:
+			continue
+		}
+		for _, resource := range kubernetesResourceTypes(inlined) {
+			replacement, ok := deprecatedKubernetesAPIVersions[resource.APIVersion+" "+resource.Kind]
+			if !ok {
+				replacement, ok = deprecatedKubernetesAPIVersions[resource.APIVersion]
+			}
+			if !ok {
+				continue
+			}
+			message := fmt.Sprintf("the %s resource of component %s uses the deprecated apiVersion %s", resource.Kind, component.Name, resource.APIVersion)
+			if replacement != "" {
+				message = fmt.Sprintf("%s, use %s instead", message, replacement)
+			}
+			issue := &errPkg.Issue{
+				Message: message,
+				Field:   field,
+				Element: component.Name,
+			}
+			if location, importReference, ok := d.locateElement("components", component.Name); ok {
+				issue.Locations = []errPkg.Location{location}
+				if importReference != nil {
+					issue.ImportReference = *importReference
+				}
+			}
+			s.deprecated(issue)
+		}
+	}
+	return nil
+}
+
+// result returns the deprecations reported as warnings, or an error if they are reported as errors
+func (s *strictState) result() (errPkg.Issues, error) {
+	if s == nil {
+		return nil, nil
+	}
+	deprecations := s.deprecations.list()
+	if len(deprecations) == 0 {
+		return nil, nil
+	}
+	if s.deprecationsAsErrors {
+		return nil, &errPkg.NonCompliantDevfile{Err: "devfile uses deprecated constructs:\n" + deprecations.Error(), Issues: deprecations}
+	}
+	return deprecations, nil
+}
+
+// kubernetesResourceType is the type of a Kubernetes resource
+type kubernetesResourceType struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+}
+
+// kubernetesResourceTypes returns the types of the resources defined in the YAML documents of content, invalid
+// documents are ignored
+func kubernetesResourceTypes(content string) []kubernetesResourceType {
+	var types []kubernetesResourceType
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var resource kubernetesResourceType
+		err := decoder.Decode(&resource)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// the rest of the content cannot be decoded
+			var typeErr *yaml.TypeError
+			if errors.As(err, &typeErr) {
+				continue
+			}
+			break
+		}
+		if resource.APIVersion != "" {
+			types = append(types, resource)
+		}
+	}
+	return types
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownFields returns the JSON pointers of the fields of value, the decoded JSON found at pointer, that are not
+// fields of the Go type t, at any depth and sorted. Values decoded by a custom unmarshaler are not looked into.
+func unknownFields(value interface{}, t reflect.Type, pointer string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fieldPointer := pointer + "/" + devfileCtx.EscapeJSONPointerToken(key)
+			fieldType, ok := fields[key]
+			if !ok {
+				unknown = append(unknown, fieldPointer)
+				continue
+			}
+			unknown = append(unknown, unknownFields(object[key], fieldType, fieldPointer)...)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range list {
+			unknown = append(unknown, unknownFields(item, t.Elem(), fmt.Sprintf("%s/%d", pointer, i))...)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, item := range object {
+			unknown = append(unknown, unknownFields(item, t.Elem(), pointer+"/"+devfileCtx.EscapeJSONPointerToken(key))...)
+		}
+		sort.Strings(unknown)
+	}
+	return unknown
+}
+
+// jsonFields returns the types of the fields of the struct type t by JSON name, including the fields of inlined structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for inlinedName, inlinedType := range jsonFields(fieldType) {
+				fields[inlinedName] = inlinedType
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
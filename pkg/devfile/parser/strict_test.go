@@ -0,0 +1,295 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDevfile_Strict(t *testing.T) {
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+  - name: runtime
+    container:
+      image: quay.io/parent-image
+`
+
+	tests := []struct {
+		name                 string
+		devfile              string
+		parent               string
+		deprecationsAsErrors bool
+		wantErr              string
+		// wantIssue is the issue reported in the error, or as the single warning if wantErr is empty
+		wantIssue *errPkg.Issue
+	}{
+		{
+			name: "devfile without unknown or deprecated fields",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+`,
+		},
+		{
+			name: "unknown field of the devfile",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+  dispalyName: Main
+`,
+			wantErr: "unknown field /metadata/dispalyName",
+			wantIssue: &errPkg.Issue{
+				Code:      errPkg.CodeUnknownField,
+				Severity:  errPkg.SeverityError,
+				Message:   "unknown field /metadata/dispalyName",
+				Field:     "/metadata/dispalyName",
+				Locations: []errPkg.Location{{File: "devfile.yaml", Line: 4, Column: 3, JSONPointer: "/metadata/dispalyName"}},
+			},
+		},
+		{
+			name: "unknown field of the parent",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+`,
+			parent: `schemaVersion: 2.2.0
+metadata:
+  name: parent
+  provider: Red Hat
+  providr: Red Hat
+components:
+  - name: runtime
+    container:
+      image: quay.io/parent-image
+`,
+			wantErr: "unknown field /metadata/providr",
+			wantIssue: &errPkg.Issue{
+				Code:            errPkg.CodeUnknownField,
+				Severity:        errPkg.SeverityError,
+				Message:         "unknown field /metadata/providr",
+				Field:           "/metadata/providr",
+				ImportReference: "uri: parent.yaml",
+				Locations:       []errPkg.Location{{File: "parent.yaml", Line: 5, Column: 3, JSONPointer: "/metadata/providr"}},
+			},
+		},
+		{
+			name: "deprecated schema version is a warning",
+			devfile: `schemaVersion: 2.0.0
+metadata:
+  name: main
+`,
+			wantIssue: &errPkg.Issue{
+				Code:      errPkg.CodeDeprecated,
+				Severity:  errPkg.SeverityWarning,
+				Message:   "schema version 2.0.0 is deprecated",
+				Field:     "/schemaVersion",
+				Locations: []errPkg.Location{{File: "devfile.yaml", Line: 1, Column: 1, JSONPointer: "/schemaVersion"}},
+			},
+		},
+		{
+			name: "deprecated schema version of the parent is an error if configured so",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+`,
+			parent: `schemaVersion: 2.0.0
+metadata:
+  name: parent
+`,
+			deprecationsAsErrors: true,
+			wantErr:              "schema version 2.0.0 is deprecated",
+			wantIssue: &errPkg.Issue{
+				Code:            errPkg.CodeDeprecated,
+				Severity:        errPkg.SeverityError,
+				Message:         "schema version 2.0.0 is deprecated",
+				Field:           "/schemaVersion",
+				ImportReference: "uri: parent.yaml",
+				Locations:       []errPkg.Location{{File: "parent.yaml", Line: 1, Column: 1, JSONPointer: "/schemaVersion"}},
+			},
+		},
+		{
+			name: "ingress with a deprecated apiVersion",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+components:
+  - name: runtime
+    container:
+      image: quay.io/image
+  - name: ingress
+    kubernetes:
+      inlined: |
+        apiVersion: v1
+        kind: Service
+        metadata:
+          name: service
+        ---
+        apiVersion: extensions/v1beta1
+        kind: Ingress
+        metadata:
+          name: ingress
+`,
+			wantIssue: &errPkg.Issue{
+				Code:      errPkg.CodeDeprecated,
+				Severity:  errPkg.SeverityWarning,
+				Message:   "the Ingress resource of component ingress uses the deprecated apiVersion extensions/v1beta1, use networking.k8s.io/v1 instead",
+				Field:     "/components/1/kubernetes/inlined",
+				Element:   "ingress",
+				Locations: []errPkg.Location{{File: "devfile.yaml", Line: 8, Column: 5, JSONPointer: "/components/1"}},
+			},
+		},
+		{
+			name: "network policy with a deprecated apiVersion",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+components:
+  - name: policy
+    kubernetes:
+      inlined: |
+        apiVersion: extensions/v1beta1
+        kind: NetworkPolicy
+        metadata:
+          name: policy
+`,
+			wantIssue: &errPkg.Issue{
+				Code:      errPkg.CodeDeprecated,
+				Severity:  errPkg.SeverityWarning,
+				Message:   "the NetworkPolicy resource of component policy uses the deprecated apiVersion extensions/v1beta1, use networking.k8s.io/v1 instead",
+				Field:     "/components/0/kubernetes/inlined",
+				Element:   "policy",
+				Locations: []errPkg.Location{{File: "devfile.yaml", Line: 5, Column: 5, JSONPointer: "/components/0"}},
+			},
+		},
+		{
+			name: "pod security policy with a deprecated apiVersion and no replacement",
+			devfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+components:
+  - name: policy
+    kubernetes:
+      inlined: |
+        apiVersion: extensions/v1beta1
+        kind: PodSecurityPolicy
+        metadata:
+          name: policy
+`,
+			wantIssue: &errPkg.Issue{
+				Code:      errPkg.CodeDeprecated,
+				Severity:  errPkg.SeverityWarning,
+				Message:   "the PodSecurityPolicy resource of component policy uses the deprecated apiVersion extensions/v1beta1",
+				Field:     "/components/0/kubernetes/inlined",
+				Element:   "policy",
+				Locations: []errPkg.Location{{File: "devfile.yaml", Line: 5, Column: 5, JSONPointer: "/components/0"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			parent := tt.parent
+			if parent == "" {
+				parent = parentDevfile
+			}
+			for name, content := range map[string]string{"devfile.yaml": tt.devfile, "parent.yaml": parent} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+					t.Fatalf("TestParseDevfile_Strict() failed to write %s: %v", name, err)
+				}
+			}
+			if tt.wantIssue != nil {
+				for i := range tt.wantIssue.Locations {
+					tt.wantIssue.Locations[i].File = filepath.Join(dir, tt.wantIssue.Locations[i].File)
+				}
+			}
+
+			d, err := ParseDevfile(ParserArgs{
+				Path:                 filepath.Join(dir, "devfile.yaml"),
+				DownloadGitResources: &isFalse,
+				Strict:               &StrictArgs{DeprecationsAsErrors: tt.deprecationsAsErrors},
+			})
+			if tt.wantErr != "" {
+				if assert.Error(t, err, "TestParseDevfile_Strict(): expected an error matching %q", tt.wantErr) {
+					assert.Regexp(t, tt.wantErr, err.Error(), "TestParseDevfile_Strict(): Error message should match")
+					assert.Equal(t, errPkg.Issues{tt.wantIssue}, errPkg.GetIssues(err), "TestParseDevfile_Strict(): issues should match")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestParseDevfile_Strict() unexpected error: %v", err)
+			}
+			if tt.wantIssue == nil {
+				assert.Empty(t, d.Warnings, "TestParseDevfile_Strict(): unexpected warnings")
+			} else {
+				assert.Equal(t, errPkg.Issues{tt.wantIssue}, d.Warnings, "TestParseDevfile_Strict(): warnings should match")
+			}
+		})
+	}
+}
+
+func Test_unknownFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "known fields",
+			content: `{"schemaVersion": "2.2.0", "metadata": {"name": "main"}, "components": [{"name": "runtime", "attributes": {"any": {"value": 1}}, "container": {"image": "quay.io/image", "mountSources": true}}]}`,
+		},
+		{
+			name:    "unknown fields at any depth",
+			content: `{"schemaVersion": "2.2.0", "unknown": true, "components": [{"name": "runtime", "container": {"image": "quay.io/image", "mountSource": true}}]}`,
+			want:    []string{"/components/0/container/mountSource", "/unknown"},
+		},
+		{
+			name:    "unknown fields of the parent overrides",
+			content: `{"schemaVersion": "2.2.0", "parent": {"uri": "parent.yaml", "components": [{"name": "runtime", "container": {"mountSource": true}}], "commands": [{"id": "run", "exec": {"commandLine": "run", "workdir": "/"}}]}}`,
+			want:    []string{"/parent/commands/0/exec/workdir", "/parent/components/0/container/mountSource"},
+		},
+		{
+			name:    "escaped keys",
+			content: `{"metadata": {"a/b~c": true}}`,
+			want:    []string{"/metadata/a~1b~0c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var content interface{}
+			if err := json.Unmarshal([]byte(tt.content), &content); err != nil {
+				t.Fatalf("Test_unknownFields() failed to unmarshal the content: %v", err)
+			}
+			got := unknownFields(content, reflect.TypeOf(v2.DevfileV2{}), "")
+			assert.Equal(t, tt.want, got, "Test_unknownFields(): unknown fields should match")
+		})
+	}
+}
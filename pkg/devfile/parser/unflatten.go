@@ -0,0 +1,488 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	apiOverride "github.com/devfile/api/v2/pkg/utils/overriding"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+	"github.com/pkg/errors"
+)
+
+// topLevelLists are the lists of elements of a devfile that can be overridden, with the key of their elements
+var topLevelLists = map[string]string{
+	"components":      "name",
+	"commands":        "id",
+	"projects":        "name",
+	"starterProjects": "name",
+}
+
+// Unflatten returns the devfile data that reproduces the flattened content once its parent is merged, e.g. after the
+// flattened content of a devfile was edited. raw is the devfile as it was authored, and parent the content of its
+// resolved parent, before the parent overrides are applied. The elements of the parent are changed with the minimal
+// parent overrides, which replace the overrides of raw, and the other elements, variables, attributes and events are
+// defined in the returned devfile. The elements of raw left unchanged are kept as they were authored.
+//
+// The content is merged the way the parser merges the parent, and an error is returned if the changes cannot be made
+// with parent overrides, e.g. if elements or fields of the parent are removed. Devfiles with plugins are not supported.
+func Unflatten(raw data.DevfileData, parent *v1.DevWorkspaceTemplateSpecContent, flattened v1.DevWorkspaceTemplateSpecContent) (data.DevfileData, error) {
+	rawContent := raw.GetDevfileWorkspaceSpecContent()
+	for _, component := range rawContent.Components {
+		if component.Plugin != nil {
+			return nil, fmt.Errorf("devfiles with plugins cannot be unflattened, component %s is a plugin", component.Name)
+		}
+	}
+	rawParent := raw.GetParent()
+	if rawParent == nil || reflect.DeepEqual(rawParent, &v1.Parent{}) {
+		rawParent = nil
+		parent = nil
+	} else if parent == nil {
+		return nil, fmt.Errorf("the content of the parent of the devfile is required")
+	}
+
+	desired, err := normalizedContent(&flattened)
+	if err != nil {
+		return nil, err
+	}
+	base := map[string]interface{}{}
+	if parent != nil {
+		if base, err = normalizedContent(parent); err != nil {
+			return nil, err
+		}
+	}
+
+	overrides := map[string]interface{}{}
+	local := flattened.DeepCopy()
+	for list, key := range topLevelLists {
+		baseItems, _ := namedValues(listValue(base[list]))
+		desiredItems, _ := namedValues(listValue(desired[list]))
+		for name := range baseItems {
+			if _, ok := desiredItems[name]; !ok {
+				return nil, fmt.Errorf("%s %s of the parent cannot be removed with parent overrides", singular(list), name)
+			}
+		}
+		var items []interface{}
+		for _, name := range sortedNames(desiredItems) {
+			baseItem, inParent := baseItems[name]
+			if !inParent {
+				continue
+			}
+			override, err := overrideValue(list+"/"+name, baseItem, desiredItems[name])
+			if err != nil {
+				return nil, err
+			}
+			if override != nil {
+				override.(map[string]interface{})[key] = name
+				items = append(items, override)
+			}
+		}
+		if len(items) > 0 {
+			overrides[list] = items
+		}
+		removeElements(local, list, baseItems)
+	}
+	if err = localTopLevelValues(local, parent); err != nil {
+		return nil, err
+	}
+	keepAuthoredElements(local, rawContent)
+
+	unflattened := copyDevfileData(raw)
+	unflattened.SetDevfileWorkspaceSpecContent(*local)
+	if rawParent != nil {
+		var parentOverrides v1.ParentOverrides
+		content, err := json.Marshal(overrides)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(content, &parentOverrides); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the parent overrides")
+		}
+		unflattenedParent := rawParent.DeepCopy()
+		unflattenedParent.ParentOverrides = parentOverrides
+		unflattened.SetParent(unflattenedParent)
+	}
+
+	if err = checkUnflattened(unflattened, parent, desired); err != nil {
+		return nil, err
+	}
+	return unflattened, nil
+}
+
+// overrideValue returns the parent override of a JSON value of the parent to get the desired value, nil if the value
+// is unchanged. Objects are overridden field by field and the lists of named items, e.g. environment variables,
+// item by item, as they are merged by the parser. The other values are replaced.
+func overrideValue(path string, base interface{}, desired interface{}) (interface{}, error) {
+	if reflect.DeepEqual(base, desired) {
+		return nil, nil
+	}
+	baseMap, baseIsMap := base.(map[string]interface{})
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+	if baseIsMap && desiredIsMap {
+		override := map[string]interface{}{}
+		for key := range baseMap {
+			if _, ok := desiredMap[key]; !ok {
+				return nil, fmt.Errorf("field %s/%s of the parent cannot be removed with parent overrides", path, key)
+			}
+		}
+		for key, desiredValue := range desiredMap {
+			baseValue, inBase := baseMap[key]
+			if !inBase {
+				override[key] = desiredValue
+				continue
+			}
+			value, err := overrideValue(path+"/"+key, baseValue, desiredValue)
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				override[key] = value
+			}
+		}
+		return override, nil
+	}
+
+	baseList, baseIsList := base.([]interface{})
+	desiredList, desiredIsList := desired.([]interface{})
+	if baseIsList && desiredIsList && len(baseList) > 0 {
+		baseItems, baseNamed := namedValues(baseList)
+		desiredItems, desiredNamed := namedValues(desiredList)
+		if baseNamed && desiredNamed {
+			var override []interface{}
+			for name := range baseItems {
+				if _, ok := desiredItems[name]; !ok {
+					return nil, fmt.Errorf("item %s of field %s of the parent cannot be removed with parent overrides", name, path)
+				}
+			}
+			for _, desiredItem := range desiredList {
+				name := itemName(desiredItem)
+				value, err := overrideValue(path+"/"+name, baseItems[name], desiredItem)
+				if err != nil {
+					return nil, err
+				}
+				if value != nil {
+					value.(map[string]interface{})["name"] = name
+					override = append(override, value)
+				}
+			}
+			return override, nil
+		}
+	}
+	return desired, nil
+}
+
+// normalizedContent returns the JSON content of the devfile content as it is compared while unflattening: without the
+// attributes added by the parser, and with the boolean fields set to their default values
+func normalizedContent(content *v1.DevWorkspaceTemplateSpecContent) (map[string]interface{}, error) {
+	normalized := content.DeepCopy()
+	for i := range normalized.Components {
+		for _, field := range componentBooleans(&normalized.Components[i], "") {
+			defaultedFields(nil).setDefault("", field)
+		}
+		normalized.Components[i].Attributes = withoutInternalAttributes(normalized.Components[i].Attributes)
+	}
+	for i := range normalized.Commands {
+		for _, field := range commandBooleans(&normalized.Commands[i]) {
+			defaultedFields(nil).setDefault("", field)
+		}
+		normalized.Commands[i].Attributes = withoutInternalAttributes(normalized.Commands[i].Attributes)
+	}
+	for i := range normalized.Projects {
+		normalized.Projects[i].Attributes = withoutInternalAttributes(normalized.Projects[i].Attributes)
+	}
+	for i := range normalized.StarterProjects {
+		normalized.StarterProjects[i].Attributes = withoutInternalAttributes(normalized.StarterProjects[i].Attributes)
+	}
+	if normalized.Events != nil {
+		for _, events := range []*[]string{&normalized.Events.PreStart, &normalized.Events.PostStart, &normalized.Events.PreStop, &normalized.Events.PostStop} {
+			if len(*events) == 0 {
+				*events = nil
+			}
+			sort.Strings(*events)
+		}
+		if reflect.DeepEqual(normalized.Events, &v1.Events{}) {
+			normalized.Events = nil
+		}
+	}
+	jsonContent, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decodeJSONValue(jsonContent)
+	if err != nil {
+		return nil, err
+	}
+	normalizedMap, _ := value.(map[string]interface{})
+	sortNamedLists(normalizedMap)
+	return normalizedMap, nil
+}
+
+// sortNamedLists sorts the lists of named items of a JSON value by name, in place, as their order does not matter once
+// merged
+func sortNamedLists(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			sortNamedLists(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			sortNamedLists(item)
+		}
+		if _, named := namedValues(v); named {
+			sort.Slice(v, func(i, j int) bool { return itemName(v[i]) < itemName(v[j]) })
+		}
+	}
+}
+
+// removeElements removes the elements of the list of the content that are defined by the parent
+func removeElements(content *v1.DevWorkspaceTemplateSpecContent, list string, parentItems map[string]interface{}) {
+	inParent := func(name string) bool {
+		_, ok := parentItems[name]
+		return ok
+	}
+	switch list {
+	case "components":
+		var components []v1.Component
+		for _, component := range content.Components {
+			if !inParent(component.Name) {
+				components = append(components, component)
+			}
+		}
+		content.Components = components
+	case "commands":
+		var commands []v1.Command
+		for _, command := range content.Commands {
+			if !inParent(command.Id) {
+				commands = append(commands, command)
+			}
+		}
+		content.Commands = commands
+	case "projects":
+		var projects []v1.Project
+		for _, project := range content.Projects {
+			if !inParent(project.Name) {
+				projects = append(projects, project)
+			}
+		}
+		content.Projects = projects
+	case "starterProjects":
+		var starterProjects []v1.StarterProject
+		for _, project := range content.StarterProjects {
+			if !inParent(project.Name) {
+				starterProjects = append(starterProjects, project)
+			}
+		}
+		content.StarterProjects = starterProjects
+	}
+}
+
+// localTopLevelValues keeps the variables, attributes and events of the content that differ from the ones of the
+// parent, the local ones taking precedence over the ones of the parent when merged
+func localTopLevelValues(content *v1.DevWorkspaceTemplateSpecContent, parent *v1.DevWorkspaceTemplateSpecContent) error {
+	if parent == nil {
+		return nil
+	}
+	for name, value := range parent.Variables {
+		localValue, ok := content.Variables[name]
+		if !ok {
+			return fmt.Errorf("variable %s of the parent cannot be removed", name)
+		}
+		if localValue == value {
+			delete(content.Variables, name)
+		}
+	}
+	if len(content.Variables) == 0 {
+		content.Variables = nil
+	}
+	for name, value := range parent.Attributes {
+		localValue, ok := content.Attributes[name]
+		if !ok {
+			return fmt.Errorf("attribute %s of the parent cannot be removed", name)
+		}
+		if reflect.DeepEqual(localValue, value) {
+			delete(content.Attributes, name)
+		}
+	}
+	if len(content.Attributes) == 0 {
+		content.Attributes = nil
+	}
+	if parent.Events != nil {
+		if content.Events == nil {
+			content.Events = &v1.Events{}
+		}
+		for _, events := range []struct {
+			kind   string
+			parent []string
+			local  *[]string
+		}{
+			{"preStart", parent.Events.PreStart, &content.Events.PreStart},
+			{"postStart", parent.Events.PostStart, &content.Events.PostStart},
+			{"preStop", parent.Events.PreStop, &content.Events.PreStop},
+			{"postStop", parent.Events.PostStop, &content.Events.PostStop},
+		} {
+			local := map[string]bool{}
+			for _, command := range *events.local {
+				local[command] = true
+			}
+			for _, command := range events.parent {
+				if !local[command] {
+					return fmt.Errorf("%s event command %s of the parent cannot be removed", events.kind, command)
+				}
+				delete(local, command)
+			}
+			var kept []string
+			for _, command := range *events.local {
+				if local[command] {
+					kept = append(kept, command)
+				}
+			}
+			*events.local = kept
+		}
+		if reflect.DeepEqual(content.Events, &v1.Events{}) {
+			content.Events = nil
+		}
+	}
+	return nil
+}
+
+// keepAuthoredElements replaces the local elements of the content that are unchanged with their authored version, and
+// strips the attributes added by the parser and the boolean fields set to their default values from the changed ones
+func keepAuthoredElements(content *v1.DevWorkspaceTemplateSpecContent, raw *v1.DevWorkspaceTemplateSpecContent) {
+	rawComponents := map[string]v1.Component{}
+	for _, component := range raw.Components {
+		rawComponents[component.Name] = component
+	}
+	for i, component := range content.Components {
+		rawComponent, ok := rawComponents[component.Name]
+		if ok && equalNormalized(&v1.DevWorkspaceTemplateSpecContent{Components: []v1.Component{component}},
+			&v1.DevWorkspaceTemplateSpecContent{Components: []v1.Component{rawComponent}}) {
+			content.Components[i] = *rawComponent.DeepCopy()
+			continue
+		}
+		var authored []booleanField
+		if ok {
+			authored = componentBooleans(&rawComponent, "")
+		}
+		stripDefaults(componentBooleans(&content.Components[i], ""), authored)
+		content.Components[i].Attributes = withoutInternalAttributes(content.Components[i].Attributes)
+	}
+
+	rawCommands := map[string]v1.Command{}
+	for _, command := range raw.Commands {
+		rawCommands[command.Id] = command
+	}
+	for i, command := range content.Commands {
+		rawCommand, ok := rawCommands[command.Id]
+		if ok && equalNormalized(&v1.DevWorkspaceTemplateSpecContent{Commands: []v1.Command{command}},
+			&v1.DevWorkspaceTemplateSpecContent{Commands: []v1.Command{rawCommand}}) {
+			content.Commands[i] = *rawCommand.DeepCopy()
+			continue
+		}
+		var authored []booleanField
+		if ok {
+			authored = commandBooleans(&rawCommand)
+		}
+		stripDefaults(commandBooleans(&content.Commands[i]), authored)
+		content.Commands[i].Attributes = withoutInternalAttributes(content.Commands[i].Attributes)
+	}
+
+	for i := range content.Projects {
+		content.Projects[i].Attributes = withoutInternalAttributes(content.Projects[i].Attributes)
+	}
+	for i := range content.StarterProjects {
+		content.StarterProjects[i].Attributes = withoutInternalAttributes(content.StarterProjects[i].Attributes)
+	}
+}
+
+// stripDefaults unsets the boolean fields set to their default values, unless they were set in the authored element
+func stripDefaults(fields []booleanField, authored []booleanField) {
+	set := map[string]bool{}
+	for _, field := range authored {
+		if *field.field != nil {
+			set[field.path] = true
+		}
+	}
+	for _, field := range fields {
+		if !set[field.path] && *field.field != nil && **field.field == field.defaultValue() {
+			*field.field = nil
+		}
+	}
+}
+
+// equalNormalized returns true if both contents are equal once normalized
+func equalNormalized(a *v1.DevWorkspaceTemplateSpecContent, b *v1.DevWorkspaceTemplateSpecContent) bool {
+	normalizedA, errA := normalizedContent(a)
+	normalizedB, errB := normalizedContent(b)
+	return errA == nil && errB == nil && reflect.DeepEqual(normalizedA, normalizedB)
+}
+
+// checkUnflattened checks that merging the parent into the unflattened devfile gives the desired content
+func checkUnflattened(unflattened data.DevfileData, parent *v1.DevWorkspaceTemplateSpecContent, desired map[string]interface{}) error {
+	var flattenedParent *v1.DevWorkspaceTemplateSpecContent
+	if parent != nil {
+		flattenedParent = parent.DeepCopy()
+		if overrides := unflattened.GetParent().ParentOverrides; !reflect.DeepEqual(overrides, v1.ParentOverrides{}) {
+			var err error
+			flattenedParent, err = apiOverride.OverrideDevWorkspaceTemplateSpec(flattenedParent, overrides.DeepCopy())
+			if err != nil {
+				return errors.Wrapf(err, "failed to apply the parent overrides")
+			}
+		}
+	}
+	merged, err := apiOverride.MergeDevWorkspaceTemplateSpec(unflattened.GetDevfileWorkspaceSpecContent().DeepCopy(), flattenedParent)
+	if err != nil {
+		return errors.Wrapf(err, "failed to merge the parent")
+	}
+	normalized, err := normalizedContent(merged)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(normalized, desired) {
+		return fmt.Errorf("the content cannot be reproduced with parent overrides")
+	}
+	return nil
+}
+
+// listValue returns the JSON value as a list, nil if it is not a list
+func listValue(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
+
+// sortedNames returns the names of the items, sorted
+func sortedNames(items map[string]interface{}) []string {
+	var names []string
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// singular returns the name of an element of a top-level list
+func singular(list string) string {
+	switch list {
+	case "starterProjects":
+		return "starter project"
+	default:
+		return list[:len(list)-1]
+	}
+}
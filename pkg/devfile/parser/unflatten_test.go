@@ -0,0 +1,203 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+	"testing/fstest"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestUnflatten(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/devfile.yaml": &fstest.MapFile{Data: []byte(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: ../stacks/parent.yaml
+  components:
+  - name: tools
+    container:
+      memoryLimit: 1Gi
+components:
+- name: runtime
+  container:
+    image: quay.io/runtime
+commands:
+- id: run
+  exec:
+    component: runtime
+    commandLine: run
+`)},
+		"stacks/parent.yaml": &fstest.MapFile{Data: []byte(`schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: tools
+  container:
+    image: quay.io/tools
+    env:
+    - name: A
+      value: "1"
+- name: cache
+  volume:
+    size: 1Gi
+commands:
+- id: build
+  exec:
+    component: tools
+    commandLine: build
+`)},
+	}
+
+	tests := []struct {
+		name        string
+		edit        func(content *v1.DevWorkspaceTemplateSpecContent)
+		wantDevfile string
+		wantErr     string
+	}{
+		{
+			name: "unchanged",
+			edit: func(content *v1.DevWorkspaceTemplateSpecContent) {},
+			wantDevfile: `commands:
+- exec:
+    commandLine: run
+    component: runtime
+  id: run
+components:
+- container:
+    image: quay.io/runtime
+  name: runtime
+metadata:
+  name: main
+parent:
+  components:
+  - container:
+      memoryLimit: 1Gi
+    name: tools
+  uri: ../stacks/parent.yaml
+schemaVersion: 2.2.0
+`,
+		},
+		{
+			name: "changed parent and local elements",
+			edit: func(content *v1.DevWorkspaceTemplateSpecContent) {
+				for i := range content.Components {
+					switch content.Components[i].Name {
+					case "tools":
+						content.Components[i].Container.Image = "quay.io/tools:2.0"
+						content.Components[i].Container.Env = append(content.Components[i].Container.Env, v1.EnvVar{Name: "B", Value: "2"})
+					case "runtime":
+						content.Components[i].Container.MemoryLimit = "512Mi"
+					}
+				}
+				content.Commands = append(content.Commands, v1.Command{Id: "debug", CommandUnion: v1.CommandUnion{
+					Exec: &v1.ExecCommand{Component: "runtime", CommandLine: "debug"},
+				}})
+			},
+			wantDevfile: `commands:
+- exec:
+    commandLine: run
+    component: runtime
+  id: run
+- exec:
+    commandLine: debug
+    component: runtime
+  id: debug
+components:
+- container:
+    image: quay.io/runtime
+    memoryLimit: 512Mi
+  name: runtime
+metadata:
+  name: main
+parent:
+  components:
+  - container:
+      env:
+      - name: B
+        value: "2"
+      image: quay.io/tools:2.0
+      memoryLimit: 1Gi
+    name: tools
+  uri: ../stacks/parent.yaml
+schemaVersion: 2.2.0
+`,
+		},
+		{
+			name: "removed parent component",
+			edit: func(content *v1.DevWorkspaceTemplateSpecContent) {
+				var components []v1.Component
+				for _, component := range content.Components {
+					if component.Name != "cache" {
+						components = append(components, component)
+					}
+				}
+				content.Components = components
+			},
+			wantErr: "component cache of the parent cannot be removed with parent overrides",
+		},
+		{
+			name: "removed parent field",
+			edit: func(content *v1.DevWorkspaceTemplateSpecContent) {
+				for i := range content.Components {
+					if content.Components[i].Name == "tools" {
+						content.Components[i].Container.Env = nil
+					}
+				}
+			},
+			wantErr: "field components/tools/container/env of the parent cannot be removed with parent overrides",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			falseValue := false
+			flattened, err := ParseDevfile(ParserArgs{FS: fsys, Path: "project/devfile.yaml"})
+			if err != nil {
+				t.Fatalf("TestUnflatten() unexpected error parsing the flattened devfile: %v", err)
+			}
+			raw, err := ParseDevfile(ParserArgs{FS: fsys, Path: "project/devfile.yaml", FlattenedDevfile: &falseValue, SetBooleanDefaults: &falseValue})
+			if err != nil {
+				t.Fatalf("TestUnflatten() unexpected error parsing the raw devfile: %v", err)
+			}
+			parent, err := ParseDevfile(ParserArgs{FS: fsys, Path: "stacks/parent.yaml"})
+			if err != nil {
+				t.Fatalf("TestUnflatten() unexpected error parsing the parent devfile: %v", err)
+			}
+
+			content := flattened.Data.GetDevfileWorkspaceSpecContent()
+			tt.edit(content)
+			unflattened, err := Unflatten(raw.Data, parent.Data.GetDevfileWorkspaceSpecContent(), *content)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			devfile, err := yaml.Marshal(unflattened)
+			if err != nil {
+				t.Fatalf("TestUnflatten() unexpected error marshalling the devfile: %v", err)
+			}
+			assert.Equal(t, tt.wantDevfile, string(devfile), "TestUnflatten(): devfile should match")
+		})
+	}
+}
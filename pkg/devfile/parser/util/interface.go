@@ -15,9 +15,21 @@
 
 package util
 
-import "github.com/devfile/library/v2/pkg/util"
+import (
+	"context"
+
+	"github.com/devfile/library/v2/pkg/util"
+)
 
 type DevfileUtils interface {
 	DownloadGitRepoResources(url string, destDir string, token string) error
 	DownloadInMemory(params util.HTTPRequestParams) ([]byte, error)
 }
+
+// DevfileUtilsWithContext is implemented by DevfileUtils clients that can abort Git resource downloads
+// when the context passed in is cancelled or its deadline is exceeded.
+// In-memory downloads are bound to a context through util.HTTPRequestParams.Context.
+type DevfileUtilsWithContext interface {
+	DevfileUtils
+	DownloadGitRepoResourcesWithContext(ctx context.Context, url string, destDir string, token string) error
+}
@@ -16,6 +16,7 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -29,6 +30,8 @@ import (
 var DevfilePossibilities = [...]string{"devfile.yaml", ".devfile.yaml", "devfile.yml", ".devfile.yml"}
 
 type DevfileUtilsClient struct {
+	// Credentials provides the credentials of the downloads without a token by host, it may be nil
+	Credentials util.CredentialProvider
 }
 
 func NewDevfileUtilsClient() DevfileUtilsClient {
@@ -38,13 +41,28 @@ func NewDevfileUtilsClient() DevfileUtilsClient {
 // DownloadInMemory is a wrapper to the util.DownloadInMemory() call.
 // This is done to help devfile/library clients invoke this function with a client.
 func (c DevfileUtilsClient) DownloadInMemory(params util.HTTPRequestParams) ([]byte, error) {
+	if params.Credentials == nil {
+		params.Credentials = c.Credentials
+	}
 	return util.DownloadInMemory(params)
 }
 
 // DownloadGitRepoResources downloads the git repository resources
 func (c DevfileUtilsClient) DownloadGitRepoResources(url string, destDir string, token string) error {
+	return c.DownloadGitRepoResourcesWithContext(context.Background(), url, destDir, token)
+}
+
+// DownloadGitRepoResourcesWithContext mimics git clone functionality, aborting the clone if ctx is cancelled or its deadline is exceeded
+func (c DevfileUtilsClient) DownloadGitRepoResourcesWithContext(ctx context.Context, url string, destDir string, token string) error {
 	var returnedErr error
 	if util.IsGitProviderRepo(url) {
+		if token == "" {
+			credentials, err := util.CredentialsForURL(c.Credentials, url)
+			if err != nil {
+				return err
+			}
+			token = credentials.GitToken()
+		}
 		gitUrl, err := util.NewGitURL(url, token)
 		if err != nil {
 			return err
@@ -68,7 +86,7 @@ func (c DevfileUtilsClient) DownloadGitRepoResources(url string, destDir string,
 
 		gitUrl.Token = token
 
-		err = gitUrl.CloneGitRepo(stackDir)
+		err = gitUrl.CloneGitRepoWithContext(ctx, stackDir)
 		if err != nil {
 			returnedErr = multierror.Append(returnedErr, err)
 			return returnedErr
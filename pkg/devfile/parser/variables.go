@@ -0,0 +1,270 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/devfile/library/v2/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// VariableSourceExternal is the source of the variables set with ParserArgs.ExternalVariables
+	VariableSourceExternal = "external"
+	// VariableSourceDevfile is the source of the variables defined in the devfile
+	VariableSourceDevfile = "devfile"
+	// VariableSourceDefault is the source of the default values of variable references, e.g. {{name:-default}}
+	VariableSourceDefault = "default"
+)
+
+// ResolvedVariable is the value of a devfile variable along with where it came from
+type ResolvedVariable struct {
+	// Value is the value the references to the variable are replaced with
+	Value string
+	// Source is the name of the VariableProvider that supplied the value, or VariableSourceExternal, VariableSourceDevfile
+	// or VariableSourceDefault
+	Source string
+	// Sensitive is true if the value is sensitive, see ParserArgs.SensitiveVariables
+	Sensitive bool
+}
+
+// VariableLookup is the information available to a VariableProvider when looking up variables
+type VariableLookup struct {
+	// Context is the context of the lookup, context.Background() if ParserArgs.Context is not set
+	Context context.Context
+	// K8sClient is the Kubernetes client of ParserArgs.K8sClient
+	K8sClient client.Client
+	// DefaultNamespace is the default namespace of ParserArgs.DefaultNamespace
+	DefaultNamespace string
+}
+
+// VariableProvider supplies the values of devfile variables, e.g. from the environment or a Kubernetes Secret.
+// Providers are registered through ParserArgs.VariableProviders.
+type VariableProvider interface {
+	// Name identifies the provider in the ResolvedVariable it supplied
+	Name() string
+	// Lookup returns the values of the variables with the given names that the provider defines,
+	// variables it does not define are left out
+	Lookup(lookup VariableLookup, names []string) (map[string]string, error)
+}
+
+// SensitiveVariableProvider is implemented by the variable providers that may supply sensitive values, e.g. credentials
+type SensitiveVariableProvider interface {
+	VariableProvider
+	// Sensitive returns true if the values supplied by the provider are sensitive
+	Sensitive() bool
+}
+
+// EnvVariableProvider supplies the values of the environment variables named after the devfile variables
+type EnvVariableProvider struct {
+	// Prefix is prepended to the names of the devfile variables to get the names of the environment variables,
+	// e.g. DEVFILE_ to look up the variable PORT with DEVFILE_PORT
+	Prefix string
+}
+
+func (p EnvVariableProvider) Name() string {
+	if p.Prefix == "" {
+		return "env"
+	}
+	return "env:" + p.Prefix
+}
+
+func (p EnvVariableProvider) Lookup(_ VariableLookup, names []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, name := range names {
+		if value, ok := os.LookupEnv(p.Prefix + name); ok {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// DotEnvVariableProvider supplies the values of the variables defined in a .env file, one KEY=value per line.
+// Lines starting with # are comments, keys can be preceded by export, and values can be single or double quoted,
+// escape sequences being interpreted in double quoted values.
+type DotEnvVariableProvider struct {
+	// Path is the path of the .env file
+	Path string
+	// Optional ignores the file if it does not exist
+	Optional bool
+}
+
+func (p DotEnvVariableProvider) Name() string {
+	return "dotenv:" + p.Path
+}
+
+func (p DotEnvVariableProvider) Lookup(_ VariableLookup, names []string) (map[string]string, error) {
+	content, err := os.ReadFile(p.Path)
+	if err != nil {
+		if p.Optional && os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read %s", p.Path)
+	}
+	variables, err := parseDotEnv(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", p.Path)
+	}
+	return pickVariables(variables, names), nil
+}
+
+// parseDotEnv returns the variables defined in the content of a .env file
+func parseDotEnv(content []byte) (map[string]string, error) {
+	variables := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		key, value, found := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=value", line)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid double quoted value: %v", line, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			// unquoted values end with an inline comment
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		variables[key] = value
+	}
+	return variables, scanner.Err()
+}
+
+// SecretVariableProvider supplies the values of the keys of a Kubernetes Secret named after the devfile variables
+type SecretVariableProvider struct {
+	// SecretName is the name of the Secret
+	SecretName string
+	// Namespace is the namespace of the Secret, ParserArgs.DefaultNamespace or the current namespace is used if it is empty
+	Namespace string
+	// Optional ignores the Secret if it does not exist
+	Optional bool
+}
+
+func (p SecretVariableProvider) Name() string {
+	return "secret:" + namespacedName(p.Namespace, p.SecretName)
+}
+
+// Sensitive returns true, as Secrets hold sensitive data
+func (p SecretVariableProvider) Sensitive() bool {
+	return true
+}
+
+func (p SecretVariableProvider) Lookup(lookup VariableLookup, names []string) (map[string]string, error) {
+	var secret corev1.Secret
+	if err := getKubernetesObject(lookup, p.Namespace, p.SecretName, &secret); err != nil {
+		return nil, ignoreNotFound(err, p.Optional)
+	}
+	variables := map[string]string{}
+	for key, value := range secret.Data {
+		variables[key] = string(value)
+	}
+	for key, value := range secret.StringData {
+		variables[key] = value
+	}
+	return pickVariables(variables, names), nil
+}
+
+// ConfigMapVariableProvider supplies the values of the keys of a Kubernetes ConfigMap named after the devfile variables
+type ConfigMapVariableProvider struct {
+	// ConfigMapName is the name of the ConfigMap
+	ConfigMapName string
+	// Namespace is the namespace of the ConfigMap, ParserArgs.DefaultNamespace or the current namespace is used if it is empty
+	Namespace string
+	// Optional ignores the ConfigMap if it does not exist
+	Optional bool
+}
+
+func (p ConfigMapVariableProvider) Name() string {
+	return "configmap:" + namespacedName(p.Namespace, p.ConfigMapName)
+}
+
+func (p ConfigMapVariableProvider) Lookup(lookup VariableLookup, names []string) (map[string]string, error) {
+	var configMap corev1.ConfigMap
+	if err := getKubernetesObject(lookup, p.Namespace, p.ConfigMapName, &configMap); err != nil {
+		return nil, ignoreNotFound(err, p.Optional)
+	}
+	return pickVariables(configMap.Data, names), nil
+}
+
+// getKubernetesObject gets the object with the given name in namespace, or in the default namespace of the lookup if
+// namespace is empty
+func getKubernetesObject(lookup VariableLookup, namespace string, name string, obj client.Object) error {
+	if lookup.K8sClient == nil {
+		return fmt.Errorf("kubernetes client is required to look up variables in %s", name)
+	}
+	if namespace == "" {
+		var err error
+		namespace, err = kubernetesNamespace(lookup.DefaultNamespace)
+		if err != nil {
+			return err
+		}
+	}
+	return lookup.K8sClient.Get(util.GetContextOrBackground(lookup.Context), types.NamespacedName{Namespace: namespace, Name: name}, obj)
+}
+
+// ignoreNotFound returns nil if err is a not found error and the object is optional, and err otherwise
+func ignoreNotFound(err error, optional bool) error {
+	if optional && kerrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// namespacedName returns name prefixed with its namespace, if any
+func namespacedName(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// pickVariables returns the variables with the given names
+func pickVariables(variables map[string]string, names []string) map[string]string {
+	picked := map[string]string{}
+	for _, name := range names {
+		if value, ok := variables[name]; ok {
+			picked[name] = value
+		}
+	}
+	return picked
+}
@@ -0,0 +1,76 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseDotEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "empty file",
+			content: "",
+			want:    map[string]string{},
+		},
+		{
+			name:    "comments, blank lines and exports",
+			content: "# comment\n\nKEY=value\nexport EXPORTED=exported\n  SPACED = spaced value  \n",
+			want:    map[string]string{"KEY": "value", "EXPORTED": "exported", "SPACED": "spaced value"},
+		},
+		{
+			name:    "quoted values",
+			content: "DOUBLE=\"line\\nbreak # kept\"\nSINGLE='no \\n escape'\nEMPTY=\n",
+			want:    map[string]string{"DOUBLE": "line\nbreak # kept", "SINGLE": "no \\n escape", "EMPTY": ""},
+		},
+		{
+			name:    "inline comment",
+			content: "KEY=value # comment\nHASH=value#kept\n",
+			want:    map[string]string{"KEY": "value", "HASH": "value#kept"},
+		},
+		{
+			name:    "line without value",
+			content: "KEY=value\nINVALID\n",
+			wantErr: "line 2: expected KEY=value",
+		},
+		{
+			name:    "invalid double quoted value",
+			content: "KEY=\"\\q\"\n",
+			wantErr: "line 1: invalid double quoted value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDotEnv([]byte(tt.content))
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
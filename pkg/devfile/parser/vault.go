@@ -0,0 +1,209 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	parserUtil "github.com/devfile/library/v2/pkg/devfile/parser/util"
+	"github.com/devfile/library/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// vaultContentFile is the file of a vault entry holding downloaded content
+	vaultContentFile = "content"
+	// vaultResourcesDir is the directory of a vault entry holding downloaded resources
+	vaultResourcesDir = "resources"
+	// vaultReferenceFile is the file of a vault entry recording the reference it was vendored for
+	vaultReferenceFile = "reference"
+)
+
+// VendorDevfile parses the devfile like ParseDevfile, and stores every parent, plugin, Kubernetes component uri and
+// git resource fetched along the way in the vault directory vaultDir, which is created if needed.
+// The devfile can then be parsed without network access by passing vaultDir in ParserArgs.OfflineVault.
+// Devfiles imported through custom resolvers or from Kubernetes DevWorkspaceTemplates are not vendored.
+func VendorDevfile(args ParserArgs, vaultDir string) (DevfileObj, error) {
+	if vaultDir == "" {
+		return DevfileObj{}, errors.New("the vault directory is not provided")
+	}
+	if args.OfflineVault != "" {
+		return DevfileObj{}, errors.New("cannot vendor a devfile while parsing offline")
+	}
+	if err := os.MkdirAll(vaultDir, 0750); err != nil {
+		return DevfileObj{}, errors.Wrapf(err, "failed to create vault %s", vaultDir)
+	}
+	return parseDevfileWithVault(args, &vault{dir: vaultDir, vendor: true})
+}
+
+// vault stores the remote content fetched while parsing a devfile on disk, keyed by the request made for it.
+// Each entry is a directory named after the sha256 of its key.
+type vault struct {
+	// dir is the directory of the vault
+	dir string
+	// vendor stores the fetched content in the vault if true, content is only served from the vault otherwise
+	vendor bool
+}
+
+// servedDevfile returns true if the vault serves the devfile d resolved with resolver, i.e. if parsing offline a devfile
+// fetched by one of the default resolvers from a URL, a registry or an OCI registry
+func (v *vault) servedDevfile(resolver Resolver, d DevfileObj) bool {
+	if v == nil || v.vendor || d.Ctx.GetAbsPath() != "" {
+		return false
+	}
+	switch resolver.(type) {
+	case URIResolver, RegistryResolver, OCIResolver:
+		return true
+	}
+	return false
+}
+
+// vendoring returns true if the vault stores the fetched content, false when parsing offline or without a vault
+func (v *vault) vendoring() bool {
+	return v != nil && v.vendor
+}
+
+// entryDir returns the directory of the vault entry for key
+func (v *vault) entryDir(key string) string {
+	return filepath.Join(v.dir, fmt.Sprintf("%x", sha256.Sum256([]byte(key))))
+}
+
+// content returns the content stored for key. When vendoring, the content is fetched and stored first.
+// A nil vault only fetches the content.
+func (v *vault) content(key string, reference string, fetch func() ([]byte, error)) ([]byte, error) {
+	if v == nil {
+		return fetch()
+	}
+	contentPath := filepath.Join(v.entryDir(key), vaultContentFile)
+	if !v.vendor {
+		data, err := os.ReadFile(filepath.Clean(contentPath))
+		if os.IsNotExist(err) {
+			return nil, &errPkg.OfflineCacheMiss{Reference: reference, Vault: v.dir}
+		}
+		return data, err
+	}
+
+	data, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	err = v.store(key, reference, vaultContentFile, func(tmpPath string) error {
+		return os.WriteFile(tmpPath, data, 0640)
+	})
+	return data, err
+}
+
+// directory populates the empty directory destDir with the resources stored for key. When vendoring, the resources
+// are fetched to destDir and stored first. A nil vault only fetches the resources.
+func (v *vault) directory(key string, reference string, destDir string, fetch func(dir string) error) error {
+	if v == nil {
+		return fetch(destDir)
+	}
+	resourcesDir := filepath.Join(v.entryDir(key), vaultResourcesDir)
+	if !v.vendor {
+		if !util.CheckPathExists(resourcesDir) {
+			return &errPkg.OfflineCacheMiss{Reference: reference, Vault: v.dir}
+		}
+		return copyDir(resourcesDir, destDir)
+	}
+
+	if err := fetch(destDir); err != nil {
+		return err
+	}
+	return v.store(key, reference, vaultResourcesDir, func(tmpPath string) error {
+		return copyDir(destDir, tmpPath)
+	})
+}
+
+// store writes an entry file or directory with write, then moves it in place so that a partially written entry is never
+// served. An entry that is already stored is kept.
+func (v *vault) store(key string, reference string, name string, write func(tmpPath string) error) error {
+	entryDir := v.entryDir(key)
+	if err := os.MkdirAll(entryDir, 0750); err != nil {
+		return errors.Wrapf(err, "failed to create vault entry for %s", reference)
+	}
+	if err := os.WriteFile(filepath.Join(entryDir, vaultReferenceFile), []byte(reference+"\n"), 0640); err != nil {
+		return errors.Wrapf(err, "failed to create vault entry for %s", reference)
+	}
+	tmpDir, err := os.MkdirTemp(entryDir, "tmp-")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create vault entry for %s", reference)
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpPath := filepath.Join(tmpDir, name)
+	if err = write(tmpPath); err != nil {
+		return errors.Wrapf(err, "failed to store %s in the vault", reference)
+	}
+	finalPath := filepath.Join(entryDir, name)
+	if err = os.Rename(tmpPath, finalPath); err != nil && !util.CheckPathExists(finalPath) {
+		return errors.Wrapf(err, "failed to store %s in the vault", reference)
+	}
+	return nil
+}
+
+// copyDir recursively copies every file of srcDir to destDir
+func copyDir(srcDir, destDir string) error {
+	return filepath.WalkDir(srcDir, func(srcPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(srcDir, srcPath)
+		if err != nil {
+			return err
+		}
+		destPath := filepath.Join(destDir, relPath)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(destPath, info.Mode())
+		}
+		return util.CopyFile(srcPath, destPath, info)
+	})
+}
+
+// vaultDevfileUtils serves the downloads made through a DevfileUtils client from a vault
+type vaultDevfileUtils struct {
+	vault  *vault
+	client parserUtil.DevfileUtils
+}
+
+func (c vaultDevfileUtils) DownloadInMemory(params util.HTTPRequestParams) ([]byte, error) {
+	return c.vault.content("url "+params.URL, params.URL, func() ([]byte, error) {
+		return c.client.DownloadInMemory(params)
+	})
+}
+
+func (c vaultDevfileUtils) DownloadGitRepoResources(url string, destDir string, token string) error {
+	resourcesDir, err := os.MkdirTemp(os.TempDir(), "vault-git-resources")
+	if err != nil {
+		return fmt.Errorf("failed to create dir: %s, error: %v", resourcesDir, err)
+	}
+	defer os.RemoveAll(resourcesDir)
+	err = c.vault.directory("git "+url, "git resources of "+url, resourcesDir, func(dir string) error {
+		return c.client.DownloadGitRepoResources(url, dir, token)
+	})
+	if err != nil {
+		return err
+	}
+	return util.CopyAllDirFiles(resourcesDir, destDir)
+}
@@ -0,0 +1,178 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVendorDevfile_Offline(t *testing.T) {
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+- name: parent-runtime
+  container:
+    image: quay.io/parent-image
+`
+	const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deploy
+`
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data string
+		switch r.URL.Path {
+		case "/parent.yaml":
+			data = parentDevfile
+		case "/deploy.yaml":
+			data = deployment
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(data))
+		if err != nil {
+			t.Errorf("TestVendorDevfile_Offline() unexpected error while writing data: %v", err)
+		}
+	}))
+	serverURL := testServer.URL
+
+	mainDevfile := []byte(fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: %[1]s/parent.yaml
+components:
+- name: deploy
+  kubernetes:
+    uri: %[1]s/deploy.yaml
+`, serverURL))
+
+	vaultDir := filepath.Join(t.TempDir(), "vault")
+	_, err := VendorDevfile(ParserArgs{Data: mainDevfile, DownloadGitResources: &isFalse}, vaultDir)
+	if err != nil {
+		t.Fatalf("TestVendorDevfile_Offline() unexpected error vendoring the devfile: %v", err)
+	}
+	testServer.Close()
+
+	tests := []struct {
+		name           string
+		devfile        []byte
+		wantComponents []string
+		wantMissing    string
+	}{
+		{
+			name:           "should parse the vendored devfile offline",
+			devfile:        mainDevfile,
+			wantComponents: []string{"parent-runtime", "deploy"},
+		},
+		{
+			name: "should fail with a cache miss for a parent that was not vendored",
+			devfile: []byte(fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: %s/other.yaml
+`, serverURL)),
+			wantMissing: serverURL + "/other.yaml",
+		},
+		{
+			name: "should fail with a cache miss for a Kubernetes component uri that was not vendored",
+			devfile: []byte(fmt.Sprintf(`schemaVersion: 2.2.0
+metadata:
+  name: main
+components:
+- name: deploy
+  kubernetes:
+    uri: %s/other-deploy.yaml
+`, serverURL)),
+			wantMissing: serverURL + "/other-deploy.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDevfile(ParserArgs{Data: tt.devfile, OfflineVault: vaultDir, DownloadGitResources: &isFalse, RecordProvenance: true})
+			if tt.wantMissing != "" {
+				var cacheMissErr *errPkg.OfflineCacheMiss
+				if assert.True(t, errors.As(err, &cacheMissErr), "TestVendorDevfile_Offline(): expected an OfflineCacheMiss error, got: %v", err) {
+					assert.Equal(t, tt.wantMissing, cacheMissErr.Reference)
+					assert.Equal(t, vaultDir, cacheMissErr.Vault)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestVendorDevfile_Offline() unexpected error: %v", err)
+			}
+
+			components, err := d.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("TestVendorDevfile_Offline() unexpected error getting components: %v", err)
+			}
+			var names []string
+			for _, component := range components {
+				names = append(names, component.Name)
+				if component.Kubernetes != nil {
+					assert.Equal(t, deployment, component.Kubernetes.Inlined, "TestVendorDevfile_Offline(): Kubernetes uri should be served from the vault")
+				}
+			}
+			assert.ElementsMatch(t, tt.wantComponents, names, "TestVendorDevfile_Offline(): flattened components should match")
+			if assert.Len(t, d.Provenance.Root.Imports, 1) {
+				assert.True(t, d.Provenance.Root.Imports[0].CacheHit, "TestVendorDevfile_Offline(): the parent should be reported as served from the vault")
+			}
+		})
+	}
+}
+
+func TestVault_directory(t *testing.T) {
+	vaultDir := t.TempDir()
+	fetched := 0
+	fetch := func(dir string) error {
+		fetched++
+		if err := os.MkdirAll(filepath.Join(dir, "kubernetes"), 0750); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, "kubernetes", "deploy.yaml"), []byte("kind: Deployment"), 0600)
+	}
+
+	vendorDir := t.TempDir()
+	err := (&vault{dir: vaultDir, vendor: true}).directory("oci example", "oci://example", vendorDir, fetch)
+	if err != nil {
+		t.Fatalf("TestVault_directory() unexpected error vendoring: %v", err)
+	}
+
+	offline := &vault{dir: vaultDir}
+	offlineDir := t.TempDir()
+	err = offline.directory("oci example", "oci://example", offlineDir, fetch)
+	if err != nil {
+		t.Fatalf("TestVault_directory() unexpected error reading the vault: %v", err)
+	}
+	assert.Equal(t, 1, fetched, "TestVault_directory(): resources should only be fetched when vendoring")
+	assert.FileExists(t, filepath.Join(offlineDir, "kubernetes", "deploy.yaml"), "TestVault_directory(): resources should be served from the vault")
+
+	err = offline.directory("oci other", "oci://other", t.TempDir(), fetch)
+	assert.Equal(t, &errPkg.OfflineCacheMiss{Reference: "oci://other", Vault: vaultDir}, err)
+}
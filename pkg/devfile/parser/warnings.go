@@ -0,0 +1,123 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"sync"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/devfile/library/v2/pkg/util"
+)
+
+// issueCollector collects the issues found while resolving a devfile that do not stop the resolution, e.g. warnings
+type issueCollector struct {
+	// mu guards issues, as downloads made concurrently can report issues
+	mu sync.Mutex
+	// issues are the issues found, in resolution order
+	issues errPkg.Issues
+}
+
+// add records an issue, as a warning if its severity is not set
+func (c *issueCollector) add(issue *errPkg.Issue) {
+	if c == nil {
+		return
+	}
+	if issue.Severity == "" {
+		issue.Severity = errPkg.SeverityWarning
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.issues = append(c.issues, issue)
+}
+
+// fork returns a collector recording the issues found in a branch of the resolution tree, so that branches can be
+// resolved concurrently. The issues of the fork are added back with join.
+func (c *issueCollector) fork() *issueCollector {
+	if c == nil {
+		return nil
+	}
+	return &issueCollector{}
+}
+
+// join adds the issues recorded by forks, in order
+func (c *issueCollector) join(forks ...*issueCollector) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, fork := range forks {
+		c.issues = append(c.issues, fork.list()...)
+	}
+}
+
+// recorded returns the number of issues recorded so far
+func (c *issueCollector) recorded() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.issues)
+}
+
+// setImportReference sets the import reference of the issues recorded after the first ones that do not have one,
+// i.e. of the issues found in the devfile imported with importReference
+func (c *issueCollector) setImportReference(first int, importReference v1.ImportReference) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, issue := range c.issues[first:] {
+		if issue.ImportReference == "" {
+			issue.ImportReference = resolveImportReference(importReference)
+		}
+	}
+}
+
+// list returns the issues recorded
+func (c *issueCollector) list() errPkg.Issues {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(errPkg.Issues(nil), c.issues...)
+}
+
+// checkHTTPTimeout records a warning if httpTimeout is set to a value that is not used
+func (c *issueCollector) checkHTTPTimeout(httpTimeout *int) {
+	if httpTimeout == nil || *httpTimeout > 0 {
+		return
+	}
+	c.add(&errPkg.Issue{
+		Code:    errPkg.CodeInvalidHTTPTimeout,
+		Message: fmt.Sprintf("invalid HTTP timeout %d, the default timeout of %s is used", *httpTimeout, util.HTTPRequestResponseTimeout),
+	})
+}
+
+// httpWarning returns the callback recording the non-fatal errors of the requests made to url
+func (c *issueCollector) httpWarning(url string) func(error) {
+	return func(err error) {
+		c.add(&errPkg.Issue{
+			Code:    errPkg.CodeHTTPCacheUnavailable,
+			Message: fmt.Sprintf("the response of %s is not cached: %v", url, err),
+		})
+	}
+}
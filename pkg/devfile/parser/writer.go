@@ -27,6 +27,12 @@ import (
 )
 
 // WriteYamlDevfile writes the content of the Devfile data to its absolute path on the filesystem.
+// The values of the sensitive variables are written as placeholders to the variables, e.g. {{name}}, in the fields whose
+// authored value references them.
+// If the devfile was read from YAML content, only the changes made to the data since it was parsed, see SetUnchanged,
+// are written: the original content is patched, keeping its comments, key ordering, anchors and the fields that were
+// left to their default values. The changes made to the elements of the parent of a flattened devfile are written as
+// parent overrides, the devfile being written flattened if its changes cannot be written this way, see Unflatten.
 func (d *DevfileObj) WriteYamlDevfile() error {
 
 	// Check kubernetes components, and restore original uri content
@@ -36,10 +42,21 @@ func (d *DevfileObj) WriteYamlDevfile() error {
 			return errors.Wrapf(err, "failed to restore kubernetes component uri field")
 		}
 	}
-	// Encode data into YAML format
-	yamlData, err := yaml.Marshal(d.Data)
+	// Restore the placeholders of the sensitive variables
+	data, err := d.redactedData()
 	if err != nil {
-		return errors.Wrapf(err, "failed to marshal devfile object into yaml")
+		return errors.Wrapf(err, "failed to redact sensitive variables")
+	}
+	// Patch the original content with the changes, or encode data into YAML format
+	yamlData, err := d.patchedContent(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to patch the original devfile content")
+	}
+	if yamlData == nil {
+		yamlData, err = yaml.Marshal(data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal devfile object into yaml")
+		}
 	}
 	// Write to the absolute path
 	fs := d.Ctx.GetFs()
@@ -17,6 +17,8 @@ package parser
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -25,7 +27,9 @@ import (
 	devfilepkg "github.com/devfile/api/v2/pkg/devfile"
 	devfileCtx "github.com/devfile/library/v2/pkg/devfile/parser/context"
 	v2 "github.com/devfile/library/v2/pkg/devfile/parser/data/v2"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
 	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestDevfileObj_WriteYamlDevfile(t *testing.T) {
@@ -149,3 +153,121 @@ func TestDevfileObj_WriteYamlDevfile(t *testing.T) {
 		})
 	}
 }
+
+func TestDevfileObj_WriteYamlDevfile_WithParent(t *testing.T) {
+	const parentDevfile = `schemaVersion: 2.2.0
+metadata:
+  name: parent
+components:
+  - name: runtime
+    container:
+      image: quay.io/runtime:1.0
+      env:
+        - name: DEBUG
+          value: "false"
+`
+	const devfile = `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+# the local tools
+components:
+  - name: tools
+    container:
+      image: quay.io/tools:1.0
+`
+	downloadGitResources := false
+
+	tests := []struct {
+		name        string
+		update      func(d DevfileObj) error
+		wantDevfile string
+		wantEnv     map[string][]v1.EnvVar
+	}{
+		{
+			name:        "unchanged devfile",
+			update:      func(d DevfileObj) error { return nil },
+			wantDevfile: devfile,
+			wantEnv: map[string][]v1.EnvVar{
+				"runtime": {{Name: "DEBUG", Value: "false"}},
+				"tools":   nil,
+			},
+		},
+		{
+			name: "changed parent and local components",
+			update: func(d DevfileObj) error {
+				return d.AddEnvVars(map[string][]v1.EnvVar{
+					"runtime": {{Name: "PORT", Value: "8080"}},
+					"tools":   {{Name: "TOOLS", Value: "all"}},
+				})
+			},
+			wantDevfile: `schemaVersion: 2.2.0
+metadata:
+  name: main
+parent:
+  uri: parent.yaml
+  components:
+    - container:
+        env:
+          - name: PORT
+            value: "8080"
+      name: runtime
+# the local tools
+components:
+  - name: tools
+    container:
+      image: quay.io/tools:1.0
+      env:
+        - name: TOOLS
+          value: all
+`,
+			wantEnv: map[string][]v1.EnvVar{
+				"runtime": {{Name: "PORT", Value: "8080"}, {Name: "DEBUG", Value: "false"}},
+				"tools":   {{Name: "TOOLS", Value: "all"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "parent.yaml"), []byte(parentDevfile), 0600); err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_WithParent() failed to write the parent devfile: %v", err)
+			}
+			devfilePath := filepath.Join(dir, "devfile.yaml")
+			if err := os.WriteFile(devfilePath, []byte(devfile), 0600); err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_WithParent() failed to write the devfile: %v", err)
+			}
+			args := ParserArgs{Path: devfilePath, DownloadGitResources: &downloadGitResources}
+			d, err := ParseDevfile(args)
+			if err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_WithParent() unexpected error parsing the devfile: %v", err)
+			}
+			if err = tt.update(d); err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_WithParent() unexpected error updating the devfile: %v", err)
+			}
+			if err = d.WriteYamlDevfile(); err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_WithParent() unexpected error writing the devfile: %v", err)
+			}
+			written, err := os.ReadFile(devfilePath)
+			if err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_WithParent() failed to read the written devfile: %v", err)
+			}
+			assert.Equal(t, tt.wantDevfile, string(written), "TestDevfileObj_WriteYamlDevfile_WithParent(): devfile should match")
+
+			reparsed, err := ParseDevfile(args)
+			if err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_WithParent() unexpected error parsing the written devfile: %v", err)
+			}
+			components, err := reparsed.Data.GetComponents(common.DevfileOptions{})
+			if err != nil {
+				t.Fatalf("TestDevfileObj_WriteYamlDevfile_WithParent() unexpected error getting the components: %v", err)
+			}
+			env := map[string][]v1.EnvVar{}
+			for _, component := range components {
+				env[component.Name] = component.Container.Env
+			}
+			assert.Equal(t, tt.wantEnv, env, "TestDevfileObj_WriteYamlDevfile_WithParent(): environment variables should match")
+		})
+	}
+}
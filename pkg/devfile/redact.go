@@ -0,0 +1,100 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devfile
+
+import (
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/hashicorp/go-multierror"
+)
+
+// redactedError is an error whose message has the values of the sensitive variables redacted
+type redactedError struct {
+	message string
+	err     error
+	// redact redacts the values of the sensitive variables from the errors wrapped by err
+	redact func(err error) error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the errors wrapped by the original error, with the values of the sensitive variables redacted as well,
+// so that the original error is not exposed by errors.Unwrap or errors.As
+func (e *redactedError) Unwrap() []error {
+	var wrapped []error
+	switch err := e.err.(type) {
+	case interface{ Unwrap() []error }:
+		wrapped = err.Unwrap()
+	case interface{ Unwrap() error }:
+		if unwrapped := err.Unwrap(); unwrapped != nil {
+			wrapped = []error{unwrapped}
+		}
+	}
+	var redacted []error
+	for _, err := range wrapped {
+		redacted = append(redacted, e.redact(err))
+	}
+	return redacted
+}
+
+// redactError returns err with the values of the sensitive variables of d redacted from its message and from the errors
+// it wraps. The errors of the library keep their type, the other errors are replaced by a redacted error.
+func redactError(d parser.DevfileObj, err error) error {
+	if err == nil {
+		return nil
+	}
+	message := d.Redact(err.Error())
+	if message == err.Error() {
+		return err
+	}
+	switch err := err.(type) {
+	case *errPkg.NonCompliantDevfile:
+		issues := redactIssues(d, err.Issues)
+		return &errPkg.NonCompliantDevfile{Err: d.Redact(err.Err), Issues: issues}
+	case errPkg.Issues:
+		return redactIssues(d, err)
+	case *errPkg.Issue:
+		return redactIssues(d, errPkg.Issues{err})[0]
+	case *multierror.Error:
+		redacted := &multierror.Error{ErrorFormat: err.ErrorFormat}
+		for _, err := range err.Errors {
+			redacted.Errors = append(redacted.Errors, redactError(d, err))
+		}
+		return redacted
+	}
+	return &redactedError{message: message, err: err, redact: func(err error) error {
+		return redactError(d, err)
+	}}
+}
+
+// redactIssues returns issues with the values of the sensitive variables of d redacted from their messages and from the
+// errors they were found from. The issues are copied rather than modified, as they can be shared with the parser cache.
+func redactIssues(d parser.DevfileObj, issues errPkg.Issues) errPkg.Issues {
+	var redacted errPkg.Issues
+	for _, issue := range issues {
+		message := d.Redact(issue.Message)
+		if message != issue.Message || (issue.Err != nil && d.Redact(issue.Err.Error()) != issue.Err.Error()) {
+			copied := *issue
+			copied.Message = message
+			copied.Err = redactError(d, issue.Err)
+			issue = &copied
+		}
+		redacted = append(redacted, issue)
+	}
+	return redacted
+}
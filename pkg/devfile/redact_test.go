@@ -0,0 +1,287 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devfile
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/devfile/library/v2/pkg/testingutil"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseDevfileAndValidate_SensitiveVariables(t *testing.T) {
+	const devfile = `schemaVersion: 2.2.0
+metadata:
+  name: main
+variables:
+  USER: devfile-user
+  PASSWORD: devfile-password
+components:
+  - name: runtime
+    container:
+      image: quay.io/runtime
+commands:
+  - id: run
+    exec:
+      component: runtime
+      commandLine: "login {{USER}}:{{PASSWORD}} --token {{TOKEN}} --region {{REGION}}"
+`
+	falseValue := false
+	newArgs := func(t *testing.T, content string) parser.ParserArgs {
+		dir := t.TempDir()
+		devfilePath := filepath.Join(dir, "devfile.yaml")
+		if err := os.WriteFile(devfilePath, []byte(content), 0600); err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_SensitiveVariables() failed to write the devfile: %v", err)
+		}
+		return parser.ParserArgs{
+			Path:                 devfilePath,
+			DownloadGitResources: &falseValue,
+			Context:              context.Background(),
+			K8sClient: &testingutil.FakeK8sClient{
+				ExpectedNamespace: "my-namespace",
+				Secrets: map[string]corev1.Secret{
+					"credentials": {Data: map[string][]byte{"TOKEN": []byte("s3cr3t-token")}},
+				},
+			},
+			DefaultNamespace:   "my-namespace",
+			ExternalVariables:  map[string]string{"PASSWORD": "external-password", "REGION": "eu-west-1"},
+			VariableProviders:  []parser.VariableProvider{parser.SecretVariableProvider{SecretName: "credentials"}},
+			SensitiveVariables: []string{"USER", "PASSWORD"},
+		}
+	}
+
+	t.Run("substituted and written as placeholders", func(t *testing.T) {
+		args := newArgs(t, devfile)
+		d, _, err := ParseDevfileAndValidate(args)
+		if err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_SensitiveVariables() unexpected error: %v", err)
+		}
+		commands, err := d.Data.GetCommands(common.DevfileOptions{})
+		if err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_SensitiveVariables() unexpected error getting the commands: %v", err)
+		}
+		assert.Equal(t, "login devfile-user:external-password --token s3cr3t-token --region eu-west-1", commands[0].Exec.CommandLine,
+			"TestParseDevfileAndValidate_SensitiveVariables(): sensitive variables should be substituted")
+		assert.Equal(t, map[string]parser.ResolvedVariable{
+			"USER":     {Value: "devfile-user", Source: parser.VariableSourceDevfile, Sensitive: true},
+			"PASSWORD": {Value: "external-password", Source: parser.VariableSourceExternal, Sensitive: true},
+			"TOKEN":    {Value: "s3cr3t-token", Source: "secret:credentials", Sensitive: true},
+			"REGION":   {Value: "eu-west-1", Source: parser.VariableSourceExternal},
+		}, d.Variables, "TestParseDevfileAndValidate_SensitiveVariables(): resolved variables should match")
+		assert.Equal(t, map[string]string{
+			"USER":     "devfile-user",
+			"PASSWORD": "devfile-password",
+			"REGION":   "eu-west-1",
+		}, d.Data.GetDevfileWorkspaceSpec().Variables,
+			"TestParseDevfileAndValidate_SensitiveVariables(): sensitive values should not be kept as variables")
+
+		// the changed fields are written with placeholders, the others as they are
+		commands[0].Exec.CommandLine += " --verbose"
+		if err = d.Data.UpdateCommand(commands[0]); err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_SensitiveVariables() unexpected error updating the command: %v", err)
+		}
+		if err = d.WriteYamlDevfile(); err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_SensitiveVariables() unexpected error writing the devfile: %v", err)
+		}
+		written, err := os.ReadFile(args.Path)
+		if err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_SensitiveVariables() failed to read the written devfile: %v", err)
+		}
+		assert.Contains(t, string(written), `commandLine: "login {{USER}}:{{PASSWORD}} --token {{TOKEN}} --region eu-west-1 --verbose"`,
+			"TestParseDevfileAndValidate_SensitiveVariables(): sensitive values should be written as placeholders")
+		assert.Contains(t, string(written), "PASSWORD: devfile-password",
+			"TestParseDevfileAndValidate_SensitiveVariables(): devfile variables should be written as they are")
+		assert.NotContains(t, string(written), "external-password",
+			"TestParseDevfileAndValidate_SensitiveVariables(): sensitive values should not be written")
+		assert.NotContains(t, string(written), "s3cr3t-token",
+			"TestParseDevfileAndValidate_SensitiveVariables(): sensitive values should not be written")
+		assert.Equal(t, "token {{TOKEN}}", d.Redact("token s3cr3t-token"),
+			"TestParseDevfileAndValidate_SensitiveVariables(): sensitive values should be redacted")
+	})
+
+	t.Run("redacted from warnings", func(t *testing.T) {
+		args := newArgs(t, strings.Replace(devfile, "commands:", `  - name: builder
+    image:
+      imageName: "{{TOKEN}}"
+      dockerfile:
+        uri: Dockerfile
+commands:`, 1))
+		args.ImageNamesAsSelector = &parser.ImageSelectorArgs{Registry: "localhost:5000"}
+		d, _, err := ParseDevfileAndValidate(args)
+		if err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_SensitiveVariables() unexpected error: %v", err)
+		}
+		if assert.Len(t, d.Warnings, 1) {
+			assert.Equal(t, "the image {{TOKEN}} of component builder does not match the image of any component", d.Warnings[0].Message)
+		}
+	})
+
+	t.Run("redacted from errors", func(t *testing.T) {
+		args := newArgs(t, strings.Replace(devfile, "commands:", `  - name: data
+    volume:
+      size: "{{TOKEN}}"
+commands:`, 1))
+		_, _, err := ParseDevfileAndValidate(args)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "{{TOKEN}}")
+			assert.NotContains(t, err.Error(), "s3cr3t-token")
+			assert.NotContains(t, errPkg.GetIssues(err).Error(), "s3cr3t-token")
+			assertRedacted(t, err, "s3cr3t-token")
+			for _, issue := range errPkg.GetIssues(err) {
+				assertRedacted(t, issue, "s3cr3t-token")
+			}
+		}
+	})
+}
+
+func TestParseDevfileAndValidate_ShortSensitiveValue(t *testing.T) {
+	const devfile = `schemaVersion: 2.2.0
+metadata:
+  name: dev-app
+components:
+  - name: dev-runtime
+    container:
+      image: quay.io/devtools/runtime
+      env:
+        - name: TOKEN
+          value: "{{token}}"
+commands:
+  - id: dev-run
+    exec:
+      component: dev-runtime
+      commandLine: "login --token {{token}} --env devel"
+`
+	dir := t.TempDir()
+	devfilePath := filepath.Join(dir, "devfile.yaml")
+	if err := os.WriteFile(devfilePath, []byte(devfile), 0600); err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_ShortSensitiveValue() failed to write the devfile: %v", err)
+	}
+	falseValue := false
+	args := parser.ParserArgs{
+		Path:                 devfilePath,
+		DownloadGitResources: &falseValue,
+		ExternalVariables:    map[string]string{"token": "dev"},
+		SensitiveVariables:   []string{"token"},
+	}
+	d, _, err := ParseDevfileAndValidate(args)
+	if err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_ShortSensitiveValue() unexpected error: %v", err)
+	}
+
+	var encoded bytes.Buffer
+	if err = d.EncodeYaml(&encoded, parser.EncodeOptions{Minimal: true}); err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_ShortSensitiveValue() unexpected error encoding the devfile: %v", err)
+	}
+	for _, want := range []string{"name: dev-app", "name: dev-runtime", "image: quay.io/devtools/runtime", "value: '{{token}}'",
+		"commandLine: login --token {{token}} --env devel", "id: dev-run", "component: dev-runtime"} {
+		assert.Contains(t, encoded.String(), want, "TestParseDevfileAndValidate_ShortSensitiveValue(): only the authored references should be restored")
+	}
+	if err = os.WriteFile(devfilePath, encoded.Bytes(), 0600); err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_ShortSensitiveValue() failed to write the encoded devfile: %v", err)
+	}
+	_, _, err = ParseDevfileAndValidate(args)
+	assert.NoError(t, err, "TestParseDevfileAndValidate_ShortSensitiveValue(): the encoded devfile should parse")
+
+	if err = d.Data.AddEnvVars(map[string][]v1.EnvVar{"dev-runtime": {{Name: "STAGE", Value: "devops"}}}); err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_ShortSensitiveValue() unexpected error adding an env var: %v", err)
+	}
+	if err = d.WriteYamlDevfile(); err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_ShortSensitiveValue() unexpected error writing the devfile: %v", err)
+	}
+	written, err := os.ReadFile(devfilePath)
+	if err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_ShortSensitiveValue() failed to read the written devfile: %v", err)
+	}
+	assert.Contains(t, string(written), "value: devops", "TestParseDevfileAndValidate_ShortSensitiveValue(): new values should be written as they are")
+	assert.NotContains(t, string(written), "{{token}}ops", "TestParseDevfileAndValidate_ShortSensitiveValue(): new values should be written as they are")
+}
+
+// assertRedacted asserts that the secret is not in the message of err, nor of the errors it wraps
+func assertRedacted(t *testing.T, err error, secret string) {
+	assert.NotContains(t, err.Error(), secret, "the message of %T should be redacted", err)
+	var wrapped []error
+	switch err := err.(type) {
+	case interface{ Unwrap() []error }:
+		wrapped = err.Unwrap()
+	case interface{ Unwrap() error }:
+		wrapped = []error{err.Unwrap()}
+	}
+	for _, err := range wrapped {
+		if err != nil {
+			assertRedacted(t, err, secret)
+		}
+	}
+}
+
+func Test_redactError(t *testing.T) {
+	d := parser.DevfileObj{Variables: map[string]parser.ResolvedVariable{"TOKEN": {Value: "s3cr3t-token", Sensitive: true}}}
+	tokenErr := errors.New("invalid token s3cr3t-token")
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr string
+	}{
+		{
+			name:    "wrapped error",
+			err:     fmt.Errorf("failed to log in: %w", tokenErr),
+			wantErr: "failed to log in: invalid token {{TOKEN}}",
+		},
+		{
+			name:    "joined errors",
+			err:     errors.Join(errors.New("other error"), fmt.Errorf("failed to log in: %w", tokenErr)),
+			wantErr: "other error\nfailed to log in: invalid token {{TOKEN}}",
+		},
+		{
+			name:    "multierror",
+			err:     multierror.Append(errors.New("other error"), tokenErr),
+			wantErr: "2 errors occurred:\n\t* other error\n\t* invalid token {{TOKEN}}\n\n",
+		},
+		{
+			name:    "issues",
+			err:     errPkg.Issues{{Code: errPkg.CodeInvalidCommand, Message: tokenErr.Error(), Err: tokenErr}},
+			wantErr: "invalid token {{TOKEN}}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := redactError(d, tt.err)
+			assert.Equal(t, tt.wantErr, err.Error(), "Test_redactError(): the message should be redacted")
+			assertRedacted(t, err, "s3cr3t-token")
+			assert.False(t, errors.Is(err, tokenErr), "Test_redactError(): the original error should not be exposed")
+		})
+	}
+
+	var issue *errPkg.Issue
+	if assert.True(t, errors.As(redactError(d, tests[3].err), &issue), "Test_redactError(): the issues should keep their type") {
+		assert.Equal(t, errPkg.CodeInvalidCommand, issue.Code)
+	}
+	unchanged := errors.New("other error")
+	assert.Same(t, unchanged, redactError(d, unchanged), "Test_redactError(): errors without sensitive values should be kept as they are")
+}
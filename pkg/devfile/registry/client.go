@@ -0,0 +1,469 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/devfile/library/v2/pkg/util"
+	indexSchema "github.com/devfile/registry-support/index/generator/schema"
+	registryLibrary "github.com/devfile/registry-support/registry-library/library"
+	"github.com/hashicorp/go-multierror"
+	versionpkg "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"oras.land/oras-go/pkg/content"
+	orasctx "oras.land/oras-go/pkg/context"
+	"oras.land/oras-go/pkg/oras"
+)
+
+const (
+	// DeprecatedTag is the tag of the deprecated stacks and samples
+	DeprecatedTag = "Deprecated"
+	// LatestVersion is the version referring to the highest version of a stack
+	LatestVersion = "latest"
+
+	// stackArchiveFile is the layer of the OCI artifact of a stack holding its resources as a tar archive
+	stackArchiveFile = "archive.tar"
+)
+
+// Auth is the authentication to a registry
+type Auth struct {
+	// Token is sent as a bearer token if set
+	Token string
+	// Username and Password are sent as basic auth credentials if Username is set
+	Username string
+	Password string
+}
+
+// Registry is a devfile registry
+type Registry struct {
+	// URL is the URL of the registry, e.g. https://registry.devfile.io
+	URL string
+	// Auth is the authentication to the registry, if any
+	Auth *Auth
+	// HTTPTimeout is the timeout of the requests to the registry in seconds. The default timeout is used if it is nil or
+	// not positive.
+	HTTPTimeout *int
+}
+
+// Client is a client of devfile registries
+type Client struct {
+	registries []registryClient
+}
+
+// registryClient is a registry with the HTTP client of its requests
+type registryClient struct {
+	Registry
+	// httpClient is the HTTP client of the requests to the registry, reusing its connections across requests
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the registries. The stacks are looked up in the registries in order.
+func NewClient(registries ...Registry) *Client {
+	c := &Client{}
+	for _, registry := range registries {
+		c.registries = append(c.registries, newRegistryClient(registry))
+	}
+	return c
+}
+
+// newRegistryClient returns the registry with the HTTP client of its requests
+func newRegistryClient(registry Registry) registryClient {
+	return registryClient{Registry: registry, httpClient: httpClient(registry)}
+}
+
+// Entry is a stack or a sample of the index of a registry
+type Entry struct {
+	indexSchema.Schema
+	// Registry is the URL of the registry the entry is from
+	Registry string `json:"registry"`
+}
+
+// Deprecated returns true if the entry is deprecated, i.e. if it has the Deprecated tag
+func (e Entry) Deprecated() bool {
+	return containsFold(e.Tags, DeprecatedTag)
+}
+
+// Filter selects the entries of the index. The entries match all the fields that are set.
+type Filter struct {
+	// Type is the type of the entries, stacks and samples if empty
+	Type indexSchema.DevfileType
+	// Tags are tags the entries all have, compared case-insensitively
+	Tags []string
+	// Language is the language of the entries, compared case-insensitively
+	Language string
+	// Architectures are architectures the entries all support. The entries that do not list their architectures
+	// support all of them.
+	Architectures []string
+	// Deprecated selects only the deprecated entries if true, and only the other ones if false
+	Deprecated *bool
+}
+
+// matches returns true if the entry matches the filter
+func (f Filter) matches(entry Entry) bool {
+	if f.Type != "" && entry.Type != f.Type {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !containsFold(entry.Tags, tag) {
+			return false
+		}
+	}
+	if f.Language != "" && !strings.EqualFold(entry.Language, f.Language) {
+		return false
+	}
+	if len(entry.Architectures) > 0 {
+		for _, architecture := range f.Architectures {
+			if !containsFold(entry.Architectures, architecture) {
+				return false
+			}
+		}
+	}
+	return f.Deprecated == nil || *f.Deprecated == entry.Deprecated()
+}
+
+// Stack is a version of a stack of a registry
+type Stack struct {
+	// Entry is the entry of the stack in the index of its registry
+	Entry Entry
+	// Version is the version of the stack
+	Version string
+	// SchemaVersion is the schema version of the devfile of the version, if known
+	SchemaVersion string
+	// StarterProjects are the names of the starter projects of the version
+	StarterProjects []string
+	// Resources are the files of the version besides its devfile
+	Resources []string
+	// Deprecated is true if the stack is deprecated
+	Deprecated bool
+	// Devfile is the content of the devfile of the version
+	Devfile []byte
+
+	// link is the path of the OCI artifact of the version in the registry
+	link string
+}
+
+// Index returns the entries of the indexes of the registries that match the filter, in the order of the registries.
+// The entries of the registries that cannot be read are left out, and their errors returned with the other entries.
+func (c *Client) Index(ctx context.Context, filter Filter) ([]Entry, error) {
+	var entries []Entry
+	var errs error
+	for _, registry := range c.registries {
+		registryEntries, err := c.index(ctx, registry, filter)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "failed to get the index of registry %s", registry.URL))
+			continue
+		}
+		for _, entry := range registryEntries {
+			if filter.matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, errs
+}
+
+// GetStack returns a version of a stack, with its devfile, from the first registry defining the stack. The version is
+// the default version of the stack if it is empty, and its highest version if it is LatestVersion.
+// The registries whose index cannot be read are skipped, and their errors returned if no other registry defines the stack.
+func (c *Client) GetStack(ctx context.Context, name string, version string) (*Stack, error) {
+	var errs error
+	for _, registry := range c.registries {
+		entries, err := c.index(ctx, registry, Filter{Type: indexSchema.StackDevfileType})
+		if err != nil {
+			if ctxErr := util.GetContextOrBackground(ctx).Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			errs = multierror.Append(errs, errors.Wrapf(err, "failed to get the index of registry %s", registry.URL))
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Name != name {
+				continue
+			}
+			stack, err := stackVersion(entry, version)
+			if err != nil {
+				return nil, err
+			}
+			devfilePath := path.Join("devfiles", name)
+			if len(entry.Versions) > 0 {
+				devfilePath = path.Join(devfilePath, stack.Version)
+			}
+			if stack.Devfile, err = c.get(ctx, registry, devfilePath, nil); err != nil {
+				return nil, errors.Wrapf(err, "failed to get the devfile of stack %s version %s", name, stack.Version)
+			}
+			return stack, nil
+		}
+	}
+	if errs != nil {
+		return nil, multierror.Append(fmt.Errorf("stack %s is not found in the registries", name), errs)
+	}
+	return nil, fmt.Errorf("stack %s is not found in the registries", name)
+}
+
+// PullStack writes a version of a stack to destDir, i.e. its devfile and its resources, and returns the stack
+func (c *Client) PullStack(ctx context.Context, name string, version string, destDir string) (*Stack, error) {
+	stack, err := c.GetStack(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	registry := c.registry(stack.Entry.Registry)
+	if err = c.pull(ctx, registry, stack, destDir); err != nil {
+		return nil, errors.Wrapf(err, "failed to pull stack %s version %s", name, stack.Version)
+	}
+	// the devfile is written in case the artifact has no devfile layer
+	if err = os.WriteFile(filepath.Join(destDir, "devfile.yaml"), stack.Devfile, 0644); err != nil {
+		return nil, err
+	}
+	return stack, nil
+}
+
+// StarterProjects returns the names of the starter projects of a version of a stack
+func (c *Client) StarterProjects(ctx context.Context, name string, version string) ([]string, error) {
+	stack, err := c.GetStack(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	return stack.StarterProjects, nil
+}
+
+// DownloadStarterProject returns the zip archive of a starter project of a version of a stack
+func (c *Client) DownloadStarterProject(ctx context.Context, name string, version string, starterProject string) ([]byte, error) {
+	stack, err := c.GetStack(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(stack.StarterProjects, starterProject) {
+		return nil, fmt.Errorf("the starter project %s does not exist in stack %s version %s", starterProject, name, stack.Version)
+	}
+	starterProjectPath := path.Join("devfiles", name, "starter-projects", starterProject)
+	if len(stack.Entry.Versions) > 0 {
+		starterProjectPath = path.Join("devfiles", name, stack.Version, "starter-projects", starterProject)
+	}
+	archive, err := c.get(ctx, c.registry(stack.Entry.Registry), starterProjectPath, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download the starter project %s of stack %s", starterProject, name)
+	}
+	return archive, nil
+}
+
+// index returns the entries of the index of a registry, the registry filtering them by type, architecture and
+// deprecation
+func (c *Client) index(ctx context.Context, registry registryClient, filter Filter) ([]Entry, error) {
+	indexPath := "v2index/all"
+	switch filter.Type {
+	case indexSchema.StackDevfileType:
+		indexPath = "v2index"
+	case indexSchema.SampleDevfileType:
+		indexPath = "v2index/sample"
+	}
+	query := url.Values{}
+	for _, architecture := range filter.Architectures {
+		query.Add("arch", architecture)
+	}
+	if filter.Deprecated != nil {
+		query.Set("deprecated", fmt.Sprint(*filter.Deprecated))
+	}
+	content, err := c.get(ctx, registry, indexPath, query)
+	if err != nil {
+		return nil, err
+	}
+	var index []indexSchema.Schema
+	if err = json.Unmarshal(content, &index); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the index")
+	}
+	entries := make([]Entry, len(index))
+	for i := range index {
+		entries[i] = Entry{Schema: index[i], Registry: registry.URL}
+	}
+	return entries, nil
+}
+
+// stackVersion returns a version of the stack of an entry, the default version if version is empty, and the highest
+// version if version is LatestVersion
+func stackVersion(entry Entry, version string) (*Stack, error) {
+	stack := &Stack{
+		Entry:           entry,
+		Version:         entry.Version,
+		StarterProjects: entry.StarterProjects,
+		Resources:       entry.Resources,
+		Deprecated:      entry.Deprecated(),
+		link:            entry.Links["self"],
+	}
+	var found *indexSchema.Version
+	var latest *versionpkg.Version
+	for i, stackVersion := range entry.Versions {
+		switch {
+		case version == "" && stackVersion.Default, version == stackVersion.Version:
+			found = &entry.Versions[i]
+		case version == LatestVersion:
+			if v, err := versionpkg.NewVersion(stackVersion.Version); err == nil && (latest == nil || v.GreaterThan(latest)) {
+				latest, found = v, &entry.Versions[i]
+			}
+		}
+		if found != nil && version != LatestVersion {
+			break
+		}
+	}
+	if found == nil {
+		if len(entry.Versions) > 0 || (version != "" && version != LatestVersion && version != entry.Version) {
+			return nil, fmt.Errorf("version %s of stack %s is not found", version, entry.Name)
+		}
+		return stack, nil
+	}
+
+	stack.Version = found.Version
+	stack.SchemaVersion = found.SchemaVersion
+	stack.StarterProjects = found.StarterProjects
+	stack.Resources = found.Resources
+	if len(found.Tags) > 0 {
+		stack.Deprecated = containsFold(found.Tags, DeprecatedTag)
+	}
+	stack.link = found.Links["self"]
+	return stack, nil
+}
+
+// pull pulls the OCI artifact of a stack version to destDir, and extracts its resources archive
+func (c *Client) pull(ctx context.Context, registry registryClient, stack *Stack, destDir string) error {
+	registryURL, err := url.Parse(registry.URL)
+	if err != nil {
+		return err
+	}
+	link := stack.link
+	if link == "" {
+		link = fmt.Sprintf("devfile-catalog/%s:%s", stack.Entry.Name, stack.Version)
+	}
+	ref := path.Join(registryURL.Host, link)
+
+	headers := http.Header{}
+	setAuthHeader(headers, registry.Auth)
+	resolver := docker.NewResolver(docker.ResolverOptions{
+		Headers:   headers,
+		PlainHTTP: registryURL.Scheme == "http",
+		Client:    registry.httpClient,
+	})
+	fileStore := content.NewFile(destDir)
+	defer fileStore.Close()
+
+	ctx = util.GetContextOrBackground(ctx)
+	if _, err = oras.Copy(orasctx.WithLoggerDiscarded(ctx), resolver, ref, fileStore, ref); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+
+	archivePath := filepath.Join(destDir, stackArchiveFile)
+	if util.CheckPathExists(archivePath) {
+		if err = util.Untar(archivePath, destDir, registryLibrary.ExcludedFiles); err != nil {
+			return fmt.Errorf("failed to extract %s: %v", stackArchiveFile, err)
+		}
+		return os.RemoveAll(archivePath)
+	}
+	return nil
+}
+
+// get returns the content of a path of a registry
+func (c *Client) get(ctx context.Context, registry registryClient, urlPath string, query url.Values) ([]byte, error) {
+	requestURL := strings.TrimSuffix(registry.URL, "/") + "/" + urlPath
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(util.GetContextOrBackground(ctx), http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	setAuthHeader(req.Header, registry.Auth)
+	req.Header.Set("Client", util.TelemetryClientName)
+
+	resp, err := registry.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to retrieve %s, %v: %s", requestURL, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// registry returns the registry with the URL
+func (c *Client) registry(registryURL string) registryClient {
+	for _, registry := range c.registries {
+		if registry.URL == registryURL {
+			return registry
+		}
+	}
+	return newRegistryClient(Registry{URL: registryURL})
+}
+
+// httpClient returns the HTTP client of the requests to a registry, with its own transport
+func httpClient(registry Registry) *http.Client {
+	timeout := util.HTTPRequestResponseTimeout
+	if registry.HTTPTimeout != nil && *registry.HTTPTimeout > 0 {
+		timeout = time.Duration(*registry.HTTPTimeout) * time.Second
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			ResponseHeaderTimeout: timeout,
+		},
+		Timeout: timeout,
+	}
+}
+
+// setAuthHeader sets the authorization header of the authentication
+func setAuthHeader(header http.Header, auth *Auth) {
+	switch {
+	case auth == nil:
+	case auth.Token != "":
+		header.Set("Authorization", "Bearer "+auth.Token)
+	case auth.Username != "":
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password)))
+	}
+}
+
+// contains returns true if the values contain the value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold returns true if the values contain the value, compared case-insensitively
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
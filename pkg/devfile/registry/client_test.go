@@ -0,0 +1,334 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/devfile/library/v2/pkg/testingutil"
+	indexSchema "github.com/devfile/registry-support/index/generator/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRegistry() *testingutil.FakeRegistry {
+	return &testingutil.FakeRegistry{
+		Index: []indexSchema.Schema{
+			{
+				Name:     "nodejs",
+				Type:     indexSchema.StackDevfileType,
+				Language: "JavaScript",
+				Tags:     []string{"Node.js", "Express"},
+				Version:  "2.1.1",
+				Versions: []indexSchema.Version{
+					{Version: "2.1.1", SchemaVersion: "2.1.0", Default: true, StarterProjects: []string{"nodejs-starter"}, Resources: []string{"devfile.yaml"}},
+					{Version: "2.2.0", SchemaVersion: "2.2.0", StarterProjects: []string{"nodejs-starter"}, Resources: []string{"devfile.yaml", "deploy.yaml"}},
+				},
+			},
+			{
+				Name:          "java-wildfly",
+				Type:          indexSchema.StackDevfileType,
+				Language:      "Java",
+				Tags:          []string{"Java", "Deprecated"},
+				Architectures: []string{"amd64"},
+				Version:       "1.0.0",
+				Versions:      []indexSchema.Version{{Version: "1.0.0", Default: true}},
+			},
+			{
+				Name:     "nodejs-basic",
+				Type:     indexSchema.SampleDevfileType,
+				Language: "JavaScript",
+				Tags:     []string{"Node.js"},
+			},
+		},
+		Devfiles: map[string]string{
+			"nodejs:2.1.1":       "schemaVersion: 2.1.0\nmetadata:\n  name: nodejs\n",
+			"nodejs:2.2.0":       "schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\n",
+			"java-wildfly:1.0.0": "schemaVersion: 2.0.0\nmetadata:\n  name: java-wildfly\n",
+		},
+		Resources: map[string]map[string]string{
+			"nodejs:2.2.0": {"deploy.yaml": "kind: Deployment\n"},
+		},
+		StarterProjects: map[string][]byte{
+			"nodejs/nodejs-starter": []byte("zip content"),
+		},
+	}
+}
+
+func TestClient_Index(t *testing.T) {
+	trueValue, falseValue := true, false
+	server := httptest.NewServer(newTestRegistry())
+	defer server.Close()
+	client := NewClient(Registry{URL: server.URL})
+
+	tests := []struct {
+		name      string
+		filter    Filter
+		wantNames []string
+	}{
+		{
+			name:      "all entries",
+			wantNames: []string{"nodejs", "java-wildfly", "nodejs-basic"},
+		},
+		{
+			name:      "samples",
+			filter:    Filter{Type: indexSchema.SampleDevfileType},
+			wantNames: []string{"nodejs-basic"},
+		},
+		{
+			name:      "tags and language",
+			filter:    Filter{Tags: []string{"node.js"}, Language: "javascript", Type: indexSchema.StackDevfileType},
+			wantNames: []string{"nodejs"},
+		},
+		{
+			name:      "architecture",
+			filter:    Filter{Architectures: []string{"arm64"}},
+			wantNames: []string{"nodejs", "nodejs-basic"},
+		},
+		{
+			name:      "deprecated",
+			filter:    Filter{Deprecated: &trueValue},
+			wantNames: []string{"java-wildfly"},
+		},
+		{
+			name:      "not deprecated",
+			filter:    Filter{Deprecated: &falseValue, Type: indexSchema.StackDevfileType},
+			wantNames: []string{"nodejs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := client.Index(context.Background(), tt.filter)
+			if !assert.NoError(t, err) {
+				return
+			}
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name)
+				assert.Equal(t, server.URL, entry.Registry, "TestClient_Index(): the registry of the entry should be set")
+			}
+			assert.Equal(t, tt.wantNames, names, "TestClient_Index(): entries should match")
+		})
+	}
+}
+
+func TestClient_GetStack(t *testing.T) {
+	server := httptest.NewServer(newTestRegistry())
+	defer server.Close()
+	client := NewClient(Registry{URL: server.URL})
+
+	tests := []struct {
+		name           string
+		stack          string
+		version        string
+		wantVersion    string
+		wantDevfile    string
+		wantDeprecated bool
+		wantErr        string
+	}{
+		{
+			name:        "default version",
+			stack:       "nodejs",
+			wantVersion: "2.1.1",
+			wantDevfile: "schemaVersion: 2.1.0\nmetadata:\n  name: nodejs\n",
+		},
+		{
+			name:        "latest version",
+			stack:       "nodejs",
+			version:     LatestVersion,
+			wantVersion: "2.2.0",
+			wantDevfile: "schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\n",
+		},
+		{
+			name:           "deprecated stack",
+			stack:          "java-wildfly",
+			version:        "1.0.0",
+			wantVersion:    "1.0.0",
+			wantDevfile:    "schemaVersion: 2.0.0\nmetadata:\n  name: java-wildfly\n",
+			wantDeprecated: true,
+		},
+		{
+			name:    "unknown version",
+			stack:   "nodejs",
+			version: "3.0.0",
+			wantErr: "version 3.0.0 of stack nodejs is not found",
+		},
+		{
+			name:    "unknown stack",
+			stack:   "python",
+			wantErr: "stack python is not found in the registries",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack, err := client.GetStack(context.Background(), tt.stack, tt.version)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.wantVersion, stack.Version)
+			assert.Equal(t, tt.wantDevfile, string(stack.Devfile))
+			assert.Equal(t, tt.wantDeprecated, stack.Deprecated)
+		})
+	}
+}
+
+func TestClient_PullStack(t *testing.T) {
+	server := httptest.NewServer(newTestRegistry())
+	defer server.Close()
+	client := NewClient(Registry{URL: server.URL})
+
+	destDir := t.TempDir()
+	stack, err := client.PullStack(context.Background(), "nodejs", "2.2.0", destDir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"devfile.yaml", "deploy.yaml"}, stack.Resources)
+	for name, want := range map[string]string{
+		"devfile.yaml": "schemaVersion: 2.2.0\nmetadata:\n  name: nodejs\n",
+		"deploy.yaml":  "kind: Deployment\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(destDir, name))
+		if assert.NoError(t, err, "TestClient_PullStack(): %s should be pulled", name) {
+			assert.Equal(t, want, string(content))
+		}
+	}
+	assert.NoFileExists(t, filepath.Join(destDir, "archive.tar"), "TestClient_PullStack(): the archive should be extracted")
+}
+
+func TestClient_StarterProjects(t *testing.T) {
+	server := httptest.NewServer(newTestRegistry())
+	defer server.Close()
+	client := NewClient(Registry{URL: server.URL})
+
+	starterProjects, err := client.StarterProjects(context.Background(), "nodejs", "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"nodejs-starter"}, starterProjects)
+	}
+	archive, err := client.DownloadStarterProject(context.Background(), "nodejs", "", "nodejs-starter")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "zip content", string(archive))
+	}
+	_, err = client.DownloadStarterProject(context.Background(), "nodejs", "", "missing")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the starter project missing does not exist in stack nodejs version 2.1.1")
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	tokenRegistry := newTestRegistry()
+	tokenRegistry.Token = "s3cr3t"
+	tokenServer := httptest.NewServer(tokenRegistry)
+	defer tokenServer.Close()
+	basicRegistry := newTestRegistry()
+	basicRegistry.Username, basicRegistry.Password = "user", "password"
+	basicServer := httptest.NewServer(basicRegistry)
+	defer basicServer.Close()
+
+	tests := []struct {
+		name       string
+		registries []Registry
+		wantErr    string
+	}{
+		{
+			name: "per-registry credentials",
+			registries: []Registry{
+				{URL: tokenServer.URL, Auth: &Auth{Token: "s3cr3t"}},
+				{URL: basicServer.URL, Auth: &Auth{Username: "user", Password: "password"}},
+			},
+		},
+		{
+			name: "missing credentials",
+			registries: []Registry{
+				{URL: tokenServer.URL, Auth: &Auth{Token: "s3cr3t"}},
+				{URL: basicServer.URL},
+			},
+			wantErr: "401: Unauthorized",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(tt.registries...)
+			entries, err := client.Index(context.Background(), Filter{Type: indexSchema.StackDevfileType})
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				assert.Len(t, entries, 2, "TestClient_Auth(): the entries of the other registries should be returned")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, entries, 4)
+
+			_, err = client.PullStack(context.Background(), "nodejs", "2.2.0", t.TempDir())
+			assert.NoError(t, err, "TestClient_Auth(): the stack should be pulled with the credentials")
+		})
+	}
+}
+
+func TestClient_GetStack_UnavailableRegistry(t *testing.T) {
+	unavailableServer := httptest.NewServer(http.NotFoundHandler())
+	defer unavailableServer.Close()
+	server := httptest.NewServer(newTestRegistry())
+	defer server.Close()
+
+	client := NewClient(Registry{URL: unavailableServer.URL}, Registry{URL: server.URL})
+	stack, err := client.GetStack(context.Background(), "nodejs", "")
+	if assert.NoError(t, err, "TestClient_GetStack_UnavailableRegistry(): the stack should be found in the next registry") {
+		assert.Equal(t, server.URL, stack.Entry.Registry)
+	}
+
+	_, err = client.GetStack(context.Background(), "python", "")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "stack python is not found in the registries")
+		assert.Contains(t, err.Error(), "failed to get the index of registry "+unavailableServer.URL)
+	}
+}
+
+func TestClient_Connections(t *testing.T) {
+	var mu sync.Mutex
+	connections := 0
+	server := httptest.NewUnstartedServer(newTestRegistry())
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			connections++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := NewClient(Registry{URL: server.URL})
+	for i := 0; i < 3; i++ {
+		_, err := client.GetStack(context.Background(), "nodejs", "")
+		assert.NoError(t, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, connections, "TestClient_Connections(): the requests to a registry should reuse the connection")
+}
@@ -0,0 +1,209 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	v2Validation "github.com/devfile/api/v2/pkg/validation"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/hashicorp/go-multierror"
+)
+
+// issueKind is the kind of issue reported by a validation error of devfile/api
+type issueKind struct {
+	code     errPkg.IssueCode
+	severity errPkg.Severity
+	// list is the top-level list of the devfile holding the elements named in the message, if any
+	list string
+	// names captures the names of the offending elements in the message, or the event type for events, as the errors
+	// of devfile/api do not expose them
+	names *regexp.Regexp
+	// pattern matches the message of the errors of the kind, to classify the errors that have no dedicated type
+	pattern *regexp.Regexp
+}
+
+var (
+	invalidCommandKind     = issueKind{code: errPkg.CodeInvalidCommand, list: "commands", names: regexp.MustCompile(`^the command "([^"]+)" is invalid`)}
+	invalidCommandTypeKind = issueKind{code: errPkg.CodeInvalidCommand, list: "commands", names: regexp.MustCompile(`^command (\S+) has invalid type`)}
+	multipleDefaultsKind   = issueKind{code: errPkg.CodeMultipleDefaultCommands, list: "commands", names: regexp.MustCompile(`command: ([^\s,;]+)`)}
+	missingDefaultKind     = issueKind{code: errPkg.CodeMissingDefaultCommand, severity: errPkg.SeverityWarning}
+	invalidVolumeKind      = issueKind{code: errPkg.CodeInvalidVolume, list: "components", names: regexp.MustCompile(`^the volume "([^"]+)" is invalid`)}
+	invalidComponentKind   = issueKind{code: errPkg.CodeInvalidComponent, list: "components", names: regexp.MustCompile(`^the component "([^"]+)" is invalid`)}
+	reservedEnvKind        = issueKind{code: errPkg.CodeReservedEnv, list: "components", names: regexp.MustCompile(`is reserved and cannot be customized in component ([^\s,]+)`)}
+	resourceKind           = issueKind{code: errPkg.CodeInvalidResourceRequirement, list: "components", names: regexp.MustCompile(`for component ([^\s:,]+):`)}
+	missingVolumeMountKind = issueKind{code: errPkg.CodeMissingVolumeMount, list: "components", names: regexp.MustCompile(`belonging to the container component ([^\s,]+)`)}
+	annotationKind         = issueKind{code: errPkg.CodeAnnotationConflict}
+	duplicateEndpointKind  = issueKind{code: errPkg.CodeDuplicateEndpoint}
+	invalidEventKind       = issueKind{code: errPkg.CodeInvalidEvent, list: "events", names: regexp.MustCompile(`^(\S+) type events are invalid`)}
+	invalidProjectKind     = issueKind{code: errPkg.CodeInvalidProject, list: "projects", names: regexp.MustCompile(`(?:^|for )project ([^\s,]+)`)}
+	invalidStarterKind     = issueKind{code: errPkg.CodeInvalidStarterProject, list: "starterProjects", names: regexp.MustCompile(`(?:^|for )starterProject ([^\s,]+)`)}
+)
+
+// fallbackKinds classify by their message the validation errors that have no dedicated type in devfile/api, and the
+// errors about imported elements, which devfile/api reports with their message only. They are matched in order.
+var fallbackKinds = []issueKind{
+	{code: errPkg.CodeDuplicateKey, pattern: regexp.MustCompile(`^duplicate key: `)},
+	withPattern(invalidCommandKind, `^the command "[^"]+" is invalid`),
+	withPattern(invalidCommandTypeKind, `^command \S+ has invalid type`),
+	withPattern(multipleDefaultsKind, `^command group \S+ error - there should be exactly one default command`),
+	withPattern(missingDefaultKind, `^command group \S+ warning - there should be exactly one default command`),
+	withPattern(invalidVolumeKind, `^the volume "[^"]+" is invalid`),
+	withPattern(invalidComponentKind, `^the component "[^"]+" is invalid`),
+	withPattern(reservedEnvKind, `^env variable \S+ is reserved`),
+	withPattern(resourceKind, `^(?:error parsing \S+ requirement|invalid resource request) for component `),
+	withPattern(missingVolumeMountKind, `^unable to find the following volume mounts`),
+	withPattern(annotationKind, `^\S+ annotation: .* has been declared multiple times`),
+	withPattern(duplicateEndpointKind, `^devfile contains multiple (?:endpoint entries with same name|containers with same endpoint targetPort)`),
+	withPattern(invalidEventKind, `^\S+ type events are invalid`),
+	withPattern(invalidProjectKind, `^project \S+ (?:should have|has more than one remote)|in the remotes for project `),
+	withPattern(invalidStarterKind, `^starterProject \S+ should have|in the remotes for starterProject `),
+}
+
+// withPattern returns kind matching the messages matched by pattern
+func withPattern(kind issueKind, pattern string) issueKind {
+	kind.pattern = regexp.MustCompile(pattern)
+	return kind
+}
+
+// classify returns the kind of issue reported by a validation error. The errors of devfile/api are classified by their
+// type, the other errors by their message.
+func classify(err error) (issueKind, bool) {
+	switch err.(type) {
+	case *v2Validation.InvalidCommandError:
+		return invalidCommandKind, true
+	case *v2Validation.InvalidCommandTypeError:
+		return invalidCommandTypeKind, true
+	case *v2Validation.MultipleDefaultCmdError:
+		return multipleDefaultsKind, true
+	case *v2Validation.MissingDefaultCmdWarning:
+		return missingDefaultKind, true
+	case *v2Validation.InvalidVolumeError:
+		return invalidVolumeKind, true
+	case *v2Validation.InvalidComponentError:
+		return invalidComponentKind, true
+	case *v2Validation.ReservedEnvError:
+		return reservedEnvKind, true
+	case *v2Validation.ParsingResourceRequirementError, *v2Validation.InvalidResourceRequestError:
+		return resourceKind, true
+	case *v2Validation.MissingVolumeMountError:
+		return missingVolumeMountKind, true
+	case *v2Validation.AnnotationConflictError:
+		return annotationKind, true
+	case *v2Validation.InvalidEndpointError:
+		return duplicateEndpointKind, true
+	case *v2Validation.InvalidEventError:
+		return invalidEventKind, true
+	case *v2Validation.MissingProjectRemoteError, *v2Validation.MissingProjectCheckoutFromRemoteError:
+		return invalidProjectKind, true
+	case *v2Validation.MissingRemoteError, *v2Validation.MultipleRemoteError, *v2Validation.InvalidProjectCheckoutRemoteError:
+		// the remotes of projects and starter projects are validated alike, only the message tells them apart
+		if invalidStarterKind.names.MatchString(err.Error()) {
+			return invalidStarterKind, true
+		}
+		return invalidProjectKind, true
+	}
+	for _, kind := range fallbackKinds {
+		if kind.pattern.MatchString(err.Error()) {
+			return kind, true
+		}
+	}
+	return issueKind{}, false
+}
+
+// devfileElements indexes the elements of the validated devfile by list and name
+type devfileElements map[string]map[string]devfileElement
+
+// devfileElement is an element of a list of the validated devfile
+type devfileElement struct {
+	index      int
+	attributes attributes.Attributes
+}
+
+// newDevfileElements returns the index of the elements of the validated devfile
+func newDevfileElements(components []v1alpha2.Component, commands []v1alpha2.Command, projects []v1alpha2.Project, starterProjects []v1alpha2.StarterProject) devfileElements {
+	elements := devfileElements{"components": {}, "commands": {}, "projects": {}, "starterProjects": {}}
+	for i, component := range components {
+		elements["components"][component.Name] = devfileElement{index: i, attributes: component.Attributes}
+	}
+	for i, command := range commands {
+		elements["commands"][command.Id] = devfileElement{index: i, attributes: command.Attributes}
+	}
+	for i, project := range projects {
+		elements["projects"][project.Name] = devfileElement{index: i, attributes: project.Attributes}
+	}
+	for i, starterProject := range starterProjects {
+		elements["starterProjects"][starterProject.Name] = devfileElement{index: i, attributes: starterProject.Attributes}
+	}
+	return elements
+}
+
+// withIssues returns the validation error err with each of its errors replaced by the issues it reports, so that the
+// issues can be retrieved with errors.As or GetIssues. The message of the returned error is the one of err.
+func withIssues(err error, elements devfileElements) error {
+	var merr *multierror.Error
+	if !errors.As(err, &merr) {
+		return issuesOf(err, elements)
+	}
+	withIssues := &multierror.Error{ErrorFormat: merr.ErrorFormat}
+	for _, err := range merr.Errors {
+		withIssues = multierror.Append(withIssues, issuesOf(err, elements))
+	}
+	return withIssues
+}
+
+// issuesOf returns the issue reported by a single validation error, or the issues about each of the elements it names.
+// The issues wrap err, so that it can still be found with errors.As.
+func issuesOf(err error, elements devfileElements) error {
+	issue := &errPkg.Issue{Code: errPkg.CodeNonCompliantDevfile, Severity: errPkg.SeverityError, Message: err.Error(), Err: err}
+	kind, ok := classify(err)
+	if !ok {
+		return issue
+	}
+	issue.Code = kind.code
+	if kind.severity != "" {
+		issue.Severity = kind.severity
+	}
+	if kind.list == "" {
+		return issue
+	}
+	var issues errPkg.Issues
+	for _, nameMatches := range kind.names.FindAllStringSubmatch(issue.Message, -1) {
+		name := nameMatches[1]
+		elementIssue := *issue
+		if kind.list == "events" {
+			elementIssue.Field = "/events/" + name
+		} else if element, ok := elements[kind.list][name]; ok {
+			elementIssue.Field = fmt.Sprintf("/%s/%d", kind.list, element.index)
+			elementIssue.Element = name
+			elementIssue.ImportReference = element.attributes.GetString(v2Validation.ImportSourceAttribute, nil)
+		} else {
+			elementIssue.Element = name
+		}
+		issues = append(issues, &elementIssue)
+	}
+	if len(issues) == 1 {
+		return issues[0]
+	}
+	if len(issues) > 1 {
+		return issues
+	}
+	return issue
+}
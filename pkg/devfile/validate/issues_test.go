@@ -0,0 +1,134 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	v2Validation "github.com/devfile/api/v2/pkg/validation"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDevfileData_Issues(t *testing.T) {
+	isTrue := true
+	container := func(name string, env ...v1alpha2.EnvVar) v1alpha2.Component {
+		return v1alpha2.Component{
+			Name: name,
+			ComponentUnion: v1alpha2.ComponentUnion{
+				Container: &v1alpha2.ContainerComponent{Container: v1alpha2.Container{Image: "quay.io/image", Env: env}},
+			},
+		}
+	}
+	defaultBuild := func(id string) v1alpha2.Command {
+		return v1alpha2.Command{
+			Id: id,
+			CommandUnion: v1alpha2.CommandUnion{
+				Exec: &v1alpha2.ExecCommand{
+					LabeledCommand: v1alpha2.LabeledCommand{
+						BaseCommand: v1alpha2.BaseCommand{Group: &v1alpha2.CommandGroup{Kind: v1alpha2.BuildCommandGroupKind, IsDefault: &isTrue}},
+					},
+					CommandLine: "make",
+					Component:   "runtime",
+				},
+			},
+		}
+	}
+	importedContainer := container("imported", v1alpha2.EnvVar{Name: "PROJECTS_ROOT", Value: "/projects"})
+	importedContainer.Attributes = attributes.Attributes{}.PutString(v2Validation.ImportSourceAttribute, "uri: parent.yaml")
+
+	tests := []struct {
+		name       string
+		components []v1alpha2.Component
+		commands   []v1alpha2.Command
+		wantIssues []errPkg.Issue
+		// wantAs is a pointer to the devfile/api error type that should still be found in the error with errors.As
+		wantAs interface{}
+	}{
+		{
+			name:       "should report the offending element and its import reference",
+			components: []v1alpha2.Component{container("runtime"), importedContainer},
+			wantIssues: []errPkg.Issue{
+				{Code: errPkg.CodeReservedEnv, Severity: errPkg.SeverityError, Field: "/components/1", Element: "imported", ImportReference: "uri: parent.yaml"},
+			},
+			wantAs: new(*v2Validation.ReservedEnvError),
+		},
+		{
+			name:       "should classify an invalid command by its type",
+			components: []v1alpha2.Component{container("tools")},
+			commands:   []v1alpha2.Command{defaultBuild("build")},
+			wantIssues: []errPkg.Issue{
+				{Code: errPkg.CodeInvalidCommand, Severity: errPkg.SeverityError, Field: "/commands/0", Element: "build"},
+			},
+			wantAs: new(*v2Validation.InvalidCommandError),
+		},
+		{
+			name:       "should report an issue for each default command of a group",
+			components: []v1alpha2.Component{container("runtime")},
+			commands:   []v1alpha2.Command{defaultBuild("build"), defaultBuild("build-again")},
+			wantIssues: []errPkg.Issue{
+				{Code: errPkg.CodeMultipleDefaultCommands, Severity: errPkg.SeverityError, Field: "/commands/0", Element: "build"},
+				{Code: errPkg.CodeMultipleDefaultCommands, Severity: errPkg.SeverityError, Field: "/commands/1", Element: "build-again"},
+			},
+			wantAs: new(*v2Validation.MultipleDefaultCmdError),
+		},
+		{
+			name:       "should report issues without element",
+			components: []v1alpha2.Component{container("runtime"), container("runtime")},
+			wantIssues: []errPkg.Issue{
+				{Code: errPkg.CodeDuplicateKey, Severity: errPkg.SeverityError},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devfileData, err := data.NewDevfileData("2.2.0")
+			if err != nil {
+				t.Fatalf("TestValidateDevfileData_Issues() unexpected error: %v", err)
+			}
+			devfileData.SetSchemaVersion("2.2.0")
+			// components are set directly as the devfile data rejects duplicate names
+			devfileData.GetDevfileWorkspaceSpec().Components = tt.components
+			devfileData.GetDevfileWorkspaceSpec().Commands = tt.commands
+
+			err = ValidateDevfileData(devfileData)
+			if err == nil {
+				t.Fatalf("TestValidateDevfileData_Issues() expected an error")
+			}
+			var issue *errPkg.Issue
+			assert.True(t, errors.As(err, &issue), "TestValidateDevfileData_Issues(): issues should be found with errors.As")
+			if tt.wantAs != nil {
+				assert.True(t, errors.As(err, tt.wantAs), "TestValidateDevfileData_Issues(): the devfile/api error should be found with errors.As")
+			}
+
+			issues := errPkg.GetIssues(err)
+			var gotIssues []errPkg.Issue
+			for _, issue := range issues {
+				assert.Contains(t, err.Error(), issue.Message, "TestValidateDevfileData_Issues(): issue message should be part of the error message")
+				gotIssue := *issue
+				gotIssue.Message = ""
+				assert.Error(t, gotIssue.Err, "TestValidateDevfileData_Issues(): issue should wrap the validation error")
+				gotIssue.Err = nil
+				gotIssues = append(gotIssues, gotIssue)
+			}
+			assert.Equal(t, tt.wantIssues, gotIssues, "TestValidateDevfileData_Issues(): issues should match")
+		})
+	}
+}
@@ -25,7 +25,8 @@ import (
 	"github.com/hashicorp/go-multierror"
 )
 
-// ValidateDevfileData validates whether sections of devfile are compatible
+// ValidateDevfileData validates whether sections of devfile are compatible.
+// The individual issues found in the devfile can be retrieved from the returned error with errors.GetIssues of the parser.
 func ValidateDevfileData(data devfileData.DevfileData) error {
 
 	commands, err := data.GetCommands(common.DevfileOptions{})
@@ -75,7 +76,10 @@ func ValidateDevfileData(data devfileData.DevfileData) error {
 			returnedErr = multierror.Append(returnedErr, err)
 		}
 
-		return returnedErr
+		if returnedErr != nil {
+			return withIssues(returnedErr, newDevfileElements(components, commands, projects, starterProjects))
+		}
+		return nil
 
 	default:
 		return fmt.Errorf("unknown devfile type %T", d)
@@ -0,0 +1,184 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devfile
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	"github.com/pkg/errors"
+)
+
+// variableReferenceRegex matches the variable references replaced by the devfile API, e.g. {{name}}
+var variableReferenceRegex = regexp.MustCompile(`\{\{\s*(.*?)\s*\}\}`)
+
+// variableDefaultSeparator separates the name of a variable from its default value in a reference, e.g. {{name:-default}}
+const variableDefaultSeparator = ":-"
+
+// resolveVariables sets the variables of the devfile to the values of the variables it defines or references, looked up
+// in order in the external variables, the variable providers and the devfile, and returns them with their source.
+// As the devfile API replaces a reference with the variable named after its whole content, references with a default
+// value, e.g. {{name:-default}}, are defined as variables too. The returned function restores the variables of the
+// devfile once the references are replaced: it removes the references with a default value and the sensitive variables
+// the devfile does not define, and restores the devfile values of the other sensitive variables.
+func resolveVariables(d parser.DevfileObj, args parser.ParserArgs) (map[string]parser.ResolvedVariable, func(), error) {
+	spec := d.Data.GetDevfileWorkspaceSpec()
+	if spec.Variables == nil {
+		spec.Variables = map[string]string{}
+	}
+	references, err := variableReferences(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	devfileVariables := map[string]string{}
+	for name, value := range spec.Variables {
+		devfileVariables[name] = value
+	}
+	sensitive := map[string]bool{}
+	for _, name := range args.SensitiveVariables {
+		sensitive[name] = true
+	}
+
+	nameSet := map[string]bool{}
+	for key := range spec.Variables {
+		nameSet[key] = true
+	}
+	for _, reference := range references {
+		name, _, _ := strings.Cut(reference, variableDefaultSeparator)
+		nameSet[name] = true
+	}
+	var names []string
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := map[string]parser.ResolvedVariable{}
+	for name, value := range args.ExternalVariables {
+		resolved[name] = parser.ResolvedVariable{Value: value, Source: parser.VariableSourceExternal, Sensitive: sensitive[name]}
+	}
+	lookup := parser.VariableLookup{Context: args.Context, K8sClient: args.K8sClient, DefaultNamespace: args.DefaultNamespace}
+	for _, provider := range args.VariableProviders {
+		var unresolved []string
+		for _, name := range names {
+			if _, ok := resolved[name]; !ok {
+				unresolved = append(unresolved, name)
+			}
+		}
+		if len(unresolved) == 0 {
+			break
+		}
+		values, err := provider.Lookup(lookup, unresolved)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to look up variables with %s", provider.Name())
+		}
+		sensitiveProvider, ok := provider.(parser.SensitiveVariableProvider)
+		providerSensitive := ok && sensitiveProvider.Sensitive()
+		for _, name := range unresolved {
+			if value, ok := values[name]; ok {
+				resolved[name] = parser.ResolvedVariable{Value: value, Source: provider.Name(), Sensitive: providerSensitive || sensitive[name]}
+			}
+		}
+	}
+	for name, value := range spec.Variables {
+		if _, ok := resolved[name]; !ok {
+			resolved[name] = parser.ResolvedVariable{Value: value, Source: parser.VariableSourceDevfile, Sensitive: sensitive[name]}
+		}
+	}
+	for name, variable := range resolved {
+		spec.Variables[name] = variable.Value
+	}
+
+	var defaultKeys []string
+	for _, reference := range references {
+		name, defaultValue, hasDefault := strings.Cut(reference, variableDefaultSeparator)
+		if !hasDefault {
+			continue
+		}
+		value := defaultValue
+		if variable, ok := resolved[name]; !ok {
+			// the first default value is reported for variables referenced with several ones
+			resolved[name] = parser.ResolvedVariable{Value: defaultValue, Source: parser.VariableSourceDefault}
+		} else if variable.Source != parser.VariableSourceDefault {
+			value = variable.Value
+		}
+		if _, defined := spec.Variables[reference]; !defined {
+			spec.Variables[reference] = value
+			defaultKeys = append(defaultKeys, reference)
+		}
+	}
+	restore := func() {
+		for _, key := range defaultKeys {
+			delete(spec.Variables, key)
+		}
+		for name, variable := range resolved {
+			if !variable.Sensitive {
+				continue
+			}
+			if value, ok := devfileVariables[name]; ok {
+				spec.Variables[name] = value
+			} else {
+				delete(spec.Variables, name)
+			}
+		}
+	}
+	return resolved, restore, nil
+}
+
+// variableReferences returns the distinct variable references of the elements of spec where variables are replaced,
+// i.e. the content of the references, sorted
+func variableReferences(spec *v1.DevWorkspaceTemplateSpec) ([]string, error) {
+	elements := []interface{}{spec.Components, spec.Commands, spec.Projects, spec.StarterProjects, spec.DependentProjects}
+	content, err := json.Marshal(elements)
+	if err != nil {
+		return nil, err
+	}
+	var values interface{}
+	if err = json.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+
+	referenceSet := map[string]bool{}
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			for _, match := range variableReferenceRegex.FindAllStringSubmatch(v, -1) {
+				referenceSet[match[1]] = true
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(values)
+
+	var references []string
+	for reference := range referenceSet {
+		references = append(references, reference)
+	}
+	sort.Strings(references)
+	return references, nil
+}
@@ -0,0 +1,125 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	"github.com/devfile/library/v2/pkg/devfile/parser/data/v2/common"
+	"github.com/devfile/library/v2/pkg/testingutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseDevfileAndValidate_VariableProviders(t *testing.T) {
+	const devfile = `schemaVersion: 2.2.0
+metadata:
+  name: main
+variables:
+  FROM_DEVFILE: devfile
+  OVERRIDDEN: devfile
+components:
+  - name: runtime
+    container:
+      image: quay.io/runtime
+commands:
+  - id: run
+    exec:
+      component: runtime
+      commandLine: "{{FROM_DEVFILE}} {{OVERRIDDEN}} {{FROM_ENV}} {{FROM_DOTENV}} {{FROM_SECRET}} {{FROM_CONFIGMAP}} {{EXTERNAL}} {{MISSING:-fallback}} {{ FROM_ENV:-unused }} {{MISSING:-other}}"
+`
+	dir := t.TempDir()
+	files := map[string]string{
+		"devfile.yaml": devfile,
+		".env":         "# local values\nFROM_DOTENV=dotenv\nexport OVERRIDDEN='dotenv'\nFROM_ENV=\"dotenv\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("TestParseDevfileAndValidate_VariableProviders() failed to write %s: %v", name, err)
+		}
+	}
+	t.Setenv("TEST_VARIABLES_FROM_ENV", "env")
+
+	k8sClient := &testingutil.FakeK8sClient{
+		ExpectedNamespace: "my-namespace",
+		Secrets: map[string]corev1.Secret{
+			"credentials": {Data: map[string][]byte{"FROM_SECRET": []byte("secret"), "FROM_CONFIGMAP": []byte("secret")}},
+		},
+		ConfigMaps: map[string]corev1.ConfigMap{
+			"settings": {Data: map[string]string{"FROM_CONFIGMAP": "configmap", "FROM_SECRET": "configmap"}},
+		},
+	}
+	falseValue := false
+	args := parser.ParserArgs{
+		Path:                 filepath.Join(dir, "devfile.yaml"),
+		DownloadGitResources: &falseValue,
+		Context:              context.Background(),
+		K8sClient:            k8sClient,
+		DefaultNamespace:     "my-namespace",
+		ExternalVariables:    map[string]string{"EXTERNAL": "external", "FROM_SECRET": "external"},
+		VariableProviders: []parser.VariableProvider{
+			parser.EnvVariableProvider{Prefix: "TEST_VARIABLES_"},
+			parser.DotEnvVariableProvider{Path: filepath.Join(dir, ".env")},
+			parser.DotEnvVariableProvider{Path: filepath.Join(dir, "missing.env"), Optional: true},
+			parser.SecretVariableProvider{SecretName: "credentials"},
+			parser.ConfigMapVariableProvider{ConfigMapName: "settings"},
+			parser.SecretVariableProvider{SecretName: "missing", Optional: true},
+		},
+	}
+
+	d, _, err := ParseDevfileAndValidate(args)
+	if err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_VariableProviders() unexpected error: %v", err)
+	}
+	commands, err := d.Data.GetCommands(common.DevfileOptions{})
+	if err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_VariableProviders() unexpected error getting the commands: %v", err)
+	}
+	assert.Equal(t, "devfile dotenv env dotenv external secret external fallback env other", commands[0].Exec.CommandLine,
+		"TestParseDevfileAndValidate_VariableProviders(): references should be replaced according to the precedence")
+	assert.Equal(t, map[string]parser.ResolvedVariable{
+		"FROM_DEVFILE":   {Value: "devfile", Source: parser.VariableSourceDevfile},
+		"OVERRIDDEN":     {Value: "dotenv", Source: "dotenv:" + filepath.Join(dir, ".env")},
+		"FROM_ENV":       {Value: "env", Source: "env:TEST_VARIABLES_"},
+		"FROM_DOTENV":    {Value: "dotenv", Source: "dotenv:" + filepath.Join(dir, ".env")},
+		"FROM_SECRET":    {Value: "external", Source: parser.VariableSourceExternal},
+		"FROM_CONFIGMAP": {Value: "secret", Source: "secret:credentials", Sensitive: true},
+		"EXTERNAL":       {Value: "external", Source: parser.VariableSourceExternal},
+		"MISSING":        {Value: "fallback", Source: parser.VariableSourceDefault},
+	}, d.Variables, "TestParseDevfileAndValidate_VariableProviders(): resolved variables should match")
+	assert.Equal(t, map[string]string{
+		"FROM_DEVFILE": "devfile",
+		"OVERRIDDEN":   "dotenv",
+		"FROM_ENV":     "env",
+		"FROM_DOTENV":  "dotenv",
+		"FROM_SECRET":  "external",
+		"EXTERNAL":     "external",
+	}, d.Data.GetDevfileWorkspaceSpec().Variables, "TestParseDevfileAndValidate_VariableProviders(): default and sensitive values should not be kept as variables")
+	assert.Empty(t, d.Warnings, "TestParseDevfileAndValidate_VariableProviders(): unexpected warnings")
+
+	t.Run("provider failure", func(t *testing.T) {
+		failing := args
+		failing.VariableProviders = []parser.VariableProvider{parser.ConfigMapVariableProvider{ConfigMapName: "missing"}}
+		_, _, err := ParseDevfileAndValidate(failing)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "failed to look up variables with configmap:missing")
+		}
+	})
+}
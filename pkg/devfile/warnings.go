@@ -0,0 +1,104 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devfile/api/v2/pkg/validation/variables"
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+)
+
+// addWarnings adds the warnings to the devfile, located in the devfile defining the elements they refer to
+func addWarnings(d *parser.DevfileObj, warnings errPkg.Issues) {
+	if len(warnings) == 0 {
+		return
+	}
+	d.Warnings = append(d.Warnings, d.LocateValidationIssues(warnings)...)
+}
+
+// elementWarning returns a warning about the element with the given name at index in list, e.g. components
+func elementWarning(code errPkg.IssueCode, list string, index int, name string, message string) *errPkg.Issue {
+	return &errPkg.Issue{
+		Code:     code,
+		Severity: errPkg.SeverityWarning,
+		Message:  message,
+		Field:    fmt.Sprintf("/%s/%d", list, index),
+		Element:  name,
+	}
+}
+
+// componentWarning returns a warning about the component of the devfile with the given name
+func componentWarning(d *parser.DevfileObj, code errPkg.IssueCode, name string, message string) *errPkg.Issue {
+	index := -1
+	for i, component := range d.Data.GetDevfileWorkspaceSpec().Components {
+		if component.Name == name {
+			index = i
+			break
+		}
+	}
+	return elementWarning(code, "components", index, name, message)
+}
+
+// variableWarnings returns the warnings about the elements of the devfile referencing the undefined variables reported
+// in varWarning, in the order of the elements in the devfile
+func variableWarnings(d parser.DevfileObj, varWarning variables.VariableWarning) errPkg.Issues {
+	spec := d.Data.GetDevfileWorkspaceSpec()
+	type elementList struct {
+		list       string
+		kind       string
+		names      []string
+		references map[string][]string
+	}
+	components := elementList{list: "components", kind: "component", references: varWarning.Components}
+	for _, component := range spec.Components {
+		components.names = append(components.names, component.Name)
+	}
+	commands := elementList{list: "commands", kind: "command", references: varWarning.Commands}
+	for _, command := range spec.Commands {
+		commands.names = append(commands.names, command.Id)
+	}
+	projects := elementList{list: "projects", kind: "project", references: varWarning.Projects}
+	for _, project := range spec.Projects {
+		projects.names = append(projects.names, project.Name)
+	}
+	starterProjects := elementList{list: "starterProjects", kind: "starter project", references: varWarning.StarterProjects}
+	for _, starterProject := range spec.StarterProjects {
+		starterProjects.names = append(starterProjects.names, starterProject.Name)
+	}
+	dependentProjects := elementList{list: "dependentProjects", kind: "dependent project", references: varWarning.DependentProjects}
+	for _, dependentProject := range spec.DependentProjects {
+		dependentProjects.names = append(dependentProjects.names, dependentProject.Name)
+	}
+
+	var warnings errPkg.Issues
+	for _, elements := range []elementList{components, commands, projects, starterProjects, dependentProjects} {
+		for i, name := range elements.names {
+			references := elements.references[name]
+			if len(references) == 0 {
+				continue
+			}
+			references = append([]string(nil), references...)
+			sort.Strings(references)
+			warnings = append(warnings, elementWarning(errPkg.CodeUndefinedVariable, elements.list, i, name,
+				fmt.Sprintf("%s %s references the undefined variables %s", elements.kind, name, strings.Join(references, ", "))))
+		}
+	}
+	return warnings
+}
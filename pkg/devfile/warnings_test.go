@@ -0,0 +1,185 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devfile/library/v2/pkg/devfile/parser"
+	errPkg "github.com/devfile/library/v2/pkg/devfile/parser/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDevfileAndValidate_Warnings(t *testing.T) {
+	const devfile = `schemaVersion: 2.2.0
+metadata:
+  name: main
+variables:
+  defined: value
+components:
+  - name: runtime
+    container:
+      image: quay.io/runtime
+      args: ["{{undefined}}"]
+  - name: builder
+    image:
+      imageName: unmatched-image
+      dockerfile:
+        uri: Dockerfile
+  - name: deploy
+    kubernetes:
+      uri: deploy.yaml
+commands:
+  - id: run
+    exec:
+      component: runtime
+      commandLine: "{{defined}} {{missing}} {{absent}}"
+`
+	dir := t.TempDir()
+	devfilePath := filepath.Join(dir, "devfile.yaml")
+	if err := os.WriteFile(devfilePath, []byte(devfile), 0600); err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_Warnings() failed to write the devfile: %v", err)
+	}
+
+	invalidHTTPTimeout := -1
+	falseValue := false
+	d, _, err := ParseDevfileAndValidate(parser.ParserArgs{
+		Path:                          devfilePath,
+		HTTPTimeout:                   &invalidHTTPTimeout,
+		ConvertKubernetesContentInUri: &falseValue,
+		DownloadGitResources:          &falseValue,
+		ImageNamesAsSelector:          &parser.ImageSelectorArgs{Registry: "localhost:5000"},
+	})
+	if err != nil {
+		t.Fatalf("TestParseDevfileAndValidate_Warnings() unexpected error: %v", err)
+	}
+
+	location := func(line int, pointer string) []errPkg.Location {
+		return []errPkg.Location{{File: devfilePath, Line: line, Column: 5, JSONPointer: pointer}}
+	}
+	want := errPkg.Issues{
+		{
+			Code:     errPkg.CodeInvalidHTTPTimeout,
+			Severity: errPkg.SeverityWarning,
+			Message:  "invalid HTTP timeout -1, the default timeout of 30s is used",
+		},
+		{
+			Code:      errPkg.CodeUndefinedVariable,
+			Severity:  errPkg.SeverityWarning,
+			Message:   "component runtime references the undefined variables undefined",
+			Field:     "/components/0",
+			Element:   "runtime",
+			Locations: location(7, "/components/0"),
+		},
+		{
+			Code:      errPkg.CodeUndefinedVariable,
+			Severity:  errPkg.SeverityWarning,
+			Message:   "command run references the undefined variables absent, missing",
+			Field:     "/commands/0",
+			Element:   "run",
+			Locations: location(20, "/commands/0"),
+		},
+		{
+			Code:      errPkg.CodeUnmatchedImageSelector,
+			Severity:  errPkg.SeverityWarning,
+			Message:   "the image unmatched-image of component builder does not match the image of any component",
+			Field:     "/components/1",
+			Element:   "builder",
+			Locations: location(11, "/components/1"),
+		},
+		{
+			Code:      errPkg.CodeKubernetesUriNotPatched,
+			Severity:  errPkg.SeverityWarning,
+			Message:   "image names are not replaced in the resources of component deploy, referenced by the uri deploy.yaml",
+			Field:     "/components/2",
+			Element:   "deploy",
+			Locations: location(16, "/components/2"),
+		},
+	}
+	assert.Equal(t, want, d.Warnings, "TestParseDevfileAndValidate_Warnings(): warnings should match")
+}
+
+func TestParseDevfileAndValidate_WarningValidationIssues(t *testing.T) {
+	const devfile = `schemaVersion: 2.2.0
+metadata:
+  name: main
+components:
+  - name: runtime
+    container:
+      image: quay.io/runtime
+%s
+commands:
+  - id: build
+    exec:
+      component: runtime
+      commandLine: make
+      group:
+        kind: build
+  - id: build-debug
+    exec:
+      component: runtime
+      commandLine: make debug
+      group:
+        kind: build
+`
+	tests := []struct {
+		name       string
+		env        string
+		wantErr    string
+		wantIssues []errPkg.IssueCode
+	}{
+		{
+			name:       "warning issues fail the validation",
+			wantErr:    "command group build warning - there should be exactly one default command",
+			wantIssues: []errPkg.IssueCode{errPkg.CodeMissingDefaultCommand},
+		},
+		{
+			name: "warning issues are reported along with errors",
+			env: `      env:
+        - name: PROJECTS_ROOT
+          value: /projects`,
+			wantErr:    "env variable PROJECTS_ROOT is reserved and cannot be customized in component runtime",
+			wantIssues: []errPkg.IssueCode{errPkg.CodeReservedEnv, errPkg.CodeMissingDefaultCommand},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			devfilePath := filepath.Join(dir, "devfile.yaml")
+			if err := os.WriteFile(devfilePath, []byte(fmt.Sprintf(devfile, tt.env)), 0600); err != nil {
+				t.Fatalf("TestParseDevfileAndValidate_WarningValidationIssues() failed to write the devfile: %v", err)
+			}
+
+			falseValue := false
+			d, _, err := ParseDevfileAndValidate(parser.ParserArgs{Path: devfilePath, DownloadGitResources: &falseValue})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr, "TestParseDevfileAndValidate_WarningValidationIssues(): error should match")
+				var codes []errPkg.IssueCode
+				for _, issue := range errPkg.GetIssues(err) {
+					codes = append(codes, issue.Code)
+				}
+				assert.ElementsMatch(t, tt.wantIssues, codes, "TestParseDevfileAndValidate_WarningValidationIssues(): issues should match")
+			}
+			if assert.Len(t, d.Warnings, 1, "TestParseDevfileAndValidate_WarningValidationIssues(): expected a single warning") {
+				assert.Equal(t, errPkg.CodeMissingDefaultCommand, d.Warnings[0].Code)
+				assert.Equal(t, errPkg.SeverityWarning, d.Warnings[0].Severity)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IOFs implements a read-only Filesystem on top of an io/fs.FS, e.g. an embed.FS or a zip.Reader.
+// Paths are slash-separated paths relative to the root of the FS, a leading slash is ignored.
+// Every operation writing to the filesystem fails with errors.ErrUnsupported.
+type IOFs struct {
+	FS fs.FS
+}
+
+var _ Filesystem = IOFs{}
+
+// NewIOFs returns a read-only Filesystem reading from fsys
+func NewIOFs(fsys fs.FS) IOFs {
+	return IOFs{FS: fsys}
+}
+
+// name returns the fs.FS path of a file name
+func (IOFs) name(name string) string {
+	name = strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// unsupported returns the error of the operations writing to the filesystem
+func unsupported(op string, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: errors.ErrUnsupported}
+}
+
+// Stat via fs.Stat
+func (f IOFs) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(f.FS, f.name(name))
+}
+
+// Create is not supported
+func (IOFs) Create(name string) (File, error) {
+	return nil, unsupported("create", name)
+}
+
+// Open via fs.FS.Open
+func (f IOFs) Open(name string) (File, error) {
+	file, err := f.FS.Open(f.name(name))
+	if err != nil {
+		return nil, err
+	}
+	return &ioFile{file: file, name: name}, nil
+}
+
+// OpenFile via fs.FS.Open, only supported for reading
+func (f IOFs) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, unsupported("open", name)
+	}
+	return f.Open(name)
+}
+
+// Rename is not supported
+func (IOFs) Rename(oldpath, newpath string) error {
+	return unsupported("rename", oldpath)
+}
+
+// MkdirAll is not supported
+func (IOFs) MkdirAll(path string, perm os.FileMode) error {
+	return unsupported("mkdir", path)
+}
+
+// Chtimes is not supported
+func (IOFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return unsupported("chtimes", name)
+}
+
+// RemoveAll is not supported
+func (IOFs) RemoveAll(path string) error {
+	return unsupported("remove", path)
+}
+
+// Remove is not supported
+func (IOFs) Remove(name string) error {
+	return unsupported("remove", name)
+}
+
+// Chmod is not supported
+func (IOFs) Chmod(name string, mode os.FileMode) error {
+	return unsupported("chmod", name)
+}
+
+// Getwd returns the root of the FS
+func (IOFs) Getwd() (dir string, err error) {
+	return "/", nil
+}
+
+// ReadFile via fs.ReadFile
+func (f IOFs) ReadFile(filename string) ([]byte, error) {
+	return fs.ReadFile(f.FS, f.name(filename))
+}
+
+// WriteFile is not supported
+func (IOFs) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	return unsupported("write", filename)
+}
+
+// TempDir is not supported
+func (IOFs) TempDir(dir, prefix string) (string, error) {
+	return "", unsupported("mkdirtemp", dir)
+}
+
+// TempFile is not supported
+func (IOFs) TempFile(dir, prefix string) (File, error) {
+	return nil, unsupported("createtemp", dir)
+}
+
+// ReadDir via fs.ReadDir
+func (f IOFs) ReadDir(dirname string) ([]os.FileInfo, error) {
+	dirEntries, err := fs.ReadDir(f.FS, f.name(dirname))
+	if err != nil {
+		return []os.FileInfo{}, err
+	}
+
+	dirsInfo := make([]os.FileInfo, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		info, err := dirEntry.Info()
+		if err != nil {
+			return dirsInfo, err
+		}
+		dirsInfo = append(dirsInfo, info)
+	}
+	return dirsInfo, nil
+}
+
+// Walk via fs.WalkDir, the walked paths are the paths in the FS
+func (f IOFs) Walk(root string, walkFn filepath.WalkFunc) error {
+	return fs.WalkDir(f.FS, f.name(root), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return walkFn(path, nil, err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return walkFn(path, nil, err)
+		}
+		return walkFn(path, info, nil)
+	})
+}
+
+// ioFile implements a read-only File on top of an fs.File
+type ioFile struct {
+	file fs.File
+	name string
+}
+
+// Name returns the name the file was opened with
+func (file *ioFile) Name() string {
+	return file.name
+}
+
+// Write is not supported
+func (file *ioFile) Write(b []byte) (n int, err error) {
+	return 0, unsupported("write", file.name)
+}
+
+// WriteString is not supported
+func (file *ioFile) WriteString(s string) (int, error) {
+	return 0, unsupported("write", file.name)
+}
+
+// Sync is a no-op
+func (file *ioFile) Sync() error {
+	return nil
+}
+
+// Close via fs.File.Close
+func (file *ioFile) Close() error {
+	return file.file.Close()
+}
+
+// Read via fs.File.Read
+func (file *ioFile) Read(b []byte) (n int, err error) {
+	return file.file.Read(b)
+}
+
+// Readdir via fs.ReadDirFile.ReadDir
+func (file *ioFile) Readdir(n int) ([]os.FileInfo, error) {
+	dir, ok := file.file.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: file.name, Err: errors.New("not a directory")}
+	}
+	dirEntries, err := dir.ReadDir(n)
+	infos := make([]os.FileInfo, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		info, infoErr := dirEntry.Info()
+		if infoErr != nil {
+			return infos, infoErr
+		}
+		infos = append(infos, info)
+	}
+	// like os.File.Readdir, io.EOF is returned at the end of the directory when n > 0
+	return infos, err
+}
@@ -21,12 +21,16 @@ import (
 	"fmt"
 
 	"github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type FakeK8sClient struct {
 	client.Client         // To satisfy interface; override all used methods
 	DevWorkspaceResources map[string]v1alpha2.DevWorkspaceTemplate
+	Secrets               map[string]corev1.Secret
+	ConfigMaps            map[string]corev1.ConfigMap
 	Errors                map[string]string
 	ExpectedNamespace     string
 }
@@ -35,13 +39,26 @@ func (client *FakeK8sClient) Get(_ context.Context, namespacedName client.Object
 	if client.ExpectedNamespace != "" && client.ExpectedNamespace != namespacedName.Namespace {
 		return fmt.Errorf("expected namespace %s, got %s", client.ExpectedNamespace, namespacedName.Namespace)
 	}
-	template, ok := obj.(*v1alpha2.DevWorkspaceTemplate)
-	if !ok {
-		return fmt.Errorf("called Get() in fake client with non-DevWorkspaceTemplate")
-	}
-	if element, ok := client.DevWorkspaceResources[namespacedName.Name]; ok {
-		*template = element
-		return nil
+	switch typed := obj.(type) {
+	case *v1alpha2.DevWorkspaceTemplate:
+		if element, ok := client.DevWorkspaceResources[namespacedName.Name]; ok {
+			*typed = element
+			return nil
+		}
+	case *corev1.Secret:
+		if element, ok := client.Secrets[namespacedName.Name]; ok {
+			*typed = element
+			return nil
+		}
+		return kerrors.NewNotFound(corev1.Resource("secrets"), namespacedName.Name)
+	case *corev1.ConfigMap:
+		if element, ok := client.ConfigMaps[namespacedName.Name]; ok {
+			*typed = element
+			return nil
+		}
+		return kerrors.NewNotFound(corev1.Resource("configmaps"), namespacedName.Name)
+	default:
+		return fmt.Errorf("called Get() in fake client with an unsupported type %T", obj)
 	}
 
 	if err, ok := client.Errors[namespacedName.Name]; ok {
@@ -0,0 +1,347 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testingutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	indexSchema "github.com/devfile/registry-support/index/generator/schema"
+	versionpkg "github.com/hashicorp/go-version"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// FakeRegistryCatalog is the repository namespace of the OCI artifacts of the stacks of a FakeRegistry
+	FakeRegistryCatalog = "devfile-catalog"
+
+	fakeRegistryConfigMediaType  = "application/vnd.devfileio.devfile.config.v2+json"
+	fakeRegistryDevfileMediaType = "application/vnd.devfileio.devfile.layer.v1"
+	fakeRegistryArchiveMediaType = "application/x-tar"
+)
+
+// FakeRegistry is an in-process devfile registry, to be started with httptest.NewServer. It serves the index, devfile
+// and starter project endpoints of the registry REST API, and the stacks as OCI artifacts made of their devfile and
+// an archive of their resources, as the devfile registries do.
+type FakeRegistry struct {
+	// Index are the stacks and samples of the registry, along with the versions of the stacks
+	Index []indexSchema.Schema
+	// Devfiles are the devfiles of the stacks by name and version, e.g. nodejs:2.1.1, and of the samples by name
+	Devfiles map[string]string
+	// Resources are the files of the stacks besides their devfile, by stack name and version then by file name
+	Resources map[string]map[string]string
+	// StarterProjects are the archives of the starter projects, by stack name and starter project name,
+	// e.g. nodejs/nodejs-starter
+	StarterProjects map[string][]byte
+	// Token is required as bearer token if set
+	Token string
+	// Username and Password are required as basic auth credentials if set
+	Username string
+	Password string
+}
+
+func (r *FakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !r.authorized(req) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	urlPath := strings.Trim(req.URL.Path, "/")
+	parts := strings.Split(urlPath, "/")
+	switch {
+	case parts[0] == "index" || parts[0] == "v2index":
+		r.serveIndex(w, req, parts)
+	case parts[0] == "devfiles" && len(parts) >= 2:
+		r.serveDevfile(w, parts[1:])
+	case urlPath == "v2":
+		w.WriteHeader(http.StatusOK)
+	case strings.HasPrefix(urlPath, "v2/"+FakeRegistryCatalog+"/"):
+		r.serveOCI(w, req, strings.TrimPrefix(urlPath, "v2/"+FakeRegistryCatalog+"/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// authorized returns true if the request has the required credentials
+func (r *FakeRegistry) authorized(req *http.Request) bool {
+	if r.Token != "" && req.Header.Get("Authorization") != "Bearer "+r.Token {
+		return false
+	}
+	if r.Username != "" {
+		username, password, ok := req.BasicAuth()
+		return ok && username == r.Username && password == r.Password
+	}
+	return true
+}
+
+// serveIndex serves the stacks, the samples or both, filtered by architecture and deprecation
+func (r *FakeRegistry) serveIndex(w http.ResponseWriter, req *http.Request, parts []string) {
+	devfileTypes := map[indexSchema.DevfileType]bool{indexSchema.StackDevfileType: true}
+	if len(parts) > 1 {
+		switch parts[1] {
+		case "sample":
+			devfileTypes = map[indexSchema.DevfileType]bool{indexSchema.SampleDevfileType: true}
+		case "all":
+			devfileTypes[indexSchema.SampleDevfileType] = true
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+	query := req.URL.Query()
+	index := []indexSchema.Schema{}
+	for _, entry := range r.Index {
+		if !devfileTypes[entry.Type] || !supportsArchitectures(entry.Architectures, query["arch"]) {
+			continue
+		}
+		if deprecated := query.Get("deprecated"); deprecated != "" && deprecated != fmt.Sprint(hasTag(entry.Tags, "Deprecated")) {
+			continue
+		}
+		if parts[0] == "index" {
+			// the index without versions only lists the default version
+			entry.Versions = nil
+		}
+		index = append(index, entry)
+	}
+	writeJSON(w, index)
+}
+
+// serveDevfile serves the devfile of a stack version or of a sample, or the archive of a starter project, at
+// <name>[/<version>][/starter-projects/<starter project>]
+func (r *FakeRegistry) serveDevfile(w http.ResponseWriter, parts []string) {
+	name := parts[0]
+	version := ""
+	if len(parts) == 2 || len(parts) == 4 {
+		version = parts[1]
+	}
+	if len(parts) >= 3 {
+		starterProject := parts[len(parts)-1]
+		archive, ok := r.StarterProjects[name+"/"+starterProject]
+		if !ok || parts[len(parts)-2] != "starter-projects" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		_, _ = w.Write(archive)
+		return
+	}
+
+	devfile, ok := r.Devfiles[name]
+	if resolved, found := r.resolveVersion(name, version); found {
+		devfile, ok = r.Devfiles[name+":"+resolved]
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write([]byte(devfile))
+}
+
+// resolveVersion returns the version of the stack, its default version if version is empty and its highest version if
+// version is latest
+func (r *FakeRegistry) resolveVersion(name string, version string) (string, bool) {
+	for _, entry := range r.Index {
+		if entry.Name != name || entry.Type != indexSchema.StackDevfileType {
+			continue
+		}
+		switch version {
+		case "":
+			for _, stackVersion := range entry.Versions {
+				if stackVersion.Default {
+					return stackVersion.Version, true
+				}
+			}
+			return entry.Version, entry.Version != ""
+		case "latest":
+			var latest *versionpkg.Version
+			for _, stackVersion := range entry.Versions {
+				if v, err := versionpkg.NewVersion(stackVersion.Version); err == nil && (latest == nil || v.GreaterThan(latest)) {
+					latest = v
+				}
+			}
+			if latest == nil {
+				return entry.Version, entry.Version != ""
+			}
+			return latest.Original(), true
+		default:
+			return version, true
+		}
+	}
+	return "", false
+}
+
+// serveOCI serves the manifests and blobs of the OCI artifacts of the stacks, at <name>/manifests/<version or digest>
+// and <name>/blobs/<digest>
+func (r *FakeRegistry) serveOCI(w http.ResponseWriter, req *http.Request, urlPath string) {
+	var content []byte
+	var mediaType string
+	switch {
+	case strings.Contains(urlPath, "/manifests/"):
+		parts := strings.SplitN(urlPath, "/manifests/", 2)
+		manifest, ok := r.manifest(parts[0], parts[1])
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		content, mediaType = manifest, ocispec.MediaTypeImageManifest
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+	case strings.Contains(urlPath, "/blobs/"):
+		parts := strings.SplitN(urlPath, "/blobs/", 2)
+		blob, ok := r.blob(parts[0], digest.Digest(parts[1]))
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		content, mediaType = blob, "application/octet-stream"
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+	if req.Method == http.MethodGet {
+		_, _ = w.Write(content)
+	}
+}
+
+// artifact returns the layers of the OCI artifact of a stack version, with their content
+func (r *FakeRegistry) artifact(name string, version string) ([]ocispec.Descriptor, [][]byte, bool) {
+	devfile, ok := r.Devfiles[name+":"+version]
+	if !ok {
+		return nil, nil, false
+	}
+	layers := []ocispec.Descriptor{ociLayer("devfile.yaml", fakeRegistryDevfileMediaType, []byte(devfile))}
+	contents := [][]byte{[]byte(devfile)}
+	if resources := r.Resources[name+":"+version]; len(resources) > 0 {
+		archive := tarArchive(resources)
+		layers = append(layers, ociLayer("archive.tar", fakeRegistryArchiveMediaType, archive))
+		contents = append(contents, archive)
+	}
+	return layers, contents, true
+}
+
+// manifest returns the manifest of the OCI artifact of a stack, by version or digest
+func (r *FakeRegistry) manifest(name string, reference string) ([]byte, bool) {
+	if strings.HasPrefix(reference, "sha256:") {
+		for key := range r.Devfiles {
+			if stackName, version, ok := strings.Cut(key, ":"); ok && stackName == name {
+				if manifest, ok := r.manifest(name, version); ok && digest.FromBytes(manifest).String() == reference {
+					return manifest, true
+				}
+			}
+		}
+		return nil, false
+	}
+	layers, _, ok := r.artifact(name, reference)
+	if !ok {
+		return nil, false
+	}
+	config := []byte("{}")
+	manifest, _ := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.Descriptor{MediaType: fakeRegistryConfigMediaType, Digest: digest.FromBytes(config), Size: int64(len(config))},
+		Layers:    layers,
+	})
+	return manifest, true
+}
+
+// blob returns a blob of the OCI artifacts of a stack
+func (r *FakeRegistry) blob(name string, blobDigest digest.Digest) ([]byte, bool) {
+	if config := []byte("{}"); digest.FromBytes(config) == blobDigest {
+		return config, true
+	}
+	for key := range r.Devfiles {
+		stackName, version, ok := strings.Cut(key, ":")
+		if !ok || stackName != name {
+			continue
+		}
+		layers, contents, _ := r.artifact(name, version)
+		for i, layer := range layers {
+			if layer.Digest == blobDigest {
+				return contents[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ociLayer returns the descriptor of a layer of an OCI artifact holding a file
+func ociLayer(name string, mediaType string, content []byte) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType:   mediaType,
+		Digest:      digest.FromBytes(content),
+		Size:        int64(len(content)),
+		Annotations: map[string]string{ocispec.AnnotationTitle: name},
+	}
+}
+
+// tarArchive returns a tar archive of the files, sorted by name so that it is reproducible
+func tarArchive(files map[string]string) []byte {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	for _, name := range names {
+		_ = writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		_, _ = writer.Write([]byte(files[name]))
+	}
+	_ = writer.Close()
+	return buffer.Bytes()
+}
+
+// supportsArchitectures returns true if the entry supports all the architectures, entries without architectures
+// supporting all of them
+func supportsArchitectures(entryArchitectures []string, architectures []string) bool {
+	if len(entryArchitectures) == 0 {
+		return true
+	}
+	for _, architecture := range architectures {
+		if !hasTag(entryArchitectures, architecture) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasTag returns true if the tags contain the tag
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON writes the value as JSON
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(content)
+}
@@ -0,0 +1,265 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Credentials authenticate the requests to a host, either with a bearer token or with a username and password
+type Credentials struct {
+	Token    string
+	Username string
+	Password string
+}
+
+// SetAuthHeader sets the authorization header of the credentials, nil credentials setting none
+func (c *Credentials) SetAuthHeader(header http.Header) {
+	switch {
+	case c == nil:
+	case c.Token != "":
+		header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "" || c.Password != "":
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.Password)))
+	}
+}
+
+// GitToken returns the token to authenticate to a git provider with: the token, or the password since git providers
+// accept personal access tokens as passwords
+func (c *Credentials) GitToken() string {
+	if c == nil {
+		return ""
+	}
+	if c.Token != "" {
+		return c.Token
+	}
+	return c.Password
+}
+
+// CredentialProvider provides the credentials of the requests to a host, e.g. github.com, a GitHub Enterprise or GitLab
+// server, or a devfile or OCI registry. The host includes the port if it is not the default one, e.g. localhost:5000.
+type CredentialProvider interface {
+	// Credentials returns the credentials for the host, or nil if there are none
+	Credentials(host string) (*Credentials, error)
+}
+
+// StaticCredentials provides credentials by host. The keys can also be URLs, e.g. https://index.docker.io/v1/, whose
+// host is used.
+type StaticCredentials map[string]Credentials
+
+func (s StaticCredentials) Credentials(host string) (*Credentials, error) {
+	host = credentialHost(host)
+	for key, credentials := range s {
+		if credentialHost(key) == host {
+			credentials := credentials
+			return &credentials, nil
+		}
+	}
+	return nil, nil
+}
+
+// CredentialProviders provides the credentials of the first provider that has credentials for the host
+type CredentialProviders []CredentialProvider
+
+func (p CredentialProviders) Credentials(host string) (*Credentials, error) {
+	for _, provider := range p {
+		if provider == nil {
+			continue
+		}
+		credentials, err := provider.Credentials(host)
+		if err != nil || credentials != nil {
+			return credentials, err
+		}
+	}
+	return nil, nil
+}
+
+// CredentialsForURL returns the credentials provider provides for the host of rawURL, or nil if provider is nil or has
+// none
+func CredentialsForURL(provider CredentialProvider, rawURL string) (*Credentials, error) {
+	if provider == nil || rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, nil
+	}
+	return provider.Credentials(u.Host)
+}
+
+// credentialHost returns the lowercase host of a host or URL, without the default ports
+func credentialHost(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if i := strings.Index(key, "://"); i >= 0 {
+		key = key[i+3:]
+	}
+	if i := strings.IndexAny(key, "/?#"); i >= 0 {
+		key = key[:i]
+	}
+	key = strings.TrimSuffix(strings.TrimSuffix(key, ":443"), ":80")
+	switch key {
+	// the Docker Hub credentials are stored for its index
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	// the raw content of GitHub is fetched with the GitHub credentials
+	case RawGitHubHost:
+		return GitHubHost
+	}
+	return key
+}
+
+// NewNetrcCredentials reads the credentials of the machines of a netrc file. If path is empty, the file is read from
+// $NETRC, or ~/.netrc, a missing ~/.netrc providing no credentials. The login and password of a machine are provided as
+// username and password. The default entry is ignored, as sending its credentials to every host the parser fetches
+// from, e.g. the hosts of the parents of a devfile, would leak them.
+func NewNetrcCredentials(path string) (CredentialProvider, error) {
+	if path == "" {
+		path = os.Getenv("NETRC")
+	}
+	optional := path == ""
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return StaticCredentials{}, nil
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if optional && os.IsNotExist(err) {
+			return StaticCredentials{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read netrc file %s", path)
+	}
+
+	machines := map[string]*Credentials{}
+	var current *Credentials
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	macro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		// a macro definition ends with an empty line
+		if macro {
+			macro = strings.TrimSpace(line) != ""
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields) && !strings.HasPrefix(fields[i], "#"); i++ {
+			value := ""
+			if i+1 < len(fields) {
+				value = fields[i+1]
+			}
+			switch fields[i] {
+			case "machine":
+				current = &Credentials{}
+				machines[value] = current
+				i++
+			case "default":
+				current = nil
+			case "login":
+				if current != nil {
+					current.Username = value
+				}
+				i++
+			case "password":
+				if current != nil {
+					current.Password = value
+				}
+				i++
+			case "account":
+				i++
+			case "macdef":
+				macro = true
+				i = len(fields)
+			}
+		}
+	}
+
+	credentials := StaticCredentials{}
+	for machine, machineCredentials := range machines {
+		credentials[machine] = *machineCredentials
+	}
+	return credentials, nil
+}
+
+// dockerConfig is the part of the Docker config file holding the credentials of the registries
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		Username      string `json:"username"`
+		Password      string `json:"password"`
+		RegistryToken string `json:"registrytoken"`
+	} `json:"auths"`
+}
+
+// NewDockerConfigCredentials reads the credentials of the registries of a Docker config file. If path is empty, the file
+// is read from $DOCKER_CONFIG/config.json, or ~/.docker/config.json, and a missing file provides no credentials. Only
+// the credentials stored in the file are read, the credential helpers are not run.
+func NewDockerConfigCredentials(path string) (CredentialProvider, error) {
+	optional := path == ""
+	if path == "" {
+		dir := os.Getenv("DOCKER_CONFIG")
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return StaticCredentials{}, nil
+			}
+			dir = filepath.Join(home, ".docker")
+		}
+		path = filepath.Join(dir, "config.json")
+	}
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if optional && os.IsNotExist(err) {
+			return StaticCredentials{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read Docker config file %s", path)
+	}
+	var config dockerConfig
+	if err = json.Unmarshal(content, &config); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse Docker config file %s", path)
+	}
+
+	credentials := StaticCredentials{}
+	for registry, auth := range config.Auths {
+		registryCredentials := Credentials{Token: auth.RegistryToken, Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode the credentials of registry %s in Docker config file %s: %v", registry, path, err)
+			}
+			username, password, _ := strings.Cut(string(decoded), ":")
+			registryCredentials.Username, registryCredentials.Password = username, password
+		}
+		if registryCredentials != (Credentials{}) {
+			credentials[registry] = registryCredentials
+		}
+	}
+	return credentials, nil
+}
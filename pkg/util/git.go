@@ -16,11 +16,13 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -98,6 +100,9 @@ func (g *GitUrl) GetToken() string {
 
 type CommandType string
 
+// commitIDRegex matches full git commit ids
+var commitIDRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
 const (
 	GitCommand        CommandType = "git"
 	unsupportedCmdMsg             = "Unsupported command \"%s\" "
@@ -106,9 +111,9 @@ const (
 // Execute is exposed as a global variable for the purpose of running mock tests
 // only "git" is supported
 /* #nosec G204 -- used internally to execute various git actions and eventual cleanup of artifacts.  Calling methods validate user input to ensure commands are used appropriately */
-var execute = func(baseDir string, cmd CommandType, args ...string) ([]byte, error) {
+var execute = func(ctx context.Context, baseDir string, cmd CommandType, args ...string) ([]byte, error) {
 	if cmd == GitCommand {
-		c := exec.Command(string(cmd), args...)
+		c := exec.CommandContext(ctx, string(cmd), args...)
 		c.Dir = baseDir
 		output, err := c.CombinedOutput()
 		return output, err
@@ -117,30 +122,27 @@ var execute = func(baseDir string, cmd CommandType, args ...string) ([]byte, err
 	return []byte(""), fmt.Errorf(unsupportedCmdMsg, string(cmd))
 }
 
+// CloneGitRepo clones the git repository into destDir and switches to the revision, if one is set
 func (g *GitUrl) CloneGitRepo(destDir string) error {
+	return g.CloneGitRepoWithContext(context.Background(), destDir)
+}
+
+// CloneGitRepoWithContext clones the git repository into destDir and switches to the revision, if one is set.
+// The git commands are killed, and the context error returned, if ctx is cancelled or its deadline is exceeded.
+func (g *GitUrl) CloneGitRepoWithContext(ctx context.Context, destDir string) error {
 	exist := CheckPathExists(destDir)
 	if !exist {
 		return fmt.Errorf("failed to clone repo, destination directory: '%s' does not exists", destDir)
 	}
 
-	host := g.Host
-	if host == RawGitHubHost {
-		host = GitHubHost
-	}
+	repoUrl := g.repoURL()
 
-	var repoUrl string
-	if g.GetToken() == "" {
-		repoUrl = fmt.Sprintf("%s://%s/%s/%s.git", g.Protocol, host, g.Owner, g.Repo)
-	} else {
-		repoUrl = fmt.Sprintf("%s://token:%s@%s/%s/%s.git", g.Protocol, g.GetToken(), host, g.Owner, g.Repo)
-		if g.Host == BitbucketHost {
-			repoUrl = fmt.Sprintf("%s://x-token-auth:%s@%s/%s/%s.git", g.Protocol, g.GetToken(), host, g.Owner, g.Repo)
-		}
-	}
-
-	_, err := execute(destDir, "git", "clone", repoUrl, destDir)
+	_, err := execute(ctx, destDir, "git", "clone", repoUrl, destDir)
 
 	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to clone repo: %w", ctx.Err())
+		}
 		if g.GetToken() == "" {
 			return fmt.Errorf("failed to clone repo without a token, ensure that a token is set if the repo is private. error: %v", err)
 		} else {
@@ -149,8 +151,11 @@ func (g *GitUrl) CloneGitRepo(destDir string) error {
 	}
 
 	if g.Revision != "" {
-		_, err := execute(destDir, "git", "checkout", g.Revision)
+		_, err := execute(ctx, destDir, "git", "checkout", g.Revision)
 		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("failed to switch repo to revision %v: %w", g.Revision, ctx.Err())
+			}
 			err = os.RemoveAll(destDir)
 			if err != nil {
 				return err
@@ -162,6 +167,74 @@ func (g *GitUrl) CloneGitRepo(destDir string) error {
 	return nil
 }
 
+// ResolveCommitWithContext returns the id of the commit the revision of the git url currently points to.
+// The revision is returned as is if it already is a full commit id, otherwise it is looked up in the remote repository.
+// A tag takes precedence over a branch of the same name, as it does when git checks out the revision.
+func (g *GitUrl) ResolveCommitWithContext(ctx context.Context) (string, error) {
+	if commitIDRegex.MatchString(g.Revision) {
+		return g.Revision, nil
+	}
+	revision := g.Revision
+	if revision == "" {
+		revision = "HEAD"
+	}
+	// the refs the revision may be, in order of precedence
+	refs := []string{revision}
+	if revision != "HEAD" && !strings.HasPrefix(revision, "refs/") {
+		refs = []string{"refs/tags/" + revision, "refs/heads/" + revision}
+	}
+	args := []string{"ls-remote", g.repoURL()}
+	for _, ref := range refs {
+		args = append(args, ref, ref+"^{}")
+	}
+
+	output, err := execute(ctx, "", "git", args...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("failed to resolve revision %v: %w", revision, ctx.Err())
+		}
+		return "", fmt.Errorf("failed to resolve revision %v of %s/%s. error: %v", revision, g.Owner, g.Repo, err)
+	}
+
+	commits := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && commitIDRegex.MatchString(fields[0]) {
+			commits[fields[1]] = fields[0]
+		}
+	}
+	commit := ""
+	for _, ref := range refs {
+		// an annotated tag is listed twice, the peeled entry with a ^{} suffix holds the id of the tagged commit
+		if commit = commits[ref+"^{}"]; commit == "" {
+			commit = commits[ref]
+		}
+		if commit != "" {
+			break
+		}
+	}
+	if commit == "" {
+		return "", fmt.Errorf("failed to resolve revision %v of %s/%s, revision not found", revision, g.Owner, g.Repo)
+	}
+	return commit, nil
+}
+
+// repoURL returns the URL to clone the repository from, including the token if one is set
+func (g *GitUrl) repoURL() string {
+	host := g.Host
+	if host == RawGitHubHost {
+		host = GitHubHost
+	}
+
+	if g.GetToken() == "" {
+		return fmt.Sprintf("%s://%s/%s/%s.git", g.Protocol, host, g.Owner, g.Repo)
+	}
+	if g.Host == BitbucketHost {
+		return fmt.Sprintf("%s://x-token-auth:%s@%s/%s/%s.git", g.Protocol, g.GetToken(), host, g.Owner, g.Repo)
+	}
+	return fmt.Sprintf("%s://token:%s@%s/%s/%s.git", g.Protocol, g.GetToken(), host, g.Owner, g.Repo)
+}
+
 func (g *GitUrl) parseGitHubUrl(url *url.URL) error {
 	var splitUrl []string
 	var err error
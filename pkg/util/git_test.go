@@ -16,9 +16,13 @@
 package util
 
 import (
+	"context"
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
@@ -468,3 +472,130 @@ func Test_CloneGitRepo(t *testing.T) {
 		})
 	}
 }
+
+func Test_CloneGitRepoWithContext(t *testing.T) {
+	gitUrl := GitUrl{
+		Protocol: "https",
+		Host:     "github.com",
+		Owner:    "devfile-resources",
+		Repo:     "python-src-docker",
+		Revision: "testbranch",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := gitUrl.CloneGitRepoWithContext(ctx, t.TempDir())
+	if err == nil {
+		t.Fatalf("expected an error when cloning with a cancelled context")
+	}
+	assert.True(t, errors.Is(err, context.Canceled), "error should wrap context.Canceled, got: %v", err)
+}
+
+func Test_ResolveCommitWithContext(t *testing.T) {
+	// create a local repository with a branch and an annotated tag, served through the file protocol
+	baseDir := t.TempDir()
+	repoDir := filepath.Join(baseDir, "repo.git")
+	gitCommands := [][]string{
+		{"init", "--initial-branch=main", repoDir},
+		{"-C", repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "first"},
+		{"-C", repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "tag", "-a", "v1.0.0", "-m", "v1.0.0"},
+		{"-C", repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "second"},
+		{"-C", repoDir, "branch", "release", "main"},
+		{"-C", repoDir, "branch", "v1.0.0", "main"},
+	}
+	for _, args := range gitCommands {
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, output)
+		}
+	}
+	revParse := func(revision string) string {
+		output, err := exec.Command("git", "-C", repoDir, "rev-parse", revision).Output()
+		if err != nil {
+			t.Fatalf("failed to resolve %s: %v", revision, err)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	mainCommit := revParse("main")
+	tagCommit := revParse("v1.0.0^{commit}")
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name       string
+		revision   string
+		ctx        context.Context
+		wantCommit string
+		wantErr    string
+	}{
+		{
+			name:       "should resolve a branch",
+			revision:   "main",
+			wantCommit: mainCommit,
+		},
+		{
+			name:       "should resolve the default branch",
+			wantCommit: mainCommit,
+		},
+		{
+			name:       "should resolve another branch",
+			revision:   "release",
+			wantCommit: mainCommit,
+		},
+		{
+			name:       "should resolve an annotated tag to the tagged commit",
+			revision:   "v1.0.0",
+			wantCommit: tagCommit,
+		},
+		{
+			name:       "should resolve a tag rather than the branch of the same name",
+			revision:   "v1.0.0",
+			wantCommit: tagCommit,
+		},
+		{
+			name:       "should resolve a qualified branch",
+			revision:   "refs/heads/v1.0.0",
+			wantCommit: mainCommit,
+		},
+		{
+			name:       "should return a full commit id as is",
+			revision:   tagCommit,
+			wantCommit: tagCommit,
+		},
+		{
+			name:     "should fail if the revision does not exist",
+			revision: "missing",
+			wantErr:  "failed to resolve revision missing of .*, revision not found",
+		},
+		{
+			name:     "should fail if the context is cancelled",
+			revision: "main",
+			ctx:      cancelledCtx,
+			wantErr:  "failed to resolve revision main: context canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitUrl := GitUrl{
+				Protocol: "file",
+				Owner:    strings.TrimPrefix(baseDir, "/"),
+				Repo:     "repo",
+				Revision: tt.revision,
+			}
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			commit, err := gitUrl.ResolveCommitWithContext(ctx)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Regexp(t, tt.wantErr, err.Error(), "Test_ResolveCommitWithContext(): Error message should match")
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCommit, commit)
+		})
+	}
+}
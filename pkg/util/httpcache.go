@@ -18,12 +18,34 @@ package util
 import (
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/gregjones/httpcache/diskcache"
 	"k8s.io/klog"
 )
 
-// cleanHttpCache checks cacheDir and deletes all files that were modified more than cacheTime back
+var (
+	httpCachesMu sync.Mutex
+	// httpCaches are the disk caches in use, keyed by directory. A single cache is used per directory, as the cache
+	// serializes its reads and writes so that concurrent requests never read a partially written response.
+	httpCaches = map[string]*diskcache.Cache{}
+)
+
+// getHttpCache returns the disk cache storing responses in cacheDir
+func getHttpCache(cacheDir string) *diskcache.Cache {
+	httpCachesMu.Lock()
+	defer httpCachesMu.Unlock()
+	cache, ok := httpCaches[cacheDir]
+	if !ok {
+		cache = diskcache.New(cacheDir)
+		httpCaches[cacheDir] = cache
+	}
+	return cache
+}
+
+// cleanHttpCache checks cacheDir and deletes all files that were modified more than cacheTime back.
+// Files removed meanwhile, e.g. by a concurrent request cleaning the cache, are ignored.
 func cleanHttpCache(cacheDir string, cacheTime time.Duration) error {
 	cacheEntries, err := os.ReadDir(cacheDir)
 	if err != nil {
@@ -33,6 +55,9 @@ func cleanHttpCache(cacheDir string, cacheTime time.Duration) error {
 	cacheFiles := make([]os.FileInfo, 0, len(cacheEntries))
 	for _, cacheEntry := range cacheEntries {
 		info, err := cacheEntry.Info()
+		if os.IsNotExist(err) {
+			continue
+		}
 		if err != nil {
 			return err
 		}
@@ -44,7 +69,7 @@ func cleanHttpCache(cacheDir string, cacheTime time.Duration) error {
 		if f.ModTime().Add(cacheTime).Before(time.Now()) {
 			klog.V(4).Infof("Removing cache file %s, because it is older than %s", f.Name(), cacheTime.String())
 			err := os.Remove(filepath.Join(cacheDir, f.Name()))
-			if err != nil {
+			if err != nil && !os.IsNotExist(err) {
 				return err
 			}
 		}
@@ -16,9 +16,11 @@
 package util
 
 import (
+	"archive/tar"
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/rand"
 	"fmt"
 	"io"
@@ -43,7 +45,6 @@ import (
 	gitpkg "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/gregjones/httpcache"
-	"github.com/gregjones/httpcache/diskcache"
 
 	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
 	"github.com/fatih/color"
@@ -95,7 +96,22 @@ type HTTPRequestParams struct {
 	URL                 string
 	Token               string
 	Timeout             *int
-	TelemetryClientName string //optional client name for telemetry
+	TelemetryClientName string             //optional client name for telemetry
+	Context             context.Context    // optional context used to cancel the request or bound it with a deadline
+	OnWarning           func(error)        // optional callback notified of the non-fatal errors, which are logged otherwise
+	Credentials         CredentialProvider // optional provider of the credentials for the host of the URL, used if Token is not set
+}
+
+// credentials returns the credentials of the request: its token if set, or the ones provided for the host of its URL
+func (params HTTPRequestParams) credentials() (*Credentials, error) {
+	if params.Token != "" {
+		return &Credentials{Token: params.Token}, nil
+	}
+	credentials, err := CredentialsForURL(params.Credentials, params.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the credentials of %s", params.URL)
+	}
+	return credentials, nil
 }
 
 // DownloadParams holds parameters of forming file download request
@@ -742,14 +758,15 @@ func GetRemoteFilesMarkedForDeletion(delSrcRelPaths []string, remoteFolder strin
 // cacheFor determines how long the response should be cached (in minutes), 0 for no caching
 func HTTPGetRequest(request HTTPRequestParams, cacheFor int) ([]byte, error) {
 	// Build http request
-	req, err := http.NewRequest("GET", request.URL, nil)
+	req, err := http.NewRequestWithContext(GetContextOrBackground(request.Context), "GET", request.URL, nil)
 	if err != nil {
 		return nil, err
 	}
-	if request.Token != "" {
-		bearer := "Bearer " + request.Token
-		req.Header.Add("Authorization", bearer)
+	credentials, err := request.credentials()
+	if err != nil {
+		return nil, err
 	}
+	credentials.SetAuthHeader(req.Header)
 
 	//add the telemetry client name
 	req.Header.Add("Client", request.TelemetryClientName)
@@ -787,16 +804,24 @@ func HTTPGetRequest(request HTTPRequestParams, cacheFor int) ([]byte, error) {
 		err = os.MkdirAll(httpCacheDir, 0750)
 		if err != nil {
 			cacheError = true
-			klog.WarningDepth(4, "Unable to setup cache: ", err)
+			if request.OnWarning != nil {
+				request.OnWarning(errors.Wrap(err, "unable to setup cache"))
+			} else {
+				klog.WarningDepth(4, "Unable to setup cache: ", err)
+			}
 		}
 		err = cleanHttpCache(httpCacheDir, httpCacheTime)
 		if err != nil {
 			cacheError = true
-			klog.WarningDepth(4, "Unable to clean up cache directory: ", err)
+			if request.OnWarning != nil {
+				request.OnWarning(errors.Wrap(err, "unable to clean up cache directory"))
+			} else {
+				klog.WarningDepth(4, "Unable to clean up cache directory: ", err)
+			}
 		}
 
 		if !cacheError {
-			httpClient.Transport = httpcache.NewTransport(diskcache.New(httpCacheDir))
+			httpClient.Transport = httpcache.NewTransport(getHttpCache(httpCacheDir))
 			klog.V(4).Infof("Response will be cached in %s for %s", httpCacheDir, httpCacheTime)
 		} else {
 			klog.V(4).Info("Response won't be cached.")
@@ -827,6 +852,14 @@ func HTTPGetRequest(request HTTPRequestParams, cacheFor int) ([]byte, error) {
 	return bytes, err
 }
 
+// GetContextOrBackground returns ctx, or context.Background() if ctx is nil
+func GetContextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
 // FilterIgnores applies the glob rules on the filesChanged and filesDeleted and filters them
 // returns the filtered results which match any of the glob rules
 func FilterIgnores(filesChanged, filesDeleted, absIgnoreRules []string) (filesChangedFiltered, filesDeletedFiltered []string) {
@@ -1048,6 +1081,56 @@ func Unzip(src, dest, pathToUnzip string) ([]string, error) {
 	return filenames, nil
 }
 
+// Untar decompresses the tar archive src into the dest directory, skipping the entries whose name is in excludedFiles
+func Untar(src, dest string, excludedFiles []string) error {
+	file, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if sliceContainsString(filepath.Base(header.Name), excludedFiles) {
+			continue
+		}
+
+		fpath := filepath.Join(dest, filepath.FromSlash(header.Name))
+		// Check for TarSlip, the same as ZipSlip for zip archives
+		if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("%s: illegal file path", fpath)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(fpath, os.ModePerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+				return err
+			}
+			outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, ModeReadWriteFile)
+			if err != nil {
+				return err
+			}
+			// limit the number of bytes copied from a file, as for zip archives
+			_, err = io.Copy(outFile, io.LimitReader(reader, 100*1024*1024))
+			_ = outFile.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // DownloadFileWithCache downloads the file to the filepath given URL and token (if applicable)
 // cacheFor determines how long the response should be cached (in minutes), 0 for no caching
 func DownloadFileWithCache(params DownloadParams, cacheFor int) error {
@@ -1109,7 +1192,12 @@ func DownloadInMemory(params HTTPRequestParams) ([]byte, error) {
 	var err error
 
 	if IsGitProviderRepo(params.URL) {
-		g, err = NewGitURL(params.URL, params.Token)
+		var credentials *Credentials
+		credentials, err = params.credentials()
+		if err != nil {
+			return nil, err
+		}
+		g, err = NewGitURL(params.URL, credentials.GitToken())
 		if err != nil {
 			return nil, errors.Errorf("failed to parse git repo. error: %v", err)
 		}
@@ -1121,21 +1209,31 @@ func DownloadInMemory(params HTTPRequestParams) ([]byte, error) {
 func (g *GitUrl) downloadInMemoryWithClient(params HTTPRequestParams, httpClient HTTPClient) ([]byte, error) {
 	var url string
 	url = params.URL
-	req, err := http.NewRequest("GET", url, nil)
+	ctx := GetContextOrBackground(params.Context)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	if IsGitProviderRepo(url) {
 		url = g.GitRawFileAPI()
-		req, err = http.NewRequest("GET", url, nil)
+		req, err = http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			return nil, err
 		}
 
 		if params.Token != "" {
 			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", params.Token))
+		} else if token := g.GetToken(); token != "" {
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		}
+	} else if params.Token == "" {
+		// the token is only sent to git providers, the credentials are provided for the host of the URL
+		credentials, err := params.credentials()
+		if err != nil {
+			return nil, err
 		}
+		credentials.SetAuthHeader(req.Header)
 	}
 
 	//add the telemetry client name in the header
@@ -16,6 +16,8 @@
 package util
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -28,6 +30,7 @@ import (
 	"runtime"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/devfile/library/v2/pkg/testingutil/filesystem"
 	"github.com/kylelemons/godebug/pretty"
@@ -795,6 +798,78 @@ func TestHTTPGetRequest(t *testing.T) {
 	}
 }
 
+func TestHTTPGetRequestCacheWarning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	// the cache directory cannot be created under a file
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, []byte{}, 0600); err != nil {
+		t.Fatalf("TestHTTPGetRequestCacheWarning() failed to write %s: %v", file, err)
+	}
+	defaultHttpCacheDir := httpCacheDir
+	httpCacheDir = filepath.Join(file, "cache")
+	defer func() {
+		httpCacheDir = defaultHttpCacheDir
+	}()
+
+	var warnings []error
+	got, err := HTTPGetRequest(HTTPRequestParams{
+		URL: server.URL,
+		OnWarning: func(warning error) {
+			warnings = append(warnings, warning)
+		},
+	}, 1)
+	if err != nil {
+		t.Fatalf("TestHTTPGetRequestCacheWarning() unexpected error: %v", err)
+	}
+	assert.Equal(t, []byte("OK"), got, "TestHTTPGetRequestCacheWarning(): the response should be returned without caching it")
+	if assert.NotEmpty(t, warnings, "TestHTTPGetRequestCacheWarning(): expected a warning about the cache") {
+		assert.Contains(t, warnings[0].Error(), "unable to setup cache")
+	}
+}
+
+func TestHTTPGetRequestWithContext(t *testing.T) {
+	// Start a local HTTP server that only returns once the request is cancelled
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer server.Close()
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	deadlineCtx, deadlineCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer deadlineCancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{
+			name:    "Case 1: Context is cancelled",
+			ctx:     cancelledCtx,
+			wantErr: context.Canceled,
+		},
+		{
+			name:    "Case 2: Context deadline is exceeded",
+			ctx:     deadlineCtx,
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := HTTPGetRequest(HTTPRequestParams{URL: server.URL, Context: tt.ctx}, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Got error: %v, want: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestFilterIgnores(t *testing.T) {
 	tests := []struct {
 		name             string
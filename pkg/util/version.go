@@ -0,0 +1,173 @@
+//
+// Copyright Red Hat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	versionpkg "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+)
+
+var versionComparison = regexp.MustCompile(`^(~>|>=|<=|!=|=|>|<|~|\^)?v?(.*)$`)
+
+// IsVersionConstraint returns true if version is a semver constraint supported by ParseVersionConstraint rather than an
+// exact version
+func IsVersionConstraint(version string) bool {
+	if _, err := versionpkg.NewVersion(version); err == nil {
+		return false
+	}
+	_, err := ParseVersionConstraint(version)
+	return err == nil
+}
+
+// ParseVersionConstraint parses a semver constraint made of ranges separated by ||, each range being made of
+// comparisons separated by commas or spaces. Besides the =, !=, >, >=, <, <= and ~> operators, the ~ operator allowing
+// patch updates, the ^ operator allowing the updates that do not change the leftmost non-zero number, and the x and *
+// wildcards are supported, e.g. ~2.1, ^1.2.3 or 2.x.
+func ParseVersionConstraint(constraint string) ([]versionpkg.Constraints, error) {
+	var ranges []versionpkg.Constraints
+	for _, versionRange := range strings.Split(constraint, "||") {
+		var comparisons []string
+		operator := ""
+		for _, field := range strings.Fields(strings.ReplaceAll(versionRange, ",", " ")) {
+			// the operator may be separated from its version, e.g. >= 1.2
+			if match := versionComparison.FindStringSubmatch(field); match[2] == "" {
+				operator += match[1]
+				continue
+			}
+			translated, err := translateComparison(operator + field)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid version constraint %s", constraint)
+			}
+			comparisons = append(comparisons, translated...)
+			operator = ""
+		}
+		if operator != "" || len(comparisons) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %s", constraint)
+		}
+		constraints, err := versionpkg.NewConstraint(strings.Join(comparisons, ", "))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version constraint %s", constraint)
+		}
+		ranges = append(ranges, constraints)
+	}
+	return ranges, nil
+}
+
+// translateComparison translates a comparison to the comparisons supported by go-version
+func translateComparison(comparison string) ([]string, error) {
+	match := versionComparison.FindStringSubmatch(comparison)
+	operator, version := match[1], match[2]
+	numbers, partial, err := versionNumbers(version)
+	if err != nil {
+		return nil, err
+	}
+	if !partial && operator != "~" && operator != "^" || operator == "~>" {
+		if operator == "" {
+			operator = "="
+		}
+		return []string{operator + " " + version}, nil
+	}
+	if !partial && len(numbers) == 0 {
+		return nil, fmt.Errorf("the operator %s cannot be used with the pre-release version %s", operator, version)
+	}
+	if len(numbers) == 0 {
+		// any version
+		switch operator {
+		case "<", "!=":
+			return nil, fmt.Errorf("no version matches %s", comparison)
+		}
+		return []string{">= 0.0.0"}, nil
+	}
+
+	lower := formatVersion(numbers)
+	upper := formatVersion(nextVersion(numbers, len(numbers)-1))
+	switch operator {
+	case "", "=":
+		return []string{">= " + lower, "< " + upper}, nil
+	case "~":
+		if len(numbers) > 1 {
+			upper = formatVersion(nextVersion(numbers, 1))
+		}
+		return []string{">= " + formatVersion(numbers), "< " + upper}, nil
+	case "^":
+		position := len(numbers) - 1
+		for i, number := range numbers {
+			if number != 0 {
+				position = i
+				break
+			}
+		}
+		return []string{">= " + formatVersion(numbers), "< " + formatVersion(nextVersion(numbers, position))}, nil
+	case ">":
+		return []string{">= " + upper}, nil
+	case ">=":
+		return []string{">= " + lower}, nil
+	case "<":
+		return []string{"< " + lower}, nil
+	case "<=":
+		return []string{"< " + upper}, nil
+	}
+	return nil, fmt.Errorf("the operator %s cannot be used with the partial version %s", operator, version)
+}
+
+// versionNumbers returns the numbers of a version before its first wildcard, and whether the version is partial, i.e.
+// has a wildcard or less than three numbers. Versions with a pre-release or build metadata are not partial.
+func versionNumbers(version string) ([]int, bool, error) {
+	if strings.ContainsAny(version, "-+") {
+		return nil, false, nil
+	}
+	var numbers []int
+	parts := strings.Split(version, ".")
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			for _, rest := range parts[i+1:] {
+				if rest != "x" && rest != "X" && rest != "*" {
+					return nil, false, fmt.Errorf("invalid version %s", version)
+				}
+			}
+			return numbers, true, nil
+		}
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return nil, false, fmt.Errorf("invalid version %s", version)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, len(numbers) < 3, nil
+}
+
+// nextVersion returns the version following the numbers when incrementing the number at position
+func nextVersion(numbers []int, position int) []int {
+	next := append([]int{}, numbers[:position+1]...)
+	next[position]++
+	return next
+}
+
+// formatVersion returns the version made of the numbers, completed with zeros
+func formatVersion(numbers []int) string {
+	parts := []string{"0", "0", "0"}
+	for i, number := range numbers {
+		if i < len(parts) {
+			parts[i] = strconv.Itoa(number)
+		}
+	}
+	return strings.Join(parts, ".")
+}